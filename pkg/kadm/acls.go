@@ -0,0 +1,867 @@
+package kadm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// ACLBuilder is a builder that is used for batch creating / listing / deleting
+// ACLS.
+//
+// An ACL consists of five components:
+//
+//   - the user (principal)
+//   - the host the user runs on
+//   - what resource to access (topic name, group id, etc.)
+//   - the operation (read, write)
+//   - whether to allow or deny the above
+//
+// This builder allows for adding the above five components in batches and
+// then creating, listing, or deleting a batch of ACLs in one go. This builder
+// merges the fifth component (allowing or denying) into allowing principals
+// and hosts and denying principals and hosts. The builder must always have an
+// Allow or Deny. For creating, the host is optional and defaults to the
+// wildcard * that allows or denies all hosts. For listing / deleting, the host
+// is also required (specifying no hosts matches all hosts, but you must
+// specify this).
+//
+// Building works on a multiplying factor: every user, every host, every
+// resource, and every operation is combined (principals * hosts * resources *
+// operations).
+//
+// With the Kafka simple authorizer (and most reimplementations), all
+// principals are required to have the "User:" prefix. The PrefixUser function
+// can be used to easily add the "User:" prefix if missing.
+//
+// The full set of operations and which requests require what operations is
+// described in a large doc comment on the ACLOperation type in the kmsg
+// package.
+//
+// Lastly, resources to access / deny access to can be created / matched based
+// on literal (exact) names, or on prefix names, or more. See the
+// ResourcePatternType function for more details.
+type ACLBuilder struct {
+	any         []string
+	anyResource bool
+	topics      []string
+	anyTopic    bool
+	groups      []string
+	anyGroup    bool
+	anyCluster  bool
+	txnIDs      []string
+	anyTxn      bool
+	tokens      []string
+	anyToken    bool
+
+	allow         []string
+	anyAllow      bool
+	allowHosts    []string
+	anyAllowHosts bool
+	deny          []string
+	anyDeny       bool
+	denyHosts     []string
+	anyDenyHosts  bool
+
+	ops []kmsg.ACLOperation
+
+	pattern kmsg.ACLResourcePatternType
+}
+
+// NewACLs returns a new ACL builder.
+func NewACLs() *ACLBuilder {
+	return new(ACLBuilder)
+}
+
+// AnyResource lists & deletes ACLs of any type matching the given names
+// (pending other filters). If no names are given, this matches all names.
+//
+// This returns the input pointer.
+//
+// This function does nothing for creating.
+func (b *ACLBuilder) AnyResource(name ...string) *ACLBuilder {
+	b.any = name
+	if len(name) == 0 {
+		b.anyResource = true
+	}
+	return b
+}
+
+// Topics sets topics to create ACLs for, or to match when listing / deleting.
+// For listing / deleting, if no topics are given, this matches all topics.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) Topics(t ...string) *ACLBuilder {
+	b.topics = t
+	if len(t) == 0 {
+		b.anyTopic = true
+	}
+	return b
+}
+
+// Groups sets groups to create ACLs for, or to match when listing / deleting.
+// For listing / deleting, if no groups are given, this matches all groups.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) Groups(g ...string) *ACLBuilder {
+	b.groups = g
+	if len(g) == 0 {
+		b.anyGroup = true
+	}
+	return b
+}
+
+// Clusters adds the cluster resource to create ACLs for, or to match when
+// listing / deleting.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) Clusters() *ACLBuilder {
+	b.anyCluster = true
+	return b
+}
+
+// TransactionalIDs sets transactional IDs to create ACLs for, or to match when
+// listing / deleting. For listing / deleting, if no IDs are given, this
+// matches all transactional IDs.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) TransactionalIDs(x ...string) *ACLBuilder {
+	b.txnIDs = x
+	if len(x) == 0 {
+		b.anyTxn = true
+	}
+	return b
+}
+
+// DelegationTokens sets delegation tokens to create ACLs for, or to match
+// when listing / deleting. For listing / deleting, if no tokens are given,
+// this matches all delegation tokens.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) DelegationTokens(t ...string) *ACLBuilder {
+	b.tokens = t
+	if len(t) == 0 {
+		b.anyToken = true
+	}
+	return b
+}
+
+// Allow sets principals to add allow permissions for. For listing and
+// deleting, you must also use AllowHosts.
+//
+// For listing / deleting, if no principals are given, this matches any
+// principal with an allow permission.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) Allow(principals ...string) *ACLBuilder {
+	b.allow = principals
+	if len(principals) == 0 {
+		b.anyAllow = true
+	}
+	return b
+}
+
+// AllowHosts sets hosts to add allow permissions for. If using this, you must
+// also use Allow.
+//
+// For creating, if this is not used, the host defaults to the wildcard "*"
+// which allows all hosts. For listing / deleting, if no hosts are given, this
+// matches any host.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) AllowHosts(hosts ...string) *ACLBuilder {
+	b.allowHosts = hosts
+	if len(hosts) == 0 {
+		b.anyAllowHosts = true
+	}
+	return b
+}
+
+// Deny sets principals to add deny permissions for. For listing and deleting,
+// you must also use DenyHosts.
+//
+// For listing / deleting, if no principals are given, this matches any
+// principal with a deny permission.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) Deny(principals ...string) *ACLBuilder {
+	b.deny = principals
+	if len(principals) == 0 {
+		b.anyDeny = true
+	}
+	return b
+}
+
+// DenyHosts sets hosts to add deny permissions for. If using this, you must
+// also use Deny.
+//
+// For creating, if this is not used, the host defaults to the wildcard "*"
+// which denies all hosts. For listing / deleting, if no hosts are given, this
+// matches any host.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) DenyHosts(hosts ...string) *ACLBuilder {
+	b.denyHosts = hosts
+	if len(hosts) == 0 {
+		b.anyDenyHosts = true
+	}
+	return b
+}
+
+// Operations sets operations to allow or deny. Passing no operations, or
+// passing kmsg.ACLOperationAny, matches any operation when listing /
+// deleting. Creating requires at least one specific operation.
+//
+// This returns the input pointer.
+func (b *ACLBuilder) Operations(operations ...kmsg.ACLOperation) *ACLBuilder {
+	b.ops = operations
+	return b
+}
+
+// ResourcePatternType sets the pattern type to use when creating or filtering
+// ACL resource names, overriding the default of LITERAL.
+//
+// For creating, only LITERAL and PREFIXED are supported.
+//
+// For listing / deleting, you can use any pattern type:
+//
+//   - ANY: matches any pattern type
+//   - MATCH: matches literal names, prefixed names that the name begins with,
+//     and the wildcard "*" name (i.e., every ACL that would apply to the name)
+//   - LITERAL: matches names exactly
+//   - PREFIXED: matches prefix ACLs exactly
+//
+// This returns the input pointer.
+func (b *ACLBuilder) ResourcePatternType(pattern kmsg.ACLResourcePatternType) *ACLBuilder {
+	b.pattern = pattern
+	return b
+}
+
+// PrefixUser prefixes all allowed and denied principals with "User:" if they
+// do not already contain a principal type (i.e., a colon).
+//
+// This returns the input pointer.
+func (b *ACLBuilder) PrefixUser() *ACLBuilder {
+	for _, ps := range []*[]string{&b.allow, &b.deny} {
+		for i, p := range *ps {
+			if !strings.Contains(p, ":") {
+				(*ps)[i] = "User:" + p
+			}
+		}
+	}
+	return b
+}
+
+// HasAnyFilter returns whether any field in this builder is opted into "any",
+// meaning a wide glob. This would be if you used Topics with no topics,
+// Allow with no principals, etc. If any field is opted into "any", the
+// builder can only be used for listing or deleting.
+func (b *ACLBuilder) HasAnyFilter() bool {
+	return b.anyResource ||
+		len(b.any) > 0 ||
+		b.anyTopic ||
+		b.anyGroup ||
+		b.anyTxn ||
+		b.anyToken ||
+		b.anyAllow ||
+		b.anyAllowHosts ||
+		b.anyDeny ||
+		b.anyDenyHosts ||
+		b.hasOpsAny() ||
+		b.pattern == kmsg.ACLResourcePatternTypeAny ||
+		b.pattern == kmsg.ACLResourcePatternTypeMatch
+}
+
+func (b *ACLBuilder) hasOpsAny() bool {
+	for _, op := range b.ops {
+		if op == kmsg.ACLOperationAny {
+			return true
+		}
+	}
+	return len(b.ops) == 0
+}
+
+// HasResource returns true if the builder has a non-empty resource (topic,
+// group, ...), or if any resource has "any" set to true.
+func (b *ACLBuilder) HasResource() bool {
+	return b.anyResource ||
+		len(b.any) > 0 ||
+		b.anyTopic ||
+		len(b.topics) > 0 ||
+		b.anyGroup ||
+		len(b.groups) > 0 ||
+		b.anyCluster ||
+		b.anyTxn ||
+		len(b.txnIDs) > 0 ||
+		b.anyToken ||
+		len(b.tokens) > 0
+}
+
+// HasPrincipals returns if any allow or deny principals have been set, or if
+// their "any" field is true.
+func (b *ACLBuilder) HasPrincipals() bool {
+	return b.anyAllow ||
+		len(b.allow) > 0 ||
+		b.anyDeny ||
+		len(b.deny) > 0
+}
+
+// HasHosts returns if any allow or deny hosts have been set, or if their "any"
+// field is true.
+func (b *ACLBuilder) HasHosts() bool {
+	return b.anyAllowHosts ||
+		len(b.allowHosts) > 0 ||
+		b.anyDenyHosts ||
+		len(b.denyHosts) > 0
+}
+
+// ValidateCreate returns an error if the builder is invalid for creating ACLs.
+func (b *ACLBuilder) ValidateCreate() error {
+	for _, field := range []struct {
+		name     string
+		any, has bool
+	}{
+		{"any", b.anyResource, len(b.any) > 0},
+		{"topics", b.anyTopic, len(b.topics) > 0},
+		{"groups", b.anyGroup, len(b.groups) > 0},
+		{"transactional IDs", b.anyTxn, len(b.txnIDs) > 0},
+		{"delegation tokens", b.anyToken, len(b.tokens) > 0},
+		{"allow", b.anyAllow, len(b.allow) > 0},
+		{"allow hosts", b.anyAllowHosts, len(b.allowHosts) > 0},
+		{"deny", b.anyDeny, len(b.deny) > 0},
+		{"deny hosts", b.anyDenyHosts, len(b.denyHosts) > 0},
+	} {
+		if field.name == "any" && (field.any || field.has) {
+			return errors.New("invalid use of AnyResource for creating ACLs")
+		}
+		if field.any && !field.has {
+			return fmt.Errorf("invalid empty %s for creating ACLs", field.name)
+		}
+	}
+
+	if len(b.allowHosts) != 0 && len(b.allow) == 0 {
+		return errors.New("invalid allow hosts with no allow principals")
+	}
+	if len(b.denyHosts) != 0 && len(b.deny) == 0 {
+		return errors.New("invalid deny hosts with no deny principals")
+	}
+	if !b.HasResource() {
+		return errors.New("invalid ACL builder with no resources for creating ACLs")
+	}
+	if !b.HasPrincipals() {
+		return errors.New("invalid ACL builder with no principals for creating ACLs")
+	}
+	if b.hasOpsAny() {
+		return errors.New("invalid ACL builder with no specific operations for creating ACLs")
+	}
+	for _, op := range b.ops {
+		if op == kmsg.ACLOperationUnknown {
+			return errors.New("invalid UNKNOWN operation for creating ACLs")
+		}
+	}
+	switch b.pattern {
+	case kmsg.ACLResourcePatternTypeLiteral, kmsg.ACLResourcePatternTypePrefixed, kmsg.ACLResourcePatternTypeUnknown:
+	default:
+		return fmt.Errorf("invalid resource pattern type %s for creating ACLs", b.pattern)
+	}
+	return nil
+}
+
+// ValidateDelete is an alias for ValidateFilter.
+func (b *ACLBuilder) ValidateDelete() error { return b.ValidateFilter() }
+
+// ValidateDescribe is an alias for ValidateFilter.
+func (b *ACLBuilder) ValidateDescribe() error { return b.ValidateFilter() }
+
+// ValidateFilter returns an error if the builder is invalid for deleting or
+// describing ACLs (which both operate on a filter basis).
+func (b *ACLBuilder) ValidateFilter() error {
+	if !b.HasResource() {
+		return errors.New("invalid ACL builder with no resources; use AnyResource to match all resources")
+	}
+	if (b.anyAllow || len(b.allow) > 0) != (b.anyAllowHosts || len(b.allowHosts) > 0) {
+		return errors.New("invalid ACL builder: filtering on allowed principals requires filtering on allowed hosts, and vice versa")
+	}
+	if (b.anyDeny || len(b.deny) > 0) != (b.anyDenyHosts || len(b.denyHosts) > 0) {
+		return errors.New("invalid ACL builder: filtering on denied principals requires filtering on denied hosts, and vice versa")
+	}
+	if !b.HasPrincipals() {
+		return errors.New("invalid ACL builder with no principals; use Allow/AllowHosts or Deny/DenyHosts with no arguments to match all")
+	}
+	for _, op := range b.ops {
+		if op == kmsg.ACLOperationUnknown {
+			return errors.New("invalid UNKNOWN operation for filtering ACLs")
+		}
+	}
+	return nil
+}
+
+// aclResource is a single resource type and name (name is nil for
+// filters that match any name).
+type aclResource struct {
+	typ  kmsg.ACLResourceType
+	name *string
+}
+
+// aclPrincipal is a principal / host / permission triple (principal or
+// host is nil for filters that match any).
+type aclPrincipal struct {
+	principal *string
+	host      *string
+	perm      kmsg.ACLPermissionType
+}
+
+func (b *ACLBuilder) resources() []aclResource {
+	var rs []aclResource
+	add := func(typ kmsg.ACLResourceType, any bool, names []string) {
+		if any && len(names) == 0 {
+			rs = append(rs, aclResource{typ, nil})
+		}
+		for _, name := range names {
+			rs = append(rs, aclResource{typ, kmsg.StringPtr(name)})
+		}
+	}
+	add(kmsg.ACLResourceTypeAny, b.anyResource, b.any)
+	add(kmsg.ACLResourceTypeTopic, b.anyTopic, b.topics)
+	add(kmsg.ACLResourceTypeGroup, b.anyGroup, b.groups)
+	if b.anyCluster {
+		rs = append(rs, aclResource{kmsg.ACLResourceTypeCluster, kmsg.StringPtr("kafka-cluster")})
+	}
+	add(kmsg.ACLResourceTypeTransactionalId, b.anyTxn, b.txnIDs)
+	add(kmsg.ACLResourceTypeDelegationToken, b.anyToken, b.tokens)
+	return rs
+}
+
+// principals returns the principal / host combinations for the builder. If
+// creating, an unspecified host defaults to "*"; otherwise, it matches any
+// host.
+func (b *ACLBuilder) principals(creating bool) []aclPrincipal {
+	var ps []aclPrincipal
+	add := func(perm kmsg.ACLPermissionType, principals, hosts []string) {
+		if creating && len(hosts) == 0 {
+			hosts = []string{"*"}
+		}
+		pptrs := []*string{nil}
+		if len(principals) > 0 {
+			pptrs = pptrs[:0]
+			for _, p := range principals {
+				pptrs = append(pptrs, kmsg.StringPtr(p))
+			}
+		}
+		hptrs := []*string{nil}
+		if len(hosts) > 0 {
+			hptrs = hptrs[:0]
+			for _, h := range hosts {
+				hptrs = append(hptrs, kmsg.StringPtr(h))
+			}
+		}
+		for _, p := range pptrs {
+			for _, h := range hptrs {
+				ps = append(ps, aclPrincipal{p, h, perm})
+			}
+		}
+	}
+	if b.anyAllow || len(b.allow) > 0 {
+		add(kmsg.ACLPermissionTypeAllow, b.allow, b.allowHosts)
+	}
+	if b.anyDeny || len(b.deny) > 0 {
+		add(kmsg.ACLPermissionTypeDeny, b.deny, b.denyHosts)
+	}
+	return ps
+}
+
+func (b *ACLBuilder) operations() []kmsg.ACLOperation {
+	if len(b.ops) == 0 {
+		return []kmsg.ACLOperation{kmsg.ACLOperationAny}
+	}
+	return b.ops
+}
+
+func (b *ACLBuilder) patternType() kmsg.ACLResourcePatternType {
+	if b.pattern == kmsg.ACLResourcePatternTypeUnknown {
+		return kmsg.ACLResourcePatternTypeLiteral
+	}
+	return b.pattern
+}
+
+// aclFilter is a single filter built from an ACL builder.
+type aclFilter struct {
+	aclResource
+	aclPrincipal
+	pattern kmsg.ACLResourcePatternType
+	op      kmsg.ACLOperation
+}
+
+func (b *ACLBuilder) filters(creating bool) []aclFilter {
+	var fs []aclFilter
+	for _, r := range b.resources() {
+		for _, p := range b.principals(creating) {
+			for _, op := range b.operations() {
+				fs = append(fs, aclFilter{r, p, b.patternType(), op})
+			}
+		}
+	}
+	return fs
+}
+
+// CreateACLsResult is a result for an individual ACL creation.
+type CreateACLsResult struct {
+	Principal string
+	Host      string
+
+	Type       kmsg.ACLResourceType        // Type is the type of resource this is.
+	Name       string                      // Name is the name of the resource allowed / denied.
+	Pattern    kmsg.ACLResourcePatternType // Pattern is the name pattern.
+	Operation  kmsg.ACLOperation           // Operation is the operation allowed / denied.
+	Permission kmsg.ACLPermissionType      // Permission is whether this is allowed / denied.
+
+	Err error // Err is the error for this ACL creation.
+}
+
+// CreateACLsResults contains all results to created ACLs.
+type CreateACLsResults []CreateACLsResult
+
+// EachError calls fn for every result that has a non-nil error.
+func (rs CreateACLsResults) EachError(fn func(CreateACLsResult)) {
+	for _, r := range rs {
+		if r.Err != nil {
+			fn(r)
+		}
+	}
+}
+
+// CreateACLs creates a batch of ACLs using the ACL builder, validating the
+// input before issuing the CreateACLs request.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses. This only returns an
+// error if the builder is invalid or if the request fails to be issued.
+func (cl *Client) CreateACLs(ctx context.Context, b *ACLBuilder) (CreateACLsResults, error) {
+	if err := b.ValidateCreate(); err != nil {
+		return nil, err
+	}
+
+	fs := b.filters(true)
+	resp, err := createACLsReq(fs).RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) != len(fs) {
+		return nil, fmt.Errorf("received %d results to %d creations", len(resp.Results), len(fs))
+	}
+
+	var rs CreateACLsResults
+	for i, r := range resp.Results {
+		f := fs[i]
+		rs = append(rs, CreateACLsResult{
+			Principal: *f.principal,
+			Host:      *f.host,
+
+			Type:       f.typ,
+			Name:       *f.name,
+			Pattern:    f.pattern,
+			Operation:  f.op,
+			Permission: f.perm,
+
+			Err: kerr.ErrorForCode(r.ErrorCode),
+		})
+	}
+	return rs, nil
+}
+
+// createACLsReq returns a request creating one ACL per filter. Filters built
+// for creating always have a name, principal, and host.
+func createACLsReq(fs []aclFilter) *kmsg.CreateACLsRequest {
+	req := kmsg.NewPtrCreateACLsRequest()
+	for _, f := range fs {
+		c := kmsg.NewCreateACLsRequestCreation()
+		c.ResourceType = f.typ
+		c.ResourceName = *f.name
+		c.ResourcePatternType = f.pattern
+		c.Principal = *f.principal
+		c.Host = *f.host
+		c.Operation = f.op
+		c.PermissionType = f.perm
+		req.Creations = append(req.Creations, c)
+	}
+	return req
+}
+
+// DescribedACL is an ACL that was described.
+type DescribedACL struct {
+	Principal string // Principal is this described ACL's principal.
+	Host      string // Host is this described ACL's host.
+
+	Type       kmsg.ACLResourceType        // Type is this described ACL's resource type.
+	Name       string                      // Name is this described ACL's resource name.
+	Pattern    kmsg.ACLResourcePatternType // Pattern is this described ACL's resource name pattern.
+	Operation  kmsg.ACLOperation           // Operation is this described ACL's operation.
+	Permission kmsg.ACLPermissionType      // Permission this described ACLs permission.
+}
+
+// DescribedACLs contains ACLs that were described.
+type DescribedACLs []DescribedACL
+
+// DescribeACLsResult contains the input used for a describe ACL filter, and the
+// describes that the filter matched.
+type DescribeACLsResult struct {
+	Principal *string // Principal is the describe filter's principal, nil if any.
+	Host      *string // Host is the describe filter's host, nil if any.
+
+	Type       kmsg.ACLResourceType        // Type is the describe filter's resource type.
+	Name       *string                     // Name is the describe filter's resource name, nil if any.
+	Pattern    kmsg.ACLResourcePatternType // Pattern is the describe filter's resource name pattern.
+	Operation  kmsg.ACLOperation           // Operation is the describe filter's operation.
+	Permission kmsg.ACLPermissionType      // Permission is the describe filter's permission.
+
+	Described DescribedACLs // Described contains all ACLs this describe filter matched.
+
+	Err error // Err is non-nil if this filter has an error.
+}
+
+// DescribeACLsResults contains all results to described ACLs.
+type DescribeACLsResults []DescribeACLsResult
+
+// EachError calls fn for every result that has a non-nil error.
+func (rs DescribeACLsResults) EachError(fn func(DescribeACLsResult)) {
+	for _, r := range rs {
+		if r.Err != nil {
+			fn(r)
+		}
+	}
+}
+
+// DescribeACLs describes a batch of ACLs using the ACL builder, validating the
+// input before issuing DescribeACLs requests.
+//
+// The DescribeACLs request only supports one filter at a time, so this issues
+// one request per filter (every resource * principal * host * operation
+// combination in the builder) concurrently.
+//
+// This returns an *AuthError if you are not authorized to describe ACLs. This
+// may return *ShardErrors if individual requests fail to be issued; the
+// results for those filters have their Err field set.
+func (cl *Client) DescribeACLs(ctx context.Context, b *ACLBuilder) (DescribeACLsResults, error) {
+	if err := b.ValidateDescribe(); err != nil {
+		return nil, err
+	}
+
+	var (
+		fs      = b.filters(false)
+		reqs    = describeACLsReqs(fs)
+		results = make([]kgo.ResponseShard, len(fs))
+		wg      sync.WaitGroup
+	)
+	for i := range reqs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			shards := cl.cl.RequestSharded(ctx, reqs[i])
+			results[i] = shards[0]
+		}(i)
+	}
+	wg.Wait()
+
+	var (
+		rs  DescribeACLsResults
+		idx int
+	)
+	for _, f := range fs {
+		rs = append(rs, DescribeACLsResult{
+			Principal:  f.principal,
+			Host:       f.host,
+			Type:       f.typ,
+			Name:       f.name,
+			Pattern:    f.pattern,
+			Operation:  f.op,
+			Permission: f.perm,
+		})
+	}
+	for i, shard := range results {
+		if shard.Err != nil {
+			rs[i].Err = shard.Err
+		}
+	}
+
+	// Shards are processed in order, but failing shards are skipped, so
+	// we track the index of the next successful result.
+	err := shardErrEach(reqs[0], results, func(kr kmsg.Response) error {
+		for results[idx].Err != nil {
+			idx++
+		}
+		r := &rs[idx]
+		idx++
+
+		resp := kr.(*kmsg.DescribeACLsResponse)
+		if err := maybeAuthErr(resp.ErrorCode); err != nil {
+			return err
+		}
+		if r.Err = kerr.ErrorForCode(resp.ErrorCode); r.Err != nil {
+			return nil
+		}
+		for _, resource := range resp.Resources {
+			for _, acl := range resource.ACLs {
+				r.Described = append(r.Described, DescribedACL{
+					Principal:  acl.Principal,
+					Host:       acl.Host,
+					Type:       resource.ResourceType,
+					Name:       resource.ResourceName,
+					Pattern:    resource.ResourcePatternType,
+					Operation:  acl.Operation,
+					Permission: acl.PermissionType,
+				})
+			}
+		}
+		return nil
+	})
+	var ae *AuthError
+	if errors.As(err, &ae) {
+		return nil, err
+	}
+	return rs, err
+}
+
+// describeACLsReqs returns one request per filter, since DescribeACLs only
+// supports a single filter per request.
+func describeACLsReqs(fs []aclFilter) []*kmsg.DescribeACLsRequest {
+	reqs := make([]*kmsg.DescribeACLsRequest, 0, len(fs))
+	for _, f := range fs {
+		req := kmsg.NewPtrDescribeACLsRequest()
+		req.ResourceType = f.typ
+		req.ResourceName = f.name
+		req.ResourcePatternType = f.pattern
+		req.Principal = f.principal
+		req.Host = f.host
+		req.Operation = f.op
+		req.PermissionType = f.perm
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+// DeletedACL an ACL that was deleted.
+type DeletedACL struct {
+	Principal string // Principal is this deleted ACL's principal.
+	Host      string // Host is this deleted ACL's host.
+
+	Type       kmsg.ACLResourceType        // Type is this deleted ACL's resource type.
+	Name       string                      // Name is this deleted ACL's resource name.
+	Pattern    kmsg.ACLResourcePatternType // Pattern is this deleted ACL's resource name pattern.
+	Operation  kmsg.ACLOperation           // Operation is this deleted ACL's operation.
+	Permission kmsg.ACLPermissionType      // Permission this deleted ACLs permission.
+
+	Err error // Err is non-nil if this match has an error.
+}
+
+// DeletedACLs contains ACLs that were deleted from a single delete filter.
+type DeletedACLs []DeletedACL
+
+// DeleteACLsResult contains the input used for a delete ACL filter, and the
+// deletions that the filter matched.
+type DeleteACLsResult struct {
+	Principal *string // Principal is the delete filter's principal, nil if any.
+	Host      *string // Host is the delete filter's host, nil if any.
+
+	Type       kmsg.ACLResourceType        // Type is the delete filter's resource type.
+	Name       *string                     // Name is the delete filter's resource name, nil if any.
+	Pattern    kmsg.ACLResourcePatternType // Pattern is the delete filter's resource name pattern.
+	Operation  kmsg.ACLOperation           // Operation is the delete filter's operation.
+	Permission kmsg.ACLPermissionType      // Permission is the delete filter's permission.
+
+	Deleted DeletedACLs // Deleted contains all ACLs this delete filter matched.
+
+	Err error // Err is non-nil if this filter has an error.
+}
+
+// DeleteACLsResults contains all results to deleted ACLs.
+type DeleteACLsResults []DeleteACLsResult
+
+// EachError calls fn for every result that has a non-nil error, including
+// per-ACL errors within a filter's matches.
+func (rs DeleteACLsResults) EachError(fn func(DeleteACLsResult)) {
+	for _, r := range rs {
+		if r.Err != nil {
+			fn(r)
+			continue
+		}
+		for _, d := range r.Deleted {
+			if d.Err != nil {
+				fn(r)
+				break
+			}
+		}
+	}
+}
+
+// DeleteACLs deletes a batch of ACLs using the ACL builder, validating the
+// input before issuing the DeleteACLs request.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses. This only returns an
+// error if the builder is invalid or if the request fails to be issued.
+func (cl *Client) DeleteACLs(ctx context.Context, b *ACLBuilder) (DeleteACLsResults, error) {
+	if err := b.ValidateDelete(); err != nil {
+		return nil, err
+	}
+
+	fs := b.filters(false)
+	resp, err := deleteACLsReq(fs).RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) != len(fs) {
+		return nil, fmt.Errorf("received %d results to %d filters", len(resp.Results), len(fs))
+	}
+
+	var rs DeleteACLsResults
+	for i, r := range resp.Results {
+		f := fs[i]
+		dr := DeleteACLsResult{
+			Principal:  f.principal,
+			Host:       f.host,
+			Type:       f.typ,
+			Name:       f.name,
+			Pattern:    f.pattern,
+			Operation:  f.op,
+			Permission: f.perm,
+			Err:        kerr.ErrorForCode(r.ErrorCode),
+		}
+		for _, m := range r.MatchingACLs {
+			dr.Deleted = append(dr.Deleted, DeletedACL{
+				Principal:  m.Principal,
+				Host:       m.Host,
+				Type:       m.ResourceType,
+				Name:       m.ResourceName,
+				Pattern:    m.ResourcePatternType,
+				Operation:  m.Operation,
+				Permission: m.PermissionType,
+				Err:        kerr.ErrorForCode(m.ErrorCode),
+			})
+		}
+		rs = append(rs, dr)
+	}
+	return rs, nil
+}
+
+// deleteACLsReq returns a request with one delete filter per filter.
+func deleteACLsReq(fs []aclFilter) *kmsg.DeleteACLsRequest {
+	req := kmsg.NewPtrDeleteACLsRequest()
+	for _, f := range fs {
+		rf := kmsg.NewDeleteACLsRequestFilter()
+		rf.ResourceType = f.typ
+		rf.ResourceName = f.name
+		rf.ResourcePatternType = f.pattern
+		rf.Principal = f.principal
+		rf.Host = f.host
+		rf.Operation = f.op
+		rf.PermissionType = f.perm
+		req.Filters = append(req.Filters, rf)
+	}
+	return req
+}
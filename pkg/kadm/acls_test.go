@@ -0,0 +1,160 @@
+package kadm
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCreateACLsReq(t *testing.T) {
+	b := NewACLs().
+		Topics("a", "b").
+		Allow("x").
+		PrefixUser().
+		Operations(kmsg.ACLOperationRead, kmsg.ACLOperationWrite)
+	if err := b.ValidateCreate(); err != nil {
+		t.Fatalf("unexpected validate err: %v", err)
+	}
+
+	req := createACLsReq(b.filters(true))
+
+	type creation struct {
+		name string
+		op   kmsg.ACLOperation
+	}
+	exp := []creation{
+		{"a", kmsg.ACLOperationRead},
+		{"a", kmsg.ACLOperationWrite},
+		{"b", kmsg.ACLOperationRead},
+		{"b", kmsg.ACLOperationWrite},
+	}
+	if len(req.Creations) != len(exp) {
+		t.Fatalf("got %d creations != exp %d", len(req.Creations), len(exp))
+	}
+	for i, c := range req.Creations {
+		if got := (creation{c.ResourceName, c.Operation}); got != exp[i] {
+			t.Errorf("creation %d: got %v != exp %v", i, got, exp[i])
+		}
+		if c.ResourceType != kmsg.ACLResourceTypeTopic ||
+			c.ResourcePatternType != kmsg.ACLResourcePatternTypeLiteral ||
+			c.Principal != "User:x" ||
+			c.Host != "*" ||
+			c.PermissionType != kmsg.ACLPermissionTypeAllow {
+			t.Errorf("creation %d: got unexpected %+v", i, c)
+		}
+	}
+}
+
+func TestDescribeACLsReqsFanOut(t *testing.T) {
+	b := NewACLs().
+		AnyResource().
+		Topics().
+		Allow().AllowHosts().
+		Deny().DenyHosts().
+		ResourcePatternType(kmsg.ACLResourcePatternTypeAny)
+	if err := b.ValidateDescribe(); err != nil {
+		t.Fatalf("unexpected validate err: %v", err)
+	}
+
+	reqs := describeACLsReqs(b.filters(false))
+
+	// Each resource type is paired with each permission, one request each.
+	type filter struct {
+		typ  kmsg.ACLResourceType
+		perm kmsg.ACLPermissionType
+	}
+	exp := []filter{
+		{kmsg.ACLResourceTypeAny, kmsg.ACLPermissionTypeAllow},
+		{kmsg.ACLResourceTypeAny, kmsg.ACLPermissionTypeDeny},
+		{kmsg.ACLResourceTypeTopic, kmsg.ACLPermissionTypeAllow},
+		{kmsg.ACLResourceTypeTopic, kmsg.ACLPermissionTypeDeny},
+	}
+	if len(reqs) != len(exp) {
+		t.Fatalf("got %d requests != exp %d", len(reqs), len(exp))
+	}
+	for i, req := range reqs {
+		if got := (filter{req.ResourceType, req.PermissionType}); got != exp[i] {
+			t.Errorf("request %d: got %v != exp %v", i, got, exp[i])
+		}
+		if req.ResourceName != nil || req.Principal != nil || req.Host != nil {
+			t.Errorf("request %d: got non-nil name, principal, or host, exp all nil to match any", i)
+		}
+		if req.ResourcePatternType != kmsg.ACLResourcePatternTypeAny || req.Operation != kmsg.ACLOperationAny {
+			t.Errorf("request %d: got pattern %v op %v, exp ANY ANY", i, req.ResourcePatternType, req.Operation)
+		}
+	}
+}
+
+func TestDeleteACLsReq(t *testing.T) {
+	b := NewACLs().
+		Groups("g").
+		Deny("User:y").
+		DenyHosts("h1", "h2").
+		ResourcePatternType(kmsg.ACLResourcePatternTypePrefixed)
+	if err := b.ValidateDelete(); err != nil {
+		t.Fatalf("unexpected validate err: %v", err)
+	}
+
+	req := deleteACLsReq(b.filters(false))
+	if len(req.Filters) != 2 {
+		t.Fatalf("got %d filters != exp 2", len(req.Filters))
+	}
+	for i, f := range req.Filters {
+		if exp := []string{"h1", "h2"}[i]; f.Host == nil || *f.Host != exp {
+			t.Errorf("filter %d: got host %v != exp %s", i, f.Host, exp)
+		}
+		if f.ResourceType != kmsg.ACLResourceTypeGroup ||
+			f.ResourceName == nil || *f.ResourceName != "g" ||
+			f.ResourcePatternType != kmsg.ACLResourcePatternTypePrefixed ||
+			f.Principal == nil || *f.Principal != "User:y" ||
+			f.Operation != kmsg.ACLOperationAny ||
+			f.PermissionType != kmsg.ACLPermissionTypeDeny {
+			t.Errorf("filter %d: got unexpected %+v", i, f)
+		}
+	}
+}
+
+func TestACLBuilderValidate(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		b         *ACLBuilder
+		createErr bool
+		filterErr bool
+	}{
+		{
+			name:      "no resources",
+			b:         NewACLs().Allow("User:x").AllowHosts(),
+			createErr: true,
+			filterErr: true,
+		},
+		{
+			name:      "any resource cannot be created",
+			b:         NewACLs().AnyResource().Allow("User:x").AllowHosts().Operations(kmsg.ACLOperationRead),
+			createErr: true,
+		},
+		{
+			name:      "creating requires specific operations",
+			b:         NewACLs().Topics("t").Allow("User:x").AllowHosts("*"),
+			createErr: true,
+		},
+		{
+			name:      "filtering principals requires hosts",
+			b:         NewACLs().Topics("t").Allow("User:x").Operations(kmsg.ACLOperationRead),
+			filterErr: true,
+		},
+		{
+			name:      "creating cannot use match patterns",
+			b:         NewACLs().Topics("t").Allow("User:x").AllowHosts("h").Operations(kmsg.ACLOperationRead).ResourcePatternType(kmsg.ACLResourcePatternTypeMatch),
+			createErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.b.ValidateCreate(); (err != nil) != test.createErr {
+				t.Errorf("create: got err? %v (%v), exp err? %v", err != nil, err, test.createErr)
+			}
+			if err := test.b.ValidateFilter(); (err != nil) != test.filterErr {
+				t.Errorf("filter: got err? %v (%v), exp err? %v", err != nil, err, test.filterErr)
+			}
+		})
+	}
+}
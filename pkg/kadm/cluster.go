@@ -0,0 +1,224 @@
+package kadm
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// DescribedCluster is the result of describing the cluster.
+type DescribedCluster struct {
+	Cluster    string        // Cluster is the cluster ID.
+	Controller int32         // Controller is the node ID of the controller broker, if available, otherwise -1.
+	Brokers    BrokerDetails // Brokers contains broker details, sorted by node ID.
+
+	// AuthorizedOperations contains the operations the client is
+	// authorized to perform on the cluster, if requested. This is nil if
+	// authorized operations were not requested.
+	AuthorizedOperations []kmsg.ACLOperation
+}
+
+// DescribeCluster describes the cluster with a DescribeCluster request, which
+// requires Kafka 2.8+. Unlike ListBrokers, this request can return the
+// operations the client is authorized to perform on the cluster; pass true
+// to request them.
+//
+// This returns an error if the request fails to be issued, or an *AuthError.
+func (cl *Client) DescribeCluster(ctx context.Context, includeAuthorizedOperations bool) (DescribedCluster, error) {
+	req := kmsg.NewPtrDescribeClusterRequest()
+	req.IncludeClusterAuthorizedOperations = includeAuthorizedOperations
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return DescribedCluster{}, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return DescribedCluster{}, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return DescribedCluster{}, err
+	}
+
+	d := DescribedCluster{
+		Cluster:    resp.ClusterID,
+		Controller: resp.ControllerID,
+	}
+	for _, b := range resp.Brokers {
+		d.Brokers = append(d.Brokers, BrokerDetail{
+			NodeID: b.NodeID,
+			Host:   b.Host,
+			Port:   b.Port,
+			Rack:   b.Rack,
+		})
+	}
+	sort.Slice(d.Brokers, func(i, j int) bool { return d.Brokers[i].NodeID < d.Brokers[j].NodeID })
+	if includeAuthorizedOperations {
+		d.AuthorizedOperations = decodeACLOperations(resp.ClusterAuthorizedOperations)
+	}
+	return d, nil
+}
+
+// decodeACLOperations decodes an authorized operations bitfield, where each
+// set bit is the ACLOperation of the same number. The minimum int32 means
+// the operations were not requested (or are unknown).
+func decodeACLOperations(bitfield int32) []kmsg.ACLOperation {
+	ops := []kmsg.ACLOperation{}
+	if bitfield == math.MinInt32 {
+		return ops
+	}
+	for i := uint(0); i < 32; i++ {
+		if bitfield&(1<<i) != 0 {
+			ops = append(ops, kmsg.ACLOperation(i))
+		}
+	}
+	return ops
+}
+
+// SupportedFeature is a feature a broker supports, and the range of versions
+// the broker supports for the feature.
+type SupportedFeature struct {
+	Name       string // Name is the name of the feature.
+	MinVersion int16  // MinVersion is the minimum version of this feature the broker supports.
+	MaxVersion int16  // MaxVersion is the maximum version of this feature the broker supports.
+}
+
+// FinalizedFeature is a feature that is finalized cluster wide, and the range
+// of version levels the feature is finalized at.
+type FinalizedFeature struct {
+	Name            string // Name is the name of the feature.
+	MinVersionLevel int16  // MinVersionLevel is the cluster-wide finalized min version level for this feature.
+	MaxVersionLevel int16  // MaxVersionLevel is the cluster-wide finalized max version level for this feature.
+}
+
+// DescribedFeatures contains the features a broker supports and the features
+// that are finalized cluster wide.
+type DescribedFeatures struct {
+	Supported      []SupportedFeature // Supported are the features the broker supports, sorted by name.
+	Finalized      []FinalizedFeature // Finalized are the cluster-wide finalized features, sorted by name.
+	FinalizedEpoch int64              // FinalizedEpoch is the monotonically increasing epoch for the finalized features, or -1 if unknown.
+}
+
+// DescribeFeatures describes the features a broker supports and the features
+// that are finalized cluster wide, as returned in an ApiVersions response.
+// Features were introduced in Kafka 2.7; older brokers return no features.
+//
+// This returns an error if the request fails to be issued.
+func (cl *Client) DescribeFeatures(ctx context.Context) (DescribedFeatures, error) {
+	req := kmsg.NewPtrApiVersionsRequest()
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return DescribedFeatures{}, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return DescribedFeatures{}, err
+	}
+
+	d := DescribedFeatures{FinalizedEpoch: resp.FinalizedFeaturesEpoch}
+	for _, f := range resp.SupportedFeatures {
+		d.Supported = append(d.Supported, SupportedFeature{
+			Name:       f.Name,
+			MinVersion: f.MinVersion,
+			MaxVersion: f.MaxVersion,
+		})
+	}
+	for _, f := range resp.FinalizedFeatures {
+		d.Finalized = append(d.Finalized, FinalizedFeature{
+			Name:            f.Name,
+			MinVersionLevel: f.MinVersionLevel,
+			MaxVersionLevel: f.MaxVersionLevel,
+		})
+	}
+	sort.Slice(d.Supported, func(i, j int) bool { return d.Supported[i].Name < d.Supported[j].Name })
+	sort.Slice(d.Finalized, func(i, j int) bool { return d.Finalized[i].Name < d.Finalized[j].Name })
+	return d, nil
+}
+
+// UpdateFeature is a feature to update and the new max version level for the
+// feature.
+type UpdateFeature struct {
+	Name string // Name is the name of the feature to update.
+
+	// MaxVersionLevel is the new cluster-wide finalized max version level
+	// for the feature. A value less than 1 deletes the finalized feature.
+	MaxVersionLevel int16
+
+	// AllowDowngrade allows the max version level to be lowered (or the
+	// feature to be deleted). Without this, the update fails if the new
+	// level is lower than the current level.
+	AllowDowngrade bool
+}
+
+// UpdateFeatureResponse contains the response for an individual feature
+// update.
+type UpdateFeatureResponse struct {
+	Name string // Name is the name of the feature that was updated.
+	Err  error  // Err is non-nil if the feature could not be updated.
+}
+
+// UpdateFeaturesResponses contains responses for feature updates, keyed by
+// feature name.
+type UpdateFeaturesResponses map[string]UpdateFeatureResponse
+
+// Sorted returns the responses sorted by feature name.
+func (rs UpdateFeaturesResponses) Sorted() []UpdateFeatureResponse {
+	s := make([]UpdateFeatureResponse, 0, len(rs))
+	for _, r := range rs {
+		s = append(s, r)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name })
+	return s
+}
+
+// Error returns the first error in the responses, if any.
+func (rs UpdateFeaturesResponses) Error() error {
+	for _, r := range rs {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
+
+// UpdateFeatures updates cluster-wide finalized feature version levels. This
+// requires Kafka 2.7+. See KIP-584 for more details.
+//
+// A top-level error in the response (for example, the request not being sent
+// to the controller) is set as the error for every feature in the request.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses. This only returns an
+// error if the request fails to be issued.
+func (cl *Client) UpdateFeatures(ctx context.Context, updates ...UpdateFeature) (UpdateFeaturesResponses, error) {
+	if len(updates) == 0 {
+		return make(UpdateFeaturesResponses), nil
+	}
+	req := kmsg.NewPtrUpdateFeaturesRequest()
+	for _, u := range updates {
+		ru := kmsg.NewUpdateFeaturesRequestFeatureUpdate()
+		ru.Feature = u.Name
+		ru.MaxVersionLevel = u.MaxVersionLevel
+		ru.AllowDowngrade = u.AllowDowngrade
+		req.FeatureUpdates = append(req.FeatureUpdates, ru)
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := make(UpdateFeaturesResponses)
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		for _, u := range updates {
+			rs[u.Name] = UpdateFeatureResponse{Name: u.Name, Err: err}
+		}
+		return rs, nil
+	}
+	for _, r := range resp.Results {
+		rs[r.Feature] = UpdateFeatureResponse{
+			Name: r.Feature,
+			Err:  kerr.ErrorForCode(r.ErrorCode),
+		}
+	}
+	return rs, nil
+}
@@ -0,0 +1,37 @@
+package kadm
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestDecodeACLOperations(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		bitfield int32
+		exp      []kmsg.ACLOperation
+	}{
+		{"not requested", math.MinInt32, []kmsg.ACLOperation{}},
+		{"none", 0, []kmsg.ACLOperation{}},
+		{
+			"read write describe",
+			1<<kmsg.ACLOperationRead | 1<<kmsg.ACLOperationWrite | 1<<kmsg.ACLOperationDescribe,
+			[]kmsg.ACLOperation{kmsg.ACLOperationRead, kmsg.ACLOperationWrite, kmsg.ACLOperationDescribe},
+		},
+		{
+			"low and high bits",
+			1<<0 | 1<<30,
+			[]kmsg.ACLOperation{0, 30},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := decodeACLOperations(test.bitfield)
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("got %v != exp %v", got, test.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package kadm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// ClusterDiffKind is the kind of a difference between two clusters.
+type ClusterDiffKind int8
+
+const (
+	// DiffTopicMissing is a topic that exists in only one cluster. A and
+	// B are either "present" or "missing".
+	DiffTopicMissing ClusterDiffKind = iota
+
+	// DiffTopicError is a topic or partition that could not be compared
+	// because it had a load error in either cluster. A and B are the
+	// error strings, if any.
+	DiffTopicError
+
+	// DiffPartitions is a topic with a different number of partitions. A
+	// and B are the partition counts.
+	DiffPartitions
+
+	// DiffConfig is a topic config with a different value. A and B are
+	// the config values.
+	DiffConfig
+
+	// DiffStartOffset is a partition with a different log start offset.
+	// A and B are the offsets.
+	DiffStartOffset
+
+	// DiffEndOffset is a partition with a different high watermark. A and
+	// B are the offsets.
+	DiffEndOffset
+
+	// DiffGroupError is a group whose offsets could not be fetched in
+	// either cluster. A and B are the error strings, if any.
+	DiffGroupError
+
+	// DiffCommittedOffset is a partition with a different committed offset
+	// for a group. A and B are the committed offsets, or empty if the
+	// group has no commit for the partition in that cluster.
+	DiffCommittedOffset
+)
+
+// String returns the kind as a string.
+func (k ClusterDiffKind) String() string {
+	switch k {
+	case DiffTopicMissing:
+		return "TOPIC_MISSING"
+	case DiffTopicError:
+		return "TOPIC_ERROR"
+	case DiffPartitions:
+		return "PARTITIONS"
+	case DiffConfig:
+		return "CONFIG"
+	case DiffStartOffset:
+		return "START_OFFSET"
+	case DiffEndOffset:
+		return "END_OFFSET"
+	case DiffGroupError:
+		return "GROUP_ERROR"
+	case DiffCommittedOffset:
+		return "COMMITTED_OFFSET"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ClusterDiff is a single difference between two clusters.
+type ClusterDiff struct {
+	Kind ClusterDiffKind // Kind is what differs.
+
+	Topic     string // Topic is the topic that differs, if any.
+	Partition int32  // Partition is the partition that differs, or -1 if the difference is not for a partition.
+	Group     string // Group is the group that differs, for group differences.
+	Config    string // Config is the config key that differs, for config differences.
+
+	A string // A is the value in the first cluster; see the kind for what the value is.
+	B string // B is the value in the second cluster; see the kind for what the value is.
+}
+
+// String returns the difference in a human readable form.
+func (d ClusterDiff) String() string {
+	var where string
+	switch {
+	case d.Group != "" && d.Topic != "":
+		where = fmt.Sprintf("group %s %s[%d]", d.Group, d.Topic, d.Partition)
+	case d.Group != "":
+		where = "group " + d.Group
+	case d.Config != "":
+		where = fmt.Sprintf("%s config %s", d.Topic, d.Config)
+	case d.Partition >= 0:
+		where = fmt.Sprintf("%s[%d]", d.Topic, d.Partition)
+	default:
+		where = d.Topic
+	}
+	return fmt.Sprintf("%s %s: %q != %q", d.Kind, where, d.A, d.B)
+}
+
+// ClusterDiffs contains all differences between two clusters.
+type ClusterDiffs []ClusterDiff
+
+// Kind returns only the differences of the given kinds.
+func (ds ClusterDiffs) Kind(kinds ...ClusterDiffKind) ClusterDiffs {
+	var keep ClusterDiffs
+	for _, d := range ds {
+		for _, k := range kinds {
+			if d.Kind == k {
+				keep = append(keep, d)
+				break
+			}
+		}
+	}
+	return keep
+}
+
+// Topic returns only the differences for the given topic.
+func (ds ClusterDiffs) Topic(topic string) ClusterDiffs {
+	var keep ClusterDiffs
+	for _, d := range ds {
+		if d.Topic == topic {
+			keep = append(keep, d)
+		}
+	}
+	return keep
+}
+
+func (ds ClusterDiffs) sort() {
+	sort.SliceStable(ds, func(i, j int) bool {
+		l, r := ds[i], ds[j]
+		switch {
+		case l.Kind != r.Kind:
+			return l.Kind < r.Kind
+		case l.Group != r.Group:
+			return l.Group < r.Group
+		case l.Topic != r.Topic:
+			return l.Topic < r.Topic
+		case l.Partition != r.Partition:
+			return l.Partition < r.Partition
+		default:
+			return l.Config < r.Config
+		}
+	})
+}
+
+// CompareClusters compares topics and groups between two clusters, returning
+// every difference found, sorted by kind, group, topic, partition, and
+// config. This is meant to verify that clusters agree before and after
+// mirroring; an empty result means the clusters agree on everything that was
+// compared.
+//
+// If no topics are given, all non-internal topics in either cluster are
+// compared. For topics in both clusters, this compares partition counts,
+// topic config overrides, and per-partition start and end offsets. Configs
+// are only compared if either cluster has the config set on the topic itself
+// (i.e., broker defaults are not compared), and sensitive configs are never
+// compared. Offsets are compared for partitions that exist in both clusters.
+//
+// For each group given, committed offsets are compared for all partitions of
+// the compared topics that the group has committed in either cluster.
+//
+// This returns an error if any request fails to be issued, or an *AuthError.
+func CompareClusters(ctx context.Context, a, b *Client, topics []string, groups ...string) (ClusterDiffs, error) {
+	ta, err := a.ListTopics(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	tb, err := b.ListTopics(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	if len(topics) == 0 {
+		ta.FilterInternal()
+		tb.FilterInternal()
+		topicsSet := make(map[string]struct{})
+		for _, tds := range []TopicDetails{ta, tb} {
+			for t := range tds {
+				topicsSet[t] = struct{}{}
+			}
+		}
+		for t := range topicsSet {
+			topics = append(topics, t)
+		}
+	}
+	sort.Strings(topics)
+
+	ds, common := compareTopics(topics, ta, tb)
+
+	if len(common) > 0 {
+		configDiffs, err := compareConfigs(ctx, a, b, common)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, configDiffs...)
+
+		for _, list := range []struct {
+			kind ClusterDiffKind
+			fn   func(*Client) (ListedOffsets, error)
+		}{
+			{DiffStartOffset, func(cl *Client) (ListedOffsets, error) { return cl.ListStartOffsets(ctx, common...) }},
+			{DiffEndOffset, func(cl *Client) (ListedOffsets, error) { return cl.ListEndOffsets(ctx, common...) }},
+		} {
+			la, err := list.fn(a)
+			if err != nil {
+				return nil, err
+			}
+			lb, err := list.fn(b)
+			if err != nil {
+				return nil, err
+			}
+			ds = append(ds, compareListedOffsets(list.kind, la, lb)...)
+		}
+	}
+
+	if len(groups) > 0 {
+		fa := a.FetchManyOffsets(ctx, groups...)
+		fb := b.FetchManyOffsets(ctx, groups...)
+		ds = append(ds, compareCommits(topics, groups, fa, fb)...)
+	}
+
+	ds.sort()
+	return ds, nil
+}
+
+// errMissingConfigs is set on a topic that one cluster did not return
+// configs for, so that it is reported as a DiffTopicError.
+var errMissingConfigs = errors.New("topic missing from describe configs response")
+
+func compareConfigs(ctx context.Context, a, b *Client, topics []string) (ClusterDiffs, error) {
+	rca, err := a.DescribeTopicConfigs(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	rcb, err := b.DescribeTopicConfigs(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	return compareDescribedConfigs(topics, rca, rcb), nil
+}
+
+// compareDescribedConfigs compares the configs of the topics described in
+// both clusters. Only configs set on the topic itself in either cluster are
+// compared, and sensitive configs are never compared.
+func compareDescribedConfigs(topics []string, rca, rcb []ResourceConfig) ClusterDiffs {
+	index := func(rcs []ResourceConfig) map[string]ResourceConfig {
+		m := make(map[string]ResourceConfig, len(rcs))
+		for _, rc := range rcs {
+			m[rc.Name] = rc
+		}
+		return m
+	}
+	ia, ib := index(rca), index(rcb)
+
+	var ds ClusterDiffs
+	for _, t := range topics {
+		ca, oka := ia[t]
+		cb, okb := ib[t]
+		if !oka {
+			ca.Err = errMissingConfigs
+		}
+		if !okb {
+			cb.Err = errMissingConfigs
+		}
+		if ca.Err != nil || cb.Err != nil {
+			ds = append(ds, ClusterDiff{Kind: DiffTopicError, Topic: t, Partition: -1, A: errStr(ca.Err), B: errStr(cb.Err)})
+			continue
+		}
+		configs := func(rc ResourceConfig) map[string]Config {
+			m := make(map[string]Config, len(rc.Configs))
+			for _, c := range rc.Configs {
+				m[c.Key] = c
+			}
+			return m
+		}
+		ma, mb := configs(ca), configs(cb)
+		keys := make(map[string]struct{})
+		for _, m := range []map[string]Config{ma, mb} {
+			for k, c := range m {
+				if c.Source == kmsg.ConfigSourceDynamicTopicConfig {
+					keys[k] = struct{}{}
+				}
+			}
+		}
+		for k := range keys {
+			va, vb := ma[k], mb[k]
+			if va.Sensitive || vb.Sensitive {
+				continue
+			}
+			if sa, sb := va.MaybeValue(), vb.MaybeValue(); sa != sb {
+				ds = append(ds, ClusterDiff{Kind: DiffConfig, Topic: t, Partition: -1, Config: k, A: sa, B: sb})
+			}
+		}
+	}
+	return ds
+}
+
+// compareTopics compares the presence, load errors, and partition counts of
+// topics in both clusters, returning the differences and the topics that
+// exist without errors in both clusters.
+func compareTopics(topics []string, ta, tb TopicDetails) (ClusterDiffs, []string) {
+	var (
+		ds       ClusterDiffs
+		common   []string
+		presence = func(has bool) string {
+			if has {
+				return "present"
+			}
+			return "missing"
+		}
+	)
+	for _, t := range topics {
+		hasA, hasB := ta.Has(t), tb.Has(t)
+		if !hasA || !hasB {
+			ds = append(ds, ClusterDiff{Kind: DiffTopicMissing, Topic: t, Partition: -1, A: presence(hasA), B: presence(hasB)})
+			continue
+		}
+		da, db := ta[t], tb[t]
+		if da.Err != nil || db.Err != nil {
+			ds = append(ds, ClusterDiff{Kind: DiffTopicError, Topic: t, Partition: -1, A: errStr(da.Err), B: errStr(db.Err)})
+			continue
+		}
+		if pa, pb := len(da.Partitions), len(db.Partitions); pa != pb {
+			ds = append(ds, ClusterDiff{Kind: DiffPartitions, Topic: t, Partition: -1, A: strconv.Itoa(pa), B: strconv.Itoa(pb)})
+		}
+		common = append(common, t)
+	}
+	return ds, common
+}
+
+// compareListedOffsets compares offsets for partitions listed in both
+// clusters, reporting differences with the given kind.
+func compareListedOffsets(kind ClusterDiffKind, la, lb ListedOffsets) ClusterDiffs {
+	var ds ClusterDiffs
+	la.Each(func(oa ListedOffset) {
+		ob, ok := lb[oa.Topic][oa.Partition]
+		switch {
+		case !ok:
+		case oa.Err != nil || ob.Err != nil:
+			ds = append(ds, ClusterDiff{Kind: DiffTopicError, Topic: oa.Topic, Partition: oa.Partition, A: errStr(oa.Err), B: errStr(ob.Err)})
+		case oa.Offset != ob.Offset:
+			ds = append(ds, ClusterDiff{Kind: kind, Topic: oa.Topic, Partition: oa.Partition, A: strconv.FormatInt(oa.Offset, 10), B: strconv.FormatInt(ob.Offset, 10)})
+		}
+	})
+	return ds
+}
+
+// compareCommits compares the committed offsets of each group for all
+// partitions of the compared topics that the group has committed in either
+// cluster.
+func compareCommits(topics, groups []string, fa, fb FetchOffsetsResponses) ClusterDiffs {
+	compared := make(map[string]bool, len(topics))
+	for _, t := range topics {
+		compared[t] = true
+	}
+	var ds ClusterDiffs
+	for _, g := range groups {
+		ga, gb := fa[g], fb[g]
+		if ga.Err != nil || gb.Err != nil {
+			ds = append(ds, ClusterDiff{Kind: DiffGroupError, Group: g, Partition: -1, A: errStr(ga.Err), B: errStr(gb.Err)})
+			continue
+		}
+		committed := func(os OffsetResponses, t string, p int32) string {
+			o, ok := os[t][p]
+			if !ok || o.Err != nil || o.Offset.Offset < 0 {
+				return ""
+			}
+			return strconv.FormatInt(o.Offset.Offset, 10)
+		}
+		var s TopicsSet
+		for _, os := range []OffsetResponses{ga.Fetched, gb.Fetched} {
+			os.Each(func(o OffsetResponse) {
+				if compared[o.Topic] {
+					s.Add(o.Topic, o.Partition)
+				}
+			})
+		}
+		s.Each(func(t string, p int32) {
+			if ca, cb := committed(ga.Fetched, t, p), committed(gb.Fetched, t, p); ca != cb {
+				ds = append(ds, ClusterDiff{Kind: DiffCommittedOffset, Group: g, Topic: t, Partition: p, A: ca, B: cb})
+			}
+		})
+	}
+	return ds
+}
+
+func errStr(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
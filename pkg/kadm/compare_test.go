@@ -0,0 +1,148 @@
+package kadm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// compareCluster is what CompareClusters loads from one cluster.
+type compareCluster struct {
+	topics  TopicDetails
+	configs []ResourceConfig
+	starts  ListedOffsets
+	ends    ListedOffsets
+	commits FetchOffsetsResponses
+}
+
+// compare runs the comparison CompareClusters runs after loading both
+// clusters, without the requests.
+func compare(topics, groups []string, a, b compareCluster) ClusterDiffs {
+	ds, common := compareTopics(topics, a.topics, b.topics)
+	if len(common) > 0 {
+		ds = append(ds, compareDescribedConfigs(common, a.configs, b.configs)...)
+		ds = append(ds, compareListedOffsets(DiffStartOffset, a.starts, b.starts)...)
+		ds = append(ds, compareListedOffsets(DiffEndOffset, a.ends, b.ends)...)
+	}
+	if len(groups) > 0 {
+		ds = append(ds, compareCommits(topics, groups, a.commits, b.commits)...)
+	}
+	ds.sort()
+	return ds
+}
+
+func comparePartitions(n int) PartitionDetails {
+	ps := make(PartitionDetails)
+	for p := int32(0); p < int32(n); p++ {
+		ps[p] = PartitionDetail{Partition: p}
+	}
+	return ps
+}
+
+func compareOffsets(os map[string][]int64) ListedOffsets {
+	l := make(ListedOffsets)
+	for t, ps := range os {
+		l[t] = make(map[int32]ListedOffset)
+		for p, o := range ps {
+			l[t][int32(p)] = ListedOffset{Topic: t, Partition: int32(p), Offset: o}
+		}
+	}
+	return l
+}
+
+func compareCommitted(g string, os map[string][]int64) FetchOffsetsResponse {
+	fetched := make(OffsetResponses)
+	for t, ps := range os {
+		fetched[t] = make(map[int32]OffsetResponse)
+		for p, o := range ps {
+			fetched[t][int32(p)] = OffsetResponse{Offset: Offset{Topic: t, Partition: int32(p), Offset: o}}
+		}
+	}
+	return FetchOffsetsResponse{Group: g, Fetched: fetched}
+}
+
+func TestCompareClusters(t *testing.T) {
+	dynamic := func(k, v string) Config {
+		return Config{Key: k, Value: StringPtr(v), Source: kmsg.ConfigSourceDynamicTopicConfig}
+	}
+	def := func(k, v string) Config {
+		return Config{Key: k, Value: StringPtr(v), Source: kmsg.ConfigSourceDefaultConfig}
+	}
+
+	a := compareCluster{
+		topics: TopicDetails{
+			"same":    {Topic: "same", Partitions: comparePartitions(2)},
+			"grown":   {Topic: "grown", Partitions: comparePartitions(1)},
+			"only-a":  {Topic: "only-a", Partitions: comparePartitions(1)},
+			"errored": {Topic: "errored", Err: kerr.TopicAuthorizationFailed},
+		},
+		configs: []ResourceConfig{
+			{Name: "same", Configs: []Config{
+				dynamic("retention.ms", "1000"),
+				def("cleanup.policy", "delete"),
+				{Key: "secret", Sensitive: true, Source: kmsg.ConfigSourceDynamicTopicConfig},
+			}},
+			{Name: "grown", Configs: []Config{def("retention.ms", "604800000")}},
+		},
+		starts: compareOffsets(map[string][]int64{"same": {0, 5}, "grown": {0}}),
+		ends:   compareOffsets(map[string][]int64{"same": {10, 20}, "grown": {3}}),
+		commits: FetchOffsetsResponses{
+			"g":      compareCommitted("g", map[string][]int64{"same": {4, 20}, "only-a": {1}}),
+			"failed": {Group: "failed", Err: kerr.GroupAuthorizationFailed},
+		},
+	}
+	b := compareCluster{
+		topics: TopicDetails{
+			"same":    {Topic: "same", Partitions: comparePartitions(2)},
+			"grown":   {Topic: "grown", Partitions: comparePartitions(3)},
+			"only-b":  {Topic: "only-b", Partitions: comparePartitions(1)},
+			"errored": {Topic: "errored", Partitions: comparePartitions(1)},
+		},
+		configs: []ResourceConfig{
+			{Name: "same", Configs: []Config{
+				def("retention.ms", "604800000"),
+				dynamic("cleanup.policy", "compact"),
+				{Key: "secret", Sensitive: true, Source: kmsg.ConfigSourceDynamicTopicConfig},
+			}},
+			// "grown" is missing from this describe response.
+		},
+		starts: compareOffsets(map[string][]int64{"same": {0, 5}, "grown": {2, 0, 0}}),
+		ends:   compareOffsets(map[string][]int64{"same": {11, 20}, "grown": {3, 0, 0}}),
+		commits: FetchOffsetsResponses{
+			"g":      compareCommitted("g", map[string][]int64{"same": {4, 19}, "grown": {2}}),
+			"failed": compareCommitted("failed", nil),
+		},
+	}
+
+	topics := []string{"errored", "grown", "only-a", "only-b", "same"}
+	groups := []string{"failed", "g"}
+
+	got := compare(topics, groups, a, b)
+	exp := ClusterDiffs{
+		{Kind: DiffTopicMissing, Topic: "only-a", Partition: -1, A: "present", B: "missing"},
+		{Kind: DiffTopicMissing, Topic: "only-b", Partition: -1, A: "missing", B: "present"},
+		{Kind: DiffTopicError, Topic: "errored", Partition: -1, A: kerr.TopicAuthorizationFailed.Error()},
+		{Kind: DiffTopicError, Topic: "grown", Partition: -1, B: errMissingConfigs.Error()},
+		{Kind: DiffPartitions, Topic: "grown", Partition: -1, A: "1", B: "3"},
+		{Kind: DiffConfig, Topic: "same", Partition: -1, Config: "cleanup.policy", A: "delete", B: "compact"},
+		{Kind: DiffConfig, Topic: "same", Partition: -1, Config: "retention.ms", A: "1000", B: "604800000"},
+		{Kind: DiffStartOffset, Topic: "grown", Partition: 0, A: "0", B: "2"},
+		{Kind: DiffEndOffset, Topic: "same", Partition: 0, A: "10", B: "11"},
+		{Kind: DiffGroupError, Group: "failed", Partition: -1, A: kerr.GroupAuthorizationFailed.Error()},
+		{Kind: DiffCommittedOffset, Group: "g", Topic: "grown", Partition: 0, B: "2"},
+		{Kind: DiffCommittedOffset, Group: "g", Topic: "only-a", Partition: 0, A: "1"},
+		{Kind: DiffCommittedOffset, Group: "g", Topic: "same", Partition: 1, A: "20", B: "19"},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got diffs:")
+		for _, d := range got {
+			t.Errorf("  %+v", d)
+		}
+		t.Errorf("exp diffs:")
+		for _, d := range exp {
+			t.Errorf("  %+v", d)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package kadm
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// CopiedOffset contains the result of copying a single partition's committed
+// offset from one group to another.
+type CopiedOffset struct {
+	Topic     string // Topic is the topic this offset is for.
+	Partition int32  // Partition is the partition this offset is for.
+
+	From int64 // From is the offset committed in the source group.
+	To   int64 // To is the offset committed (or, if validating, to be committed) in the destination group, or -1 if the offset could not be copied.
+
+	// Timestamp is the timestamp used to translate this offset, if
+	// translating. This is -1 if not translating or if the source group
+	// was caught up, in which case the offset is translated to the
+	// destination's high watermark.
+	Timestamp int64
+
+	Err error // Err is non-nil if the offset could not be fetched, translated, or committed.
+}
+
+// CopiedOffsets contains per-partition results of copying committed offsets.
+type CopiedOffsets map[string]map[int32]CopiedOffset
+
+// Sorted returns the copied offsets sorted by topic and partition.
+func (cs CopiedOffsets) Sorted() []CopiedOffset {
+	var s []CopiedOffset
+	cs.Each(func(c CopiedOffset) { s = append(s, c) })
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		return l.Topic < r.Topic || l.Topic == r.Topic && l.Partition < r.Partition
+	})
+	return s
+}
+
+// Each calls fn for every copied offset.
+func (cs CopiedOffsets) Each(fn func(CopiedOffset)) {
+	for _, ps := range cs {
+		for _, c := range ps {
+			fn(c)
+		}
+	}
+}
+
+// EachError calls fn for every copied offset that has a non-nil error. This
+// can be used to report partitions that could not be translated.
+func (cs CopiedOffsets) EachError(fn func(CopiedOffset)) {
+	cs.Each(func(c CopiedOffset) {
+		if c.Err != nil {
+			fn(c)
+		}
+	})
+}
+
+// Error returns the first error in the copied offsets, if any.
+func (cs CopiedOffsets) Error() error {
+	for _, ps := range cs {
+		for _, c := range ps {
+			if c.Err != nil {
+				return c.Err
+			}
+		}
+	}
+	return nil
+}
+
+// Ok returns true if there are no errors. This is a shortcut for cs.Error() ==
+// nil.
+func (cs CopiedOffsets) Ok() bool {
+	return cs.Error() == nil
+}
+
+func (cs CopiedOffsets) set(c CopiedOffset) {
+	ps := cs[c.Topic]
+	if ps == nil {
+		ps = make(map[int32]CopiedOffset)
+		cs[c.Topic] = ps
+	}
+	ps[c.Partition] = c
+}
+
+// CopyOffsets copies the committed offsets of srcGroup in this client's
+// cluster to dstGroup using the dst client. The dst client can be for the same
+// cluster (to rename a group) or a different cluster (to migrate a group). If
+// dst is nil, this client is used.
+//
+// If translate is false, offsets are copied as is, which is only correct if
+// the destination topics have identical offsets (for example, when copying
+// within the same cluster).
+//
+// If translate is true, offsets are translated by timestamp, which is
+// necessary when topics have been mirrored to a different cluster and offsets
+// do not line up. For each committed offset, this reads the timestamp of the
+// record batch containing the committed offset in the source cluster and then
+// lists the first offset at or after that timestamp in the destination, like
+// ListOffsetsAfterMilli. Because the timestamp is the first timestamp in the
+// batch, translation errs on the side of reprocessing records rather than
+// skipping them. A source group that is caught up on a partition is
+// translated to the destination's high watermark. Partitions that could not
+// be translated have an error and are not committed.
+//
+// The destination group must be Empty or Dead.
+//
+// This returns an error if the source offsets could not be fetched, if the
+// destination group is not empty, or if any request fails to be issued.
+// Per-partition translation and commit errors are included in the results.
+func (cl *Client) CopyOffsets(ctx context.Context, srcGroup string, dst *Client, dstGroup string, translate bool) (CopiedOffsets, error) {
+	return cl.copyOffsets(ctx, srcGroup, dst, dstGroup, translate, false)
+}
+
+// ValidateCopyOffsets is CopyOffsets, but does not commit any offsets. This can
+// be used to see what offsets would be committed and which partitions cannot
+// be translated.
+func (cl *Client) ValidateCopyOffsets(ctx context.Context, srcGroup string, dst *Client, dstGroup string, translate bool) (CopiedOffsets, error) {
+	return cl.copyOffsets(ctx, srcGroup, dst, dstGroup, translate, true)
+}
+
+func (cl *Client) copyOffsets(ctx context.Context, srcGroup string, dst *Client, dstGroup string, translate, dry bool) (CopiedOffsets, error) {
+	if dst == nil {
+		dst = cl
+	}
+	if err := dst.requireInactiveGroup(ctx, dstGroup); err != nil {
+		return nil, err
+	}
+	fetched, err := cl.FetchOffsets(ctx, srcGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := make(CopiedOffsets)
+	var copying Offsets
+	fetched.Each(func(o OffsetResponse) {
+		c := CopiedOffset{
+			Topic:     o.Topic,
+			Partition: o.Partition,
+			From:      o.Offset.Offset,
+			To:        -1,
+			Timestamp: -1,
+			Err:       o.Err,
+		}
+		if c.Err == nil && c.From < 0 {
+			c.Err = errors.New("source group has no committed offset")
+		}
+		if c.Err == nil {
+			copying.Add(o.Offset)
+		}
+		cs.set(c)
+	})
+	if len(copying) == 0 {
+		return cs, nil
+	}
+
+	if !translate {
+		for _, ps := range copying {
+			for _, o := range ps {
+				c := cs[o.Topic][o.Partition]
+				c.To = c.From
+				cs.set(c)
+			}
+		}
+	} else if err := cl.translateOffsets(ctx, dst, cs, copying); err != nil {
+		return nil, err
+	}
+
+	var commit Offsets
+	cs.Each(func(c CopiedOffset) {
+		if c.Err != nil {
+			return
+		}
+		o := copying[c.Topic][c.Partition]
+		commit.Add(Offset{
+			Topic:       c.Topic,
+			Partition:   c.Partition,
+			Offset:      c.To,
+			LeaderEpoch: -1,
+			Metadata:    o.Metadata,
+		})
+	})
+	if dry || len(commit) == 0 {
+		return cs, nil
+	}
+	committed, err := dst.CommitOffsets(ctx, dstGroup, commit)
+	if err != nil {
+		return nil, err
+	}
+	committed.Each(func(o OffsetResponse) {
+		c := cs[o.Topic][o.Partition]
+		c.Err = o.Err
+		cs.set(c)
+	})
+	return cs, nil
+}
+
+// translateOffsets sets the To field for all copying offsets in cs, or sets
+// an error if an offset could not be translated.
+func (cl *Client) translateOffsets(ctx context.Context, dst *Client, cs CopiedOffsets, copying Offsets) error {
+	timestamps, err := cl.batchTimestamps(ctx, copying)
+	if err != nil {
+		return err
+	}
+
+	// A timestamp of -1 lists the high watermark, which is what we want
+	// for partitions the source group was caught up on.
+	listAt := make(map[string]map[int32]int64)
+	for t, ps := range timestamps {
+		for p, bt := range ps {
+			c := cs[t][p]
+			if bt.err != nil {
+				c.Err = fmt.Errorf("unable to read source record timestamp: %w", bt.err)
+				cs.set(c)
+				continue
+			}
+			c.Timestamp = bt.ts
+			cs.set(c)
+			lt := listAt[t]
+			if lt == nil {
+				lt = make(map[int32]int64)
+				listAt[t] = lt
+			}
+			lt[p] = bt.ts
+		}
+	}
+	if len(listAt) == 0 {
+		return nil
+	}
+
+	listed, err := dst.listOffsetsAt(ctx, 0, listAt)
+	if err != nil {
+		return err
+	}
+
+	// If no records are at or after the timestamp in the destination,
+	// the offset is -1 and we translate to the high watermark.
+	var ends map[string]map[int32]int64
+	for t, ps := range listAt {
+		for p := range ps {
+			l, ok := listed[t][p]
+			if ok && l.Err == nil && l.Offset < 0 {
+				if ends == nil {
+					ends = make(map[string]map[int32]int64)
+				}
+				if ends[t] == nil {
+					ends[t] = make(map[int32]int64)
+				}
+				ends[t][p] = -1
+			}
+		}
+	}
+	var listedEnds ListedOffsets
+	if len(ends) > 0 {
+		if listedEnds, err = dst.listOffsetsAt(ctx, 0, ends); err != nil {
+			return err
+		}
+	}
+
+	for t, ps := range listAt {
+		for p := range ps {
+			c := cs[t][p]
+			l, ok := listed[t][p]
+			if _, isEnd := ends[t][p]; isEnd {
+				l, ok = listedEnds[t][p]
+			}
+			switch {
+			case !ok:
+				c.Err = fmt.Errorf("destination partition was not listed: %w", kerr.UnknownTopicOrPartition)
+			case l.Err != nil:
+				c.Err = fmt.Errorf("unable to list destination offset: %w", l.Err)
+			default:
+				c.To = l.Offset
+			}
+			cs.set(c)
+		}
+	}
+	return nil
+}
+
+type batchTimestamp struct {
+	ts  int64 // -1 if the offset is at the high watermark
+	err error
+}
+
+// batchTimestamps returns the first timestamp of the record batch containing
+// each offset. This issues fetch requests directly to partition leaders and
+// only reads record batch headers, which are not compressed.
+func (cl *Client) batchTimestamps(ctx context.Context, os Offsets) (map[string]map[int32]batchTimestamp, error) {
+	topics := make([]string, 0, len(os))
+	for t := range os {
+		topics = append(topics, t)
+	}
+	m, err := cl.Metadata(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	return cl.batchTimestampsWith(ctx, m, os), nil
+}
+
+// batchTimestampsWith is batchTimestamps using already loaded metadata, which
+// must contain every topic in os.
+func (cl *Client) batchTimestampsWith(ctx context.Context, m Metadata, os Offsets) map[string]map[int32]batchTimestamp {
+	var (
+		tss     = make(map[string]map[int32]batchTimestamp)
+		set     = func(t string, p int32, bt batchTimestamp) { tss[t][p] = bt }
+		leaders = make(map[int32]*kmsg.FetchRequest)
+		ids     = make(map[TopicID]string)
+	)
+	for t, ps := range os {
+		tss[t] = make(map[int32]batchTimestamp)
+		td, ok := m.Topics[t]
+		for p, o := range ps {
+			var err error
+			pd, pok := td.Partitions[p]
+			switch {
+			case !ok:
+				err = kerr.UnknownTopicOrPartition
+			case td.Err != nil:
+				err = td.Err
+			case !pok:
+				err = kerr.UnknownTopicOrPartition
+			case pd.Err != nil:
+				err = pd.Err
+			case pd.Leader < 0:
+				err = kerr.LeaderNotAvailable
+			}
+			if err != nil {
+				set(t, p, batchTimestamp{err: err})
+				continue
+			}
+			ids[td.ID] = t
+
+			req := leaders[pd.Leader]
+			if req == nil {
+				req = kmsg.NewPtrFetchRequest()
+				req.ReplicaID = -1
+				req.MinBytes = 1
+				leaders[pd.Leader] = req
+			}
+			var rt *kmsg.FetchRequestTopic
+			for i := range req.Topics {
+				if req.Topics[i].Topic == t {
+					rt = &req.Topics[i]
+				}
+			}
+			if rt == nil {
+				req.Topics = append(req.Topics, kmsg.NewFetchRequestTopic())
+				rt = &req.Topics[len(req.Topics)-1]
+				rt.Topic = t
+				rt.TopicID = td.ID
+			}
+			rp := kmsg.NewFetchRequestTopicPartition()
+			rp.Partition = p
+			rp.FetchOffset = o.Offset
+			rp.PartitionMaxBytes = 1 << 10 // we only need the first batch header
+			rt.Partitions = append(rt.Partitions, rp)
+		}
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for leader, req := range leaders {
+		leader, req := leader, req
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			kresp, err := cl.cl.Broker(int(leader)).Request(ctx, req)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				resp := kresp.(*kmsg.FetchResponse)
+				if err = maybeAuthErr(resp.ErrorCode); err == nil {
+					err = kerr.ErrorForCode(resp.ErrorCode)
+				}
+				if err == nil {
+					for _, t := range resp.Topics {
+						topic := t.Topic
+						if topic == "" {
+							topic = ids[t.TopicID]
+						}
+						for _, p := range t.Partitions {
+							if _, ok := os[topic][p.Partition]; !ok {
+								continue
+							}
+							bt := batchTimestamp{err: kerr.ErrorForCode(p.ErrorCode)}
+							if bt.err == nil {
+								bt.ts, bt.err = firstBatchTimestamp(p.RecordBatches, os[topic][p.Partition].Offset, p.HighWatermark)
+							}
+							set(topic, p.Partition, bt)
+						}
+					}
+				}
+			}
+			for _, rt := range req.Topics {
+				for _, rp := range rt.Partitions {
+					if _, ok := tss[rt.Topic][rp.Partition]; !ok {
+						if err == nil {
+							err = errors.New("partition missing from fetch response")
+						}
+						set(rt.Topic, rp.Partition, batchTimestamp{err: err})
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return tss
+}
+
+// firstBatchTimestamp returns the first timestamp of the first batch in
+// fetched record batches. If no batches were returned and the offset is at
+// the high watermark, this returns -1.
+func firstBatchTimestamp(batches []byte, offset, hwm int64) (int64, error) {
+	if len(batches) == 0 {
+		if offset >= hwm {
+			return -1, nil
+		}
+		return 0, errors.New("no records were returned")
+	}
+	// Both message sets and record batches start with an int64 offset,
+	// an int32 length, and then four bytes before the magic byte.
+	if len(batches) < 17 {
+		return 0, errors.New("fetched record batch is too short")
+	}
+	var ts int64
+	switch magic := int8(batches[16]); magic {
+	case 2:
+		// firstOffset, length, leaderEpoch, magic, crc, attributes,
+		// lastOffsetDelta, then the int64 firstTimestamp.
+		if len(batches) < 35 {
+			return 0, errors.New("fetched record batch is too short")
+		}
+		ts = int64(binary.BigEndian.Uint64(batches[27:]))
+	case 1:
+		// offset, size, crc, magic, attributes, then the int64 timestamp.
+		if len(batches) < 26 {
+			return 0, errors.New("fetched message set is too short")
+		}
+		ts = int64(binary.BigEndian.Uint64(batches[18:]))
+	default:
+		return 0, fmt.Errorf("message format v%d does not have timestamps", magic)
+	}
+	if ts < 0 {
+		return 0, errors.New("fetched record has no timestamp")
+	}
+	return ts, nil
+}
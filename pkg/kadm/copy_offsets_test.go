@@ -0,0 +1,61 @@
+package kadm
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestFirstBatchTimestamp(t *testing.T) {
+	v2 := (&kmsg.RecordBatch{
+		FirstOffset:    10,
+		Magic:          2,
+		FirstTimestamp: 1600000000000,
+		MaxTimestamp:   1600000000500,
+	}).AppendTo(nil)
+	v1 := (&kmsg.MessageV1{
+		Offset:    10,
+		Magic:     1,
+		Timestamp: 1500000000000,
+		Value:     []byte("v"),
+	}).AppendTo(nil)
+	v0 := (&kmsg.MessageV0{
+		Offset: 10,
+		Magic:  0,
+		Value:  []byte("v"),
+	}).AppendTo(nil)
+	noTimestamp := (&kmsg.MessageV1{
+		Offset:    10,
+		Magic:     1,
+		Timestamp: -1,
+	}).AppendTo(nil)
+
+	for _, test := range []struct {
+		name    string
+		batches []byte
+		offset  int64
+		hwm     int64
+		exp     int64
+		expErr  bool
+	}{
+		{name: "v2 batch", batches: v2, offset: 10, hwm: 20, exp: 1600000000000},
+		{name: "v1 message set", batches: v1, offset: 10, hwm: 20, exp: 1500000000000},
+		{name: "v0 message set", batches: v0, offset: 10, hwm: 20, expErr: true},
+		{name: "no timestamp", batches: noTimestamp, offset: 10, hwm: 20, expErr: true},
+		{name: "empty at hwm", offset: 20, hwm: 20, exp: -1},
+		{name: "empty below hwm", offset: 10, hwm: 20, expErr: true},
+		{name: "too short for magic", batches: v2[:16], offset: 10, hwm: 20, expErr: true},
+		{name: "v2 too short", batches: v2[:34], offset: 10, hwm: 20, expErr: true},
+		{name: "v1 too short", batches: v1[:25], offset: 10, hwm: 20, expErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts, err := firstBatchTimestamp(test.batches, test.offset, test.hwm)
+			if gotErr := err != nil; gotErr != test.expErr {
+				t.Fatalf("got err? %v (%v), exp err? %v", gotErr, err, test.expErr)
+			}
+			if !test.expErr && ts != test.exp {
+				t.Errorf("got ts %d != exp %d", ts, test.exp)
+			}
+		})
+	}
+}
@@ -2,9 +2,9 @@ module github.com/twmb/franz-go/pkg/kadm
 
 go 1.16
 
-require github.com/twmb/franz-go v1.1.2
+require github.com/twmb/franz-go v1.7.0
 
 require (
-	github.com/klauspost/compress v1.13.6 // indirect
-	github.com/twmb/franz-go/pkg/kmsg v0.0.0-20211010181717-11efd498dac5
+	github.com/twmb/franz-go/pkg/kmsg v1.2.0
+	golang.org/x/crypto v0.0.0-20220817201139-bc19a97f63c8
 )
@@ -233,6 +233,27 @@ func (cl *Client) DescribeGroups(ctx context.Context, groups ...string) (Describ
 	}
 }
 
+// requireInactiveGroup describes the group and returns an error if the group
+// is not Empty or Dead, i.e., if the group has active members that could be
+// committing offsets.
+func (cl *Client) requireInactiveGroup(ctx context.Context, group string) error {
+	described, err := cl.DescribeGroups(ctx, group)
+	if err != nil {
+		return err
+	}
+	g, ok := described[group]
+	if !ok {
+		return fmt.Errorf("group %q was not described", group)
+	}
+	if g.Err != nil {
+		return g.Err
+	}
+	if g.State != "Empty" && g.State != "Dead" {
+		return fmt.Errorf("group %q is in state %s and must be Empty or Dead", group, g.State)
+	}
+	return nil
+}
+
 // DeleteGroupResponse contains the response for an individual deleted group.
 type DeleteGroupResponse struct {
 	Group string // Group is the group this response is for.
@@ -353,16 +374,8 @@ func (os OffsetResponses) Ok() bool {
 	return os.Error() == nil
 }
 
-// CommitOffsets issues an offset commit request for the input offsets.
-//
-// This function can be used to manually commit offsets when directly consuming
-// partitions outside of an actual consumer group. For example, if you assign
-// partitions manually, but want still use Kafka to checkpoint what you have
-// consumed, you can manually issue an offset commit request with this method.
-//
-// This does not return on authorization failures, instead, authorization
-// failures are included in the responses.
-func (cl *Client) CommitOffsets(ctx context.Context, group string, os Offsets) (OffsetResponses, error) {
+// commitOffsetsReq returns an offset commit request for every input offset.
+func commitOffsetsReq(group string, os Offsets) *kmsg.OffsetCommitRequest {
 	req := kmsg.NewPtrOffsetCommitRequest()
 	req.Group = group
 	for t, ps := range os {
@@ -378,9 +391,24 @@ func (cl *Client) CommitOffsets(ctx context.Context, group string, os Offsets) (
 			if o.CommitLeaderEpoch {
 				rp.LeaderEpoch = o.LeaderEpoch
 			}
+			rt.Partitions = append(rt.Partitions, rp)
 		}
+		req.Topics = append(req.Topics, rt)
 	}
+	return req
+}
 
+// CommitOffsets issues an offset commit request for the input offsets.
+//
+// This function can be used to manually commit offsets when directly consuming
+// partitions outside of an actual consumer group. For example, if you assign
+// partitions manually, but want still use Kafka to checkpoint what you have
+// consumed, you can manually issue an offset commit request with this method.
+//
+// This does not return on authorization failures, instead, authorization
+// failures are included in the responses.
+func (cl *Client) CommitOffsets(ctx context.Context, group string, os Offsets) (OffsetResponses, error) {
+	req := commitOffsetsReq(group, os)
 	resp, err := req.RequestWith(ctx, cl.cl)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,49 @@
+package kadm
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCommitOffsetsReq(t *testing.T) {
+	var os Offsets
+	os.Add(Offset{Topic: "foo", Partition: 0, Offset: 10, LeaderEpoch: 3, Metadata: "meta"})
+	os.Add(Offset{Topic: "foo", Partition: 1, Offset: 20, LeaderEpoch: 4, CommitLeaderEpoch: true})
+	os.Add(Offset{Topic: "bar", Partition: 2, Offset: 30})
+
+	req := commitOffsetsReq("g", os)
+	if req.Group != "g" {
+		t.Errorf("got group %q != exp g", req.Group)
+	}
+
+	type part struct {
+		topic     string
+		partition int32
+		offset    int64
+		epoch     int32
+		meta      string
+	}
+	var got []part
+	for _, rt := range req.Topics {
+		for _, rp := range rt.Partitions {
+			var meta string
+			if rp.Metadata != nil {
+				meta = *rp.Metadata
+			}
+			got = append(got, part{rt.Topic, rp.Partition, rp.Offset, rp.LeaderEpoch, meta})
+		}
+	}
+	sort.Slice(got, func(i, j int) bool {
+		return got[i].topic < got[j].topic || got[i].topic == got[j].topic && got[i].partition < got[j].partition
+	})
+
+	exp := []part{
+		{"bar", 2, 30, -1, ""},
+		{"foo", 0, 10, -1, "meta"},
+		{"foo", 1, 20, 4, ""},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got request partitions %v != exp %v", got, exp)
+	}
+}
@@ -0,0 +1,444 @@
+package kadm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// LagMonitor configures MonitorGroupLag.
+type LagMonitor struct {
+	// Interval is how often to calculate lag. If non-positive, this
+	// defaults to 10s.
+	Interval time.Duration
+
+	// Groups are the groups to monitor. If empty, all groups are
+	// monitored, and groups are re-listed every round.
+	Groups []string
+
+	// StallAfter is how long a partition with lag must go without its
+	// committed offset changing before the partition is considered
+	// stalled. If non-positive, this defaults to five intervals.
+	StallAfter time.Duration
+
+	// NoTimeLag, if true, disables estimating time-based lag. Estimating
+	// time lag requires one additional metadata request each round, and
+	// at least one fetch request per partition leader; see
+	// MonitorGroupLag for the full request count.
+	NoTimeLag bool
+}
+
+// PartitionLag is the lag of a single partition in a group lag snapshot.
+type PartitionLag struct {
+	GroupMemberLag
+
+	// TimeLag is an estimate of how far behind the member is in time:
+	// the time between the snapshot and the timestamp of the record batch
+	// containing the committed offset. This is 0 if there is no lag, and
+	// -1 if time lag is unknown or not being estimated.
+	TimeLag time.Duration
+
+	// CommitUnchangedSince is the time this monitor first saw the
+	// current committed offset.
+	CommitUnchangedSince time.Time
+
+	// Stalled is true if the partition has lag and the committed offset
+	// has not changed for at least the monitor's StallAfter duration.
+	Stalled bool
+}
+
+// GroupLagSnapshot is the lag of a single group at a point in time.
+type GroupLagSnapshot struct {
+	Group DescribedGroup // Group is the described group.
+
+	// Lag is the per-topic, per-partition lag of the members in the
+	// group. Partitions that are not assigned to a member are not
+	// included.
+	Lag map[string]map[int32]PartitionLag
+
+	TotalLag int64 // TotalLag is the sum of all non-negative partition lag.
+
+	Err error // Err is non-nil if the group's committed offsets could not be fetched.
+}
+
+// Stalled returns the stalled partitions in this group.
+func (s GroupLagSnapshot) Stalled() TopicsSet {
+	var ts TopicsSet
+	for t, ps := range s.Lag {
+		for p, l := range ps {
+			if l.Stalled {
+				ts.Add(t, p)
+			}
+		}
+	}
+	return ts
+}
+
+// LagSnapshot is the lag of all monitored groups at a point in time.
+type LagSnapshot struct {
+	Time   time.Time                   // Time is when this snapshot was started.
+	Groups map[string]GroupLagSnapshot // Groups contains the lag for every described group.
+
+	// Err is non-nil if part of this round failed, for example if groups
+	// could not be described or end offsets could not be listed. The
+	// snapshot may still contain partial results, and the monitor keeps
+	// running.
+	Err error
+}
+
+type lagState struct {
+	offset int64
+	since  time.Time
+}
+
+// MonitorGroupLag periodically calculates the lag of consumer groups and
+// calls fn with every snapshot until the context is canceled, at which point
+// this returns the context error. This is the long running version of
+// CalculateGroupLag.
+//
+// Before the first round, this issues one metadata request and one
+// ApiVersions request per broker to check for batched offset fetching. Every
+// round then issues:
+//
+//   - if Groups is empty, one ListGroups request per broker
+//   - one DescribeGroups request per group coordinator
+//   - one metadata request and one ListOffsets request per partition leader,
+//     to list end offsets
+//   - one OffsetFetch request per group coordinator if all brokers support
+//     batched offset fetching (Kafka 3.0+), otherwise one per group
+//   - unless NoTimeLag is set, one metadata request and one fetch request
+//     per partition leader per layer, where the number of layers is the
+//     most distinct lagging committed offsets any single partition has
+//     across the monitored groups (one, if groups do not share partitions)
+//
+// Group coordinators are found with FindCoordinator requests as needed, and
+// the client caches them across rounds.
+//
+// fn is called serially in the monitor goroutine; a slow fn delays the next
+// round.
+func (cl *Client) MonitorGroupLag(ctx context.Context, m LagMonitor, fn func(LagSnapshot)) error {
+	if m.Interval <= 0 {
+		m.Interval = 10 * time.Second
+	}
+	if m.StallAfter <= 0 {
+		m.StallAfter = 5 * m.Interval
+	}
+
+	batched := cl.supportsBatchedOffsetFetch(ctx)
+	state := make(map[string]map[string]map[int32]lagState)
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		fn(cl.lagRound(ctx, m, batched, state))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cl *Client) lagRound(ctx context.Context, m LagMonitor, batched bool, state map[string]map[string]map[int32]lagState) LagSnapshot {
+	s := LagSnapshot{
+		Time:   time.Now(),
+		Groups: make(map[string]GroupLagSnapshot),
+	}
+	setErr := func(err error) {
+		if s.Err == nil && err != nil {
+			s.Err = err
+		}
+	}
+
+	described, err := cl.DescribeGroups(ctx, m.Groups...)
+	var se *ShardErrors
+	if err != nil && !errors.As(err, &se) {
+		s.Err = err
+		return s
+	}
+	setErr(err)
+
+	var groups []string
+	for g, d := range described {
+		if d.Err != nil {
+			s.Groups[g] = GroupLagSnapshot{Group: d, Err: d.Err}
+			continue
+		}
+		groups = append(groups, g)
+	}
+	if len(groups) == 0 {
+		return s
+	}
+
+	var ends ListedOffsets
+	if topics := described.AssignedPartitions().Topics(); len(topics) > 0 {
+		ends, err = cl.ListEndOffsets(ctx, topics...)
+		setErr(err)
+	}
+
+	var commits FetchOffsetsResponses
+	if batched {
+		commits = cl.fetchOffsetsBatched(ctx, groups)
+	} else {
+		commits = cl.FetchManyOffsets(ctx, groups...)
+	}
+
+	for _, g := range groups {
+		d := described[g]
+		commit := commits[g]
+		gs := GroupLagSnapshot{
+			Group: d,
+			Lag:   make(map[string]map[int32]PartitionLag),
+			Err:   commit.Err,
+		}
+		if commit.Err == nil {
+			for t, ps := range CalculateGroupLag(d, commit.Fetched, ends) {
+				lt := make(map[int32]PartitionLag, len(ps))
+				gs.Lag[t] = lt
+				for p, l := range ps {
+					lt[p] = PartitionLag{GroupMemberLag: l, TimeLag: -1}
+					if l.Lag > 0 {
+						gs.TotalLag += l.Lag
+					}
+				}
+			}
+		}
+		s.Groups[g] = gs
+	}
+
+	if !m.NoTimeLag {
+		setErr(cl.estimateTimeLag(ctx, s))
+	}
+	trackStalls(s, m.StallAfter, state)
+	return s
+}
+
+// trackStalls updates the stall state from the snapshot and marks stalled
+// partitions. State for groups and partitions that are no longer in the
+// snapshot is dropped.
+func trackStalls(s LagSnapshot, stallAfter time.Duration, state map[string]map[string]map[int32]lagState) {
+	for g := range state {
+		if _, ok := s.Groups[g]; !ok {
+			delete(state, g)
+		}
+	}
+	for g, gs := range s.Groups {
+		if gs.Err != nil {
+			continue
+		}
+		prior := state[g]
+		now := make(map[string]map[int32]lagState, len(gs.Lag))
+		for t, ps := range gs.Lag {
+			nt := make(map[int32]lagState, len(ps))
+			now[t] = nt
+			for p, l := range ps {
+				st, ok := prior[t][p]
+				if !ok || st.offset != l.Commit.Offset {
+					st = lagState{offset: l.Commit.Offset, since: s.Time}
+				}
+				nt[p] = st
+				l.CommitUnchangedSince = st.since
+				l.Stalled = l.Err == nil && l.Lag > 0 && s.Time.Sub(st.since) >= stallAfter
+				ps[p] = l
+			}
+		}
+		state[g] = now
+	}
+}
+
+// estimateTimeLag sets the TimeLag for every lagging partition in the
+// snapshot by reading the timestamp of the record batch at each committed
+// offset. Every layer from timeLagLayers is fetched separately, using the
+// same metadata.
+func (cl *Client) estimateTimeLag(ctx context.Context, s LagSnapshot) error {
+	layers := timeLagLayers(s)
+	timestamps := make(map[lagOffset]batchTimestamp)
+	if len(layers) > 0 {
+		// The first layer contains every topic, so we load metadata
+		// once and reuse it for every layer.
+		topics := make([]string, 0, len(layers[0]))
+		for t := range layers[0] {
+			topics = append(topics, t)
+		}
+		meta, err := cl.Metadata(ctx, topics...)
+		if err != nil {
+			return err
+		}
+		for _, layer := range layers {
+			for t, ps := range cl.batchTimestampsWith(ctx, meta, layer) {
+				for p, bt := range ps {
+					timestamps[lagOffset{t, p, layer[t][p].Offset}] = bt
+				}
+			}
+		}
+	}
+	applyTimeLag(s, timestamps)
+	return nil
+}
+
+// lagOffset is a committed offset in a partition.
+type lagOffset struct {
+	t string
+	p int32
+	o int64
+}
+
+// timeLagLayers returns the distinct committed offsets of all lagging
+// partitions in the snapshot. Multiple groups can be committed at different
+// offsets in the same partition, but a fetch request can only contain a
+// partition once, so offsets are split into layers where each layer contains
+// a partition at most once.
+func timeLagLayers(s LagSnapshot) []Offsets {
+	var (
+		layers []Offsets
+		seen   = make(map[lagOffset]bool)
+	)
+	for _, gs := range s.Groups {
+		for t, ps := range gs.Lag {
+			for p, l := range ps {
+				o := l.Commit.Offset
+				if l.Err != nil || l.Lag <= 0 || o < 0 || seen[lagOffset{t, p, o}] {
+					continue
+				}
+				seen[lagOffset{t, p, o}] = true
+				placed := false
+				for i := range layers {
+					if _, exists := layers[i][t][p]; !exists {
+						layers[i].AddOffset(t, p, o)
+						placed = true
+						break
+					}
+				}
+				if !placed {
+					var os Offsets
+					os.AddOffset(t, p, o)
+					layers = append(layers, os)
+				}
+			}
+		}
+	}
+	return layers
+}
+
+// applyTimeLag sets the TimeLag of partitions in the snapshot: partitions
+// without lag have no time lag, and lagging partitions have the time between
+// the snapshot and the timestamp of the batch at their committed offset.
+// Partitions whose timestamp is unknown keep a TimeLag of -1.
+func applyTimeLag(s LagSnapshot, timestamps map[lagOffset]batchTimestamp) {
+	for _, gs := range s.Groups {
+		for t, ps := range gs.Lag {
+			for p, l := range ps {
+				if l.Err != nil || l.Lag < 0 {
+					continue
+				}
+				if l.Lag == 0 {
+					l.TimeLag = 0
+					ps[p] = l
+					continue
+				}
+				bt, ok := timestamps[lagOffset{t, p, l.Commit.Offset}]
+				if !ok || bt.err != nil {
+					continue
+				}
+				l.TimeLag = 0
+				if bt.ts >= 0 {
+					if lag := s.Time.Sub(time.Unix(0, bt.ts*1e6)); lag > 0 {
+						l.TimeLag = lag
+					}
+				}
+				ps[p] = l
+			}
+		}
+	}
+}
+
+// supportsBatchedOffsetFetch returns whether every broker supports
+// OffsetFetch v8+, which allows fetching offsets for many groups at once.
+func (cl *Client) supportsBatchedOffsetFetch(ctx context.Context) bool {
+	brokers, err := cl.ListBrokers(ctx)
+	if err != nil || len(brokers) == 0 {
+		return false
+	}
+	offsetFetchKey := kmsg.NewPtrOffsetFetchRequest().Key()
+	for _, b := range brokers {
+		req := kmsg.NewPtrApiVersionsRequest()
+		kresp, err := cl.cl.Broker(int(b.NodeID)).Request(ctx, req)
+		if err != nil {
+			return false
+		}
+		resp := kresp.(*kmsg.ApiVersionsResponse)
+		var supported bool
+		for _, k := range resp.ApiKeys {
+			if k.ApiKey == offsetFetchKey {
+				supported = k.MaxVersion >= 8
+			}
+		}
+		if !supported {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchOffsetsBatched fetches committed offsets for many groups, issuing one
+// request per group coordinator. This requires Kafka 3.0+, as well as a kgo
+// that shards v8 OffsetFetch groups by coordinator, which the kgo version
+// required by this module does.
+func (cl *Client) fetchOffsetsBatched(ctx context.Context, groups []string) FetchOffsetsResponses {
+	req := kmsg.NewPtrOffsetFetchRequest()
+	for _, g := range groups {
+		rg := kmsg.NewOffsetFetchRequestGroup()
+		rg.Group = g
+		req.Groups = append(req.Groups, rg)
+	}
+
+	fetched := make(FetchOffsetsResponses, len(groups))
+	for _, shard := range cl.cl.RequestSharded(ctx, req) {
+		if shard.Err != nil {
+			for _, g := range shard.Req.(*kmsg.OffsetFetchRequest).Groups {
+				fetched[g.Group] = FetchOffsetsResponse{Group: g.Group, Err: shard.Err}
+			}
+			continue
+		}
+		resp := shard.Resp.(*kmsg.OffsetFetchResponse)
+		for _, g := range resp.Groups {
+			f := FetchOffsetsResponse{Group: g.Group}
+			if f.Err = maybeAuthErr(g.ErrorCode); f.Err == nil {
+				f.Err = kerr.ErrorForCode(g.ErrorCode)
+			}
+			if f.Err == nil {
+				f.Fetched = make(OffsetResponses)
+				for _, t := range g.Topics {
+					rt := make(map[int32]OffsetResponse)
+					f.Fetched[t.Topic] = rt
+					for _, p := range t.Partitions {
+						var meta string
+						if p.Metadata != nil {
+							meta = *p.Metadata
+						}
+						rt[p.Partition] = OffsetResponse{
+							Offset: Offset{
+								Topic:       t.Topic,
+								Partition:   p.Partition,
+								Offset:      p.Offset,
+								LeaderEpoch: p.LeaderEpoch,
+								Metadata:    meta,
+							},
+							Err: kerr.ErrorForCode(p.ErrorCode),
+						}
+					}
+				}
+			}
+			fetched[g.Group] = f
+		}
+	}
+	for _, g := range groups {
+		if _, ok := fetched[g]; !ok {
+			fetched[g] = FetchOffsetsResponse{Group: g, Err: errors.New("group missing from offset fetch response")}
+		}
+	}
+	return fetched
+}
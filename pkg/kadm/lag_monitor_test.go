@@ -0,0 +1,181 @@
+package kadm
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// lagSnapshot returns a snapshot at time at, with lags mapping groups to
+// partitions of topic "t" to their {commit, lag}.
+func lagSnapshot(at time.Time, lags map[string]map[int32][2]int64) LagSnapshot {
+	s := LagSnapshot{
+		Time:   at,
+		Groups: make(map[string]GroupLagSnapshot),
+	}
+	for g, gl := range lags {
+		ps := make(map[int32]PartitionLag)
+		for p, ol := range gl {
+			ps[p] = PartitionLag{
+				GroupMemberLag: GroupMemberLag{
+					Commit: Offset{Topic: "t", Partition: p, Offset: ol[0]},
+					Lag:    ol[1],
+				},
+				TimeLag: -1,
+			}
+		}
+		s.Groups[g] = GroupLagSnapshot{Lag: map[string]map[int32]PartitionLag{"t": ps}}
+	}
+	return s
+}
+
+func TestTrackStalls(t *testing.T) {
+	var (
+		start      = time.Unix(1000, 0)
+		stallAfter = 30 * time.Second
+		state      = make(map[string]map[string]map[int32]lagState)
+	)
+
+	type exp struct {
+		stalled bool
+		since   time.Duration // since start
+	}
+	for _, round := range []struct {
+		name string
+		at   time.Duration
+		lags map[int32][2]int64 // partition => {commit, lag}
+		exp  map[int32]exp
+	}{
+		{
+			name: "first round starts tracking",
+			lags: map[int32][2]int64{0: {10, 5}, 1: {20, 0}},
+			exp:  map[int32]exp{0: {false, 0}, 1: {false, 0}},
+		},
+		{
+			name: "not yet stalled",
+			at:   20 * time.Second,
+			lags: map[int32][2]int64{0: {10, 8}, 1: {20, 0}},
+			exp:  map[int32]exp{0: {false, 0}, 1: {false, 0}},
+		},
+		{
+			name: "stall starts, zero lag never stalls",
+			at:   30 * time.Second,
+			lags: map[int32][2]int64{0: {10, 9}, 1: {20, 0}},
+			exp:  map[int32]exp{0: {true, 0}, 1: {false, 0}},
+		},
+		{
+			name: "progress resets the stall",
+			at:   40 * time.Second,
+			lags: map[int32][2]int64{0: {11, 9}, 1: {20, 0}},
+			exp:  map[int32]exp{0: {false, 40 * time.Second}, 1: {false, 0}},
+		},
+		{
+			name: "stalls again without progress",
+			at:   70 * time.Second,
+			lags: map[int32][2]int64{0: {11, 12}, 1: {20, 0}},
+			exp:  map[int32]exp{0: {true, 40 * time.Second}, 1: {false, 0}},
+		},
+	} {
+		s := lagSnapshot(start.Add(round.at), map[string]map[int32][2]int64{"g": round.lags})
+		trackStalls(s, stallAfter, state)
+
+		var expStalled int
+		for p, e := range round.exp {
+			l := s.Groups["g"].Lag["t"][p]
+			if l.Stalled != e.stalled || !l.CommitUnchangedSince.Equal(start.Add(e.since)) {
+				t.Errorf("%s: partition %d: got stalled %v since %v, exp stalled %v since %v",
+					round.name, p, l.Stalled, l.CommitUnchangedSince, e.stalled, start.Add(e.since))
+			}
+			if e.stalled {
+				expStalled++
+			}
+		}
+		if got := len(s.Groups["g"].Stalled()["t"]); got != expStalled {
+			t.Errorf("%s: got %d stalled partitions, exp %d", round.name, got, expStalled)
+		}
+	}
+
+	trackStalls(LagSnapshot{Time: start, Groups: map[string]GroupLagSnapshot{}}, stallAfter, state)
+	if len(state) != 0 {
+		t.Errorf("got %d groups of state after the group disappeared, exp 0", len(state))
+	}
+}
+
+func TestTimeLagLayers(t *testing.T) {
+	s := lagSnapshot(time.Now(), map[string]map[int32][2]int64{
+		"a": {0: {10, 5}, 1: {20, 5}, 2: {30, 0}, 3: {-1, 5}},
+		"b": {0: {10, 5}, 1: {25, 1}, 2: {29, 1}},
+		"c": {1: {22, 3}, 4: {40, -1}},
+	})
+
+	layers := timeLagLayers(s)
+
+	// Partition 1 has three distinct lagging commits, so we need three
+	// layers. Partition 0 is shared by a and b at the same offset, and
+	// partitions with no lag, an unknown lag, or no commit are skipped.
+	got := make(map[int32][]int64)
+	for i, layer := range layers {
+		for t2, ps := range layer {
+			if t2 != "t" {
+				t.Errorf("layer %d: unexpected topic %q", i, t2)
+			}
+			for p, o := range ps {
+				got[p] = append(got[p], o.Offset)
+			}
+		}
+	}
+	for _, os := range got {
+		sort.Slice(os, func(i, j int) bool { return os[i] < os[j] })
+	}
+	exp := map[int32][]int64{
+		0: {10},
+		1: {20, 22, 25},
+		2: {29},
+	}
+	if len(layers) != 3 {
+		t.Errorf("got %d layers, exp 3", len(layers))
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got layered offsets %v != exp %v", got, exp)
+	}
+}
+
+func TestApplyTimeLag(t *testing.T) {
+	now := time.Unix(1000, 0)
+	ms := func(d time.Duration) int64 { return now.Add(-d).UnixNano() / 1e6 }
+
+	s := lagSnapshot(now, map[string]map[int32][2]int64{
+		"g": {
+			0: {10, 5}, // batch 5s old
+			1: {20, 0}, // no lag
+			2: {30, 5}, // at the high watermark
+			3: {40, 5}, // fetch error
+			4: {50, 5}, // not fetched
+			5: {60, 5}, // batch from the future
+			6: {70, -1},
+		},
+	})
+	applyTimeLag(s, map[lagOffset]batchTimestamp{
+		{"t", 0, 10}: {ts: ms(5 * time.Second)},
+		{"t", 2, 30}: {ts: -1},
+		{"t", 3, 40}: {err: errors.New("fetch failed")},
+		{"t", 5, 60}: {ts: ms(-time.Second)},
+	})
+
+	exp := map[int32]time.Duration{
+		0: 5 * time.Second,
+		1: 0,
+		2: 0,
+		3: -1,
+		4: -1,
+		5: 0,
+		6: -1,
+	}
+	for p, e := range exp {
+		if got := s.Groups["g"].Lag["t"][p].TimeLag; got != e {
+			t.Errorf("partition %d: got time lag %v != exp %v", p, got, e)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package kadm
+
+import (
+	"context"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// DescribedLogDirPartition is the information for a single partition's
+// described log directory.
+type DescribedLogDirPartition struct {
+	Broker    int32  // Broker is the broker this partition is on.
+	Dir       string // Dir is the directory this partition lives in.
+	Topic     string // Topic is the topic for this partition.
+	Partition int32  // Partition is this partition.
+	Size      int64  // Size is the total size of the log segments of this partition, in bytes.
+
+	// OffsetLag is how far behind the log end offset this partition is.
+	// The math is:
+	//
+	//     if IsFuture {
+	//         logEndOffset - futureLogEndOffset
+	//     } else {
+	//         max(highWaterMark-logEndOffset, 0)
+	//     }
+	//
+	OffsetLag int64
+	// IsFuture is true if this replica was created by an
+	// AlterReplicaLogDirsRequest and will replace the current log of the
+	// replica in the future.
+	IsFuture bool
+}
+
+// DescribedLogDirTopics contains per-partition described log directories.
+type DescribedLogDirTopics map[string]map[int32]DescribedLogDirPartition
+
+// Sorted returns the described partitions sorted by topic and partition.
+func (ds DescribedLogDirTopics) Sorted() []DescribedLogDirPartition {
+	var all []DescribedLogDirPartition
+	for _, ps := range ds {
+		for _, d := range ps {
+			all = append(all, d)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		l, r := all[i], all[j]
+		return l.Topic < r.Topic || l.Topic == r.Topic && l.Partition < r.Partition
+	})
+	return all
+}
+
+// DescribedLogDir is a described log directory.
+type DescribedLogDir struct {
+	Broker int32                 // Broker is the broker being described.
+	Dir    string                // Dir is the described directory.
+	Topics DescribedLogDirTopics // Partitions are the partitions in this directory.
+	Err    error                 // Err is non-nil if this directory could not be described.
+}
+
+// Size returns the total size of all partitions in this directory. This
+// includes future replicas, which take up disk space as well.
+func (ds DescribedLogDir) Size() int64 {
+	var tot int64
+	for _, ps := range ds.Topics {
+		for _, d := range ps {
+			tot += d.Size
+		}
+	}
+	return tot
+}
+
+// DescribedLogDirs contains per-directory responses to described log
+// directories for a single broker.
+type DescribedLogDirs map[string]DescribedLogDir
+
+// Sorted returns the described directories sorted by directory name.
+func (ds DescribedLogDirs) Sorted() []DescribedLogDir {
+	s := make([]DescribedLogDir, 0, len(ds))
+	for _, d := range ds {
+		s = append(s, d)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Dir < s[j].Dir })
+	return s
+}
+
+// EachError calls fn for every directory that has a non-nil error.
+func (ds DescribedLogDirs) EachError(fn func(DescribedLogDir)) {
+	for _, d := range ds {
+		if d.Err != nil {
+			fn(d)
+		}
+	}
+}
+
+// EachPartition calls fn for every partition in every directory.
+func (ds DescribedLogDirs) EachPartition(fn func(DescribedLogDirPartition)) {
+	for _, d := range ds {
+		for _, ps := range d.Topics {
+			for _, p := range ps {
+				fn(p)
+			}
+		}
+	}
+}
+
+// Size returns the total size of all directories.
+func (ds DescribedLogDirs) Size() int64 {
+	var tot int64
+	for _, d := range ds {
+		tot += d.Size()
+	}
+	return tot
+}
+
+// TopicSizes returns the total size of each topic across all directories.
+func (ds DescribedLogDirs) TopicSizes() map[string]int64 {
+	sizes := make(map[string]int64)
+	ds.EachPartition(func(p DescribedLogDirPartition) {
+		sizes[p.Topic] += p.Size
+	})
+	return sizes
+}
+
+// DescribedAllLogDirs contains per-broker responses to described log
+// directories.
+type DescribedAllLogDirs map[int32]DescribedLogDirs
+
+// Sorted returns each broker's directories sorted by broker ID and directory
+// name.
+func (ds DescribedAllLogDirs) Sorted() []DescribedLogDir {
+	var all []DescribedLogDir
+	for _, bds := range ds {
+		all = append(all, bds.Sorted()...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Broker < all[j].Broker })
+	return all
+}
+
+// EachError calls fn for every directory that has a non-nil error.
+func (ds DescribedAllLogDirs) EachError(fn func(DescribedLogDir)) {
+	for _, bds := range ds {
+		bds.EachError(fn)
+	}
+}
+
+// EachPartition calls fn for every partition in every directory on every
+// broker.
+func (ds DescribedAllLogDirs) EachPartition(fn func(DescribedLogDirPartition)) {
+	for _, bds := range ds {
+		bds.EachPartition(fn)
+	}
+}
+
+// TopicSizes returns the total size of each topic across all brokers. This
+// includes every replica of every partition.
+func (ds DescribedAllLogDirs) TopicSizes() map[string]int64 {
+	sizes := make(map[string]int64)
+	ds.EachPartition(func(p DescribedLogDirPartition) {
+		sizes[p.Topic] += p.Size
+	})
+	return sizes
+}
+
+// BrokerSizes returns the total size of all directories on each broker.
+func (ds DescribedAllLogDirs) BrokerSizes() map[int32]int64 {
+	sizes := make(map[int32]int64)
+	for b, bds := range ds {
+		sizes[b] = bds.Size()
+	}
+	return sizes
+}
+
+// DescribeAllLogDirs describes the log directories for every input topic
+// partition on every broker. If the input set is nil, this describes all log
+// directories on all brokers. If the input set is non-nil but empty, this
+// describes nothing and returns empty results without issuing a request.
+//
+// This may return *ShardErrors or an *AuthError.
+func (cl *Client) DescribeAllLogDirs(ctx context.Context, s TopicsSet) (DescribedAllLogDirs, error) {
+	if s != nil && len(s) == 0 {
+		return make(DescribedAllLogDirs), nil
+	}
+	req := describeLogDirsReq(s)
+	shards := cl.cl.RequestSharded(ctx, req)
+	resps := make(DescribedAllLogDirs)
+	return resps, shardErrEachBroker(req, shards, func(b BrokerDetail, kr kmsg.Response) error {
+		return describeLogDirsInto(b.NodeID, kr.(*kmsg.DescribeLogDirsResponse), resps)
+	})
+}
+
+// DescribeBrokerLogDirs describes the log directories for the input topic
+// partitions on the given broker. If the input set is nil, this describes all
+// log directories on the broker. If the input set is non-nil but empty, this
+// describes the broker's directories without any partitions.
+//
+// This returns an error if the request fails to be issued, or an *AuthError.
+func (cl *Client) DescribeBrokerLogDirs(ctx context.Context, broker int32, s TopicsSet) (DescribedLogDirs, error) {
+	req := describeLogDirsReq(s)
+	kresp, err := cl.cl.Broker(int(broker)).Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resps := make(DescribedAllLogDirs)
+	if err := describeLogDirsInto(broker, kresp.(*kmsg.DescribeLogDirsResponse), resps); err != nil {
+		return nil, err
+	}
+	if resps[broker] == nil {
+		return make(DescribedLogDirs), nil
+	}
+	return resps[broker], nil
+}
+
+// describeLogDirsReq returns a request for the input set. Null topics
+// describes everything, so we only leave topics null if the set is nil.
+func describeLogDirsReq(s TopicsSet) *kmsg.DescribeLogDirsRequest {
+	req := kmsg.NewPtrDescribeLogDirsRequest()
+	if s != nil {
+		req.Topics = make([]kmsg.DescribeLogDirsRequestTopic, 0, len(s))
+	}
+	for t, ps := range s {
+		rt := kmsg.NewDescribeLogDirsRequestTopic()
+		rt.Topic = t
+		for p := range ps {
+			rt.Partitions = append(rt.Partitions, p)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+	return req
+}
+
+func describeLogDirsInto(broker int32, resp *kmsg.DescribeLogDirsResponse, into DescribedAllLogDirs) error {
+	dirs := into[broker]
+	if dirs == nil {
+		dirs = make(DescribedLogDirs)
+		into[broker] = dirs
+	}
+	for _, rd := range resp.Dirs {
+		if err := maybeAuthErr(rd.ErrorCode); err != nil {
+			return err
+		}
+		d := DescribedLogDir{
+			Broker: broker,
+			Dir:    rd.Dir,
+			Topics: make(DescribedLogDirTopics),
+			Err:    kerr.ErrorForCode(rd.ErrorCode),
+		}
+		for _, rt := range rd.Topics {
+			ps := make(map[int32]DescribedLogDirPartition)
+			d.Topics[rt.Topic] = ps
+			for _, rp := range rt.Partitions {
+				ps[rp.Partition] = DescribedLogDirPartition{
+					Broker:    broker,
+					Dir:       rd.Dir,
+					Topic:     rt.Topic,
+					Partition: rp.Partition,
+					Size:      rp.Size,
+					OffsetLag: rp.OffsetLag,
+					IsFuture:  rp.IsFuture,
+				}
+			}
+		}
+		dirs[rd.Dir] = d
+	}
+	return nil
+}
+
+// AlterReplicaLogDirsReq is the input for a request to alter replica log
+// directories. The key is the directory to move partitions to, and the value
+// is the set of partitions to move into the directory.
+type AlterReplicaLogDirsReq map[string]TopicsSet
+
+// Add merges the input topic set into the given directory.
+func (r *AlterReplicaLogDirsReq) Add(d string, s TopicsSet) {
+	if *r == nil {
+		*r = make(map[string]TopicsSet)
+	}
+	existing := (*r)[d]
+	s.Each(func(t string, p int32) {
+		existing.Add(t, p)
+	})
+	(*r)[d] = existing
+}
+
+// AlterReplicaLogDirsResponse contains the response for an individual
+// partition's log directory alteration.
+type AlterReplicaLogDirsResponse struct {
+	Broker    int32  // Broker is the broker this response came from.
+	Dir       string // Dir is the directory this partition was requested to be moved to.
+	Topic     string // Topic is the topic for this partition.
+	Partition int32  // Partition is the partition that was moved.
+	Err       error  // Err is non-nil if this move had an error.
+}
+
+// AlterReplicaLogDirsResponses contains per-broker, per-topic, per-partition
+// responses to altered replica log directories.
+type AlterReplicaLogDirsResponses map[int32]map[string]map[int32]AlterReplicaLogDirsResponse
+
+// Sorted returns the responses sorted by broker, topic, and partition.
+func (rs AlterReplicaLogDirsResponses) Sorted() []AlterReplicaLogDirsResponse {
+	var all []AlterReplicaLogDirsResponse
+	rs.Each(func(r AlterReplicaLogDirsResponse) {
+		all = append(all, r)
+	})
+	sort.Slice(all, func(i, j int) bool {
+		l, r := all[i], all[j]
+		if l.Broker != r.Broker {
+			return l.Broker < r.Broker
+		}
+		return l.Topic < r.Topic || l.Topic == r.Topic && l.Partition < r.Partition
+	})
+	return all
+}
+
+// Each calls fn for every response.
+func (rs AlterReplicaLogDirsResponses) Each(fn func(AlterReplicaLogDirsResponse)) {
+	for _, ts := range rs {
+		for _, ps := range ts {
+			for _, r := range ps {
+				fn(r)
+			}
+		}
+	}
+}
+
+// EachError calls fn for every response that has a non-nil error.
+func (rs AlterReplicaLogDirsResponses) EachError(fn func(AlterReplicaLogDirsResponse)) {
+	rs.Each(func(r AlterReplicaLogDirsResponse) {
+		if r.Err != nil {
+			fn(r)
+		}
+	})
+}
+
+// Error returns the first error in the responses, if any.
+func (rs AlterReplicaLogDirsResponses) Error() error {
+	var err error
+	rs.EachError(func(r AlterReplicaLogDirsResponse) {
+		if err == nil {
+			err = r.Err
+		}
+	})
+	return err
+}
+
+// Ok returns true if there are no errors. This is a shortcut for rs.Error() ==
+// nil.
+func (rs AlterReplicaLogDirsResponses) Ok() bool {
+	return rs.Error() == nil
+}
+
+// AlterReplicaLogDirs alters the log directories for the input topic
+// partitions, moving each partition to the requested directory. This function
+// moves all replicas on any broker; to move replicas on a single broker, use
+// AlterBrokerReplicaLogDirs.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses. This may return
+// *ShardErrors.
+func (cl *Client) AlterReplicaLogDirs(ctx context.Context, alter AlterReplicaLogDirsReq) (AlterReplicaLogDirsResponses, error) {
+	req, dirs := alterReplicaLogDirsReq(alter)
+	shards := cl.cl.RequestSharded(ctx, req)
+	resps := make(AlterReplicaLogDirsResponses)
+	return resps, shardErrEachBroker(req, shards, func(b BrokerDetail, kr kmsg.Response) error {
+		alterReplicaLogDirsInto(b.NodeID, kr.(*kmsg.AlterReplicaLogDirsResponse), dirs, resps)
+		return nil
+	})
+}
+
+// AlterBrokerReplicaLogDirs alters the log directories for the input topic
+// partitions on the given broker, moving each partition to the requested
+// directory.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses. This only returns an
+// error if the request fails to be issued.
+func (cl *Client) AlterBrokerReplicaLogDirs(ctx context.Context, broker int32, alter AlterReplicaLogDirsReq) (AlterReplicaLogDirsResponses, error) {
+	req, dirs := alterReplicaLogDirsReq(alter)
+	kresp, err := cl.cl.Broker(int(broker)).Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resps := make(AlterReplicaLogDirsResponses)
+	alterReplicaLogDirsInto(broker, kresp.(*kmsg.AlterReplicaLogDirsResponse), dirs, resps)
+	return resps, nil
+}
+
+// alterReplicaLogDirsReq returns the request for the input, as well as a
+// lookup of each topic partition to the directory it is being moved to (the
+// response does not include the directory).
+func alterReplicaLogDirsReq(alter AlterReplicaLogDirsReq) (*kmsg.AlterReplicaLogDirsRequest, map[string]map[int32]string) {
+	req := kmsg.NewPtrAlterReplicaLogDirsRequest()
+	dirs := make(map[string]map[int32]string)
+	for dir, s := range alter {
+		rd := kmsg.NewAlterReplicaLogDirsRequestDir()
+		rd.Dir = dir
+		for t, ps := range s {
+			rt := kmsg.NewAlterReplicaLogDirsRequestDirTopic()
+			rt.Topic = t
+			tdirs := dirs[t]
+			if tdirs == nil {
+				tdirs = make(map[int32]string)
+				dirs[t] = tdirs
+			}
+			for p := range ps {
+				rt.Partitions = append(rt.Partitions, p)
+				tdirs[p] = dir
+			}
+			rd.Topics = append(rd.Topics, rt)
+		}
+		req.Dirs = append(req.Dirs, rd)
+	}
+	return req, dirs
+}
+
+func alterReplicaLogDirsInto(broker int32, resp *kmsg.AlterReplicaLogDirsResponse, dirs map[string]map[int32]string, into AlterReplicaLogDirsResponses) {
+	ts := into[broker]
+	if ts == nil {
+		ts = make(map[string]map[int32]AlterReplicaLogDirsResponse)
+		into[broker] = ts
+	}
+	for _, rt := range resp.Topics {
+		ps := ts[rt.Topic]
+		if ps == nil {
+			ps = make(map[int32]AlterReplicaLogDirsResponse)
+			ts[rt.Topic] = ps
+		}
+		for _, rp := range rt.Partitions {
+			ps[rp.Partition] = AlterReplicaLogDirsResponse{
+				Broker:    broker,
+				Dir:       dirs[rt.Topic][rp.Partition],
+				Topic:     rt.Topic,
+				Partition: rp.Partition,
+				Err:       kerr.ErrorForCode(rp.ErrorCode),
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package kadm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDescribeAllLogDirsEmpty(t *testing.T) {
+	// A non-nil empty set describes nothing and issues no request: the
+	// client has no underlying kgo client, so a request would panic.
+	ds, err := new(Client).DescribeAllLogDirs(context.Background(), make(TopicsSet))
+	if err != nil || ds == nil || len(ds) != 0 {
+		t.Errorf("got %v (%v), exp empty non-nil results", ds, err)
+	}
+}
+
+func TestDescribeLogDirsReq(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		s       TopicsSet
+		expNil  bool
+		expLen  int
+		expPart []int32
+	}{
+		{name: "nil set describes all", s: nil, expNil: true},
+		{name: "empty set describes no partitions", s: make(TopicsSet)},
+		{name: "set", s: TopicsSet{"t": {1: {}}}, expLen: 1, expPart: []int32{1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := describeLogDirsReq(test.s)
+			if gotNil := req.Topics == nil; gotNil != test.expNil {
+				t.Fatalf("got nil topics? %v, exp nil? %v", gotNil, test.expNil)
+			}
+			if len(req.Topics) != test.expLen {
+				t.Fatalf("got %d topics != exp %d", len(req.Topics), test.expLen)
+			}
+			if test.expLen > 0 && !reflect.DeepEqual(req.Topics[0].Partitions, test.expPart) {
+				t.Errorf("got partitions %v != exp %v", req.Topics[0].Partitions, test.expPart)
+			}
+		})
+	}
+}
@@ -289,12 +289,26 @@ func (cl *Client) listOffsets(ctx context.Context, isolation int8, timestamp int
 		return nil, err
 	}
 
+	timestamps := make(map[string]map[int32]int64, len(tds))
+	for t, td := range tds {
+		ts := make(map[int32]int64, len(td.Partitions))
+		for p := range td.Partitions {
+			ts[p] = timestamp
+		}
+		timestamps[t] = ts
+	}
+	return cl.listOffsetsAt(ctx, isolation, timestamps)
+}
+
+// listOffsetsAt lists offsets for the given partitions, with each partition
+// being listed at its own timestamp.
+func (cl *Client) listOffsetsAt(ctx context.Context, isolation int8, timestamps map[string]map[int32]int64) (ListedOffsets, error) {
 	req := kmsg.NewPtrListOffsetsRequest()
 	req.IsolationLevel = isolation
-	for t, td := range tds {
+	for t, ps := range timestamps {
 		rt := kmsg.NewListOffsetsRequestTopic()
 		rt.Topic = t
-		for p := range td.Partitions {
+		for p, timestamp := range ps {
 			rp := kmsg.NewListOffsetsRequestTopicPartition()
 			rp.Partition = p
 			rp.Timestamp = timestamp
@@ -0,0 +1,457 @@
+package kadm
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// AlterPartitionAssignmentsReq is the input for a request to alter partition
+// assignments. The keys are topics and partitions, and the final slice
+// corresponds to brokers that replicas will be assigned to. If the brokers
+// for a given partition are null, the request will *cancel* any active
+// reassignment for that partition.
+type AlterPartitionAssignmentsReq map[string]map[int32][]int32
+
+// Assign specifies brokers that a partition should be placed on. Using null
+// for the brokers cancels a pending reassignment of the partition.
+func (r *AlterPartitionAssignmentsReq) Assign(t string, p int32, brokers []int32) {
+	if *r == nil {
+		*r = make(map[string]map[int32][]int32)
+	}
+	ps := (*r)[t]
+	if ps == nil {
+		ps = make(map[int32][]int32)
+		(*r)[t] = ps
+	}
+	ps[p] = brokers
+}
+
+// CancelAssign cancels a reassignment of the given partition.
+func (r *AlterPartitionAssignmentsReq) CancelAssign(t string, p int32) {
+	r.Assign(t, p, nil)
+}
+
+// AlterPartitionAssignmentsResponse contains a response for an individual
+// partition that was assigned.
+type AlterPartitionAssignmentsResponse struct {
+	Topic     string // Topic is the topic that was assigned.
+	Partition int32  // Partition is the partition that was assigned.
+	Err       error  // Err is non-nil if this assignment errored.
+}
+
+// AlterPartitionAssignmentsResponses contains responses to all partitions in an
+// alter assignment request.
+type AlterPartitionAssignmentsResponses map[string]map[int32]AlterPartitionAssignmentsResponse
+
+// Sorted returns the responses sorted by topic and partition.
+func (rs AlterPartitionAssignmentsResponses) Sorted() []AlterPartitionAssignmentsResponse {
+	var all []AlterPartitionAssignmentsResponse
+	rs.Each(func(r AlterPartitionAssignmentsResponse) {
+		all = append(all, r)
+	})
+	sort.Slice(all, func(i, j int) bool {
+		l, r := all[i], all[j]
+		return l.Topic < r.Topic || l.Topic == r.Topic && l.Partition < r.Partition
+	})
+	return all
+}
+
+// Each calls fn for every response.
+func (rs AlterPartitionAssignmentsResponses) Each(fn func(AlterPartitionAssignmentsResponse)) {
+	for _, ps := range rs {
+		for _, r := range ps {
+			fn(r)
+		}
+	}
+}
+
+// EachError calls fn for every response that has a non-nil error.
+func (rs AlterPartitionAssignmentsResponses) EachError(fn func(AlterPartitionAssignmentsResponse)) {
+	rs.Each(func(r AlterPartitionAssignmentsResponse) {
+		if r.Err != nil {
+			fn(r)
+		}
+	})
+}
+
+// Error returns the first error in the responses, if any.
+func (rs AlterPartitionAssignmentsResponses) Error() error {
+	for _, ps := range rs {
+		for _, r := range ps {
+			if r.Err != nil {
+				return r.Err
+			}
+		}
+	}
+	return nil
+}
+
+// Ok returns true if there are no errors. This is a shortcut for rs.Error() ==
+// nil.
+func (rs AlterPartitionAssignmentsResponses) Ok() bool {
+	return rs.Error() == nil
+}
+
+// AlterPartitionAssignments alters partition assignments for the requested
+// partitions, returning an error only if the request could not be issued.
+//
+// A top-level error in the response (for example, the request timing out on
+// the controller) is set as the error for every partition in the request.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses.
+func (cl *Client) AlterPartitionAssignments(ctx context.Context, req AlterPartitionAssignmentsReq) (AlterPartitionAssignmentsResponses, error) {
+	if len(req) == 0 {
+		return make(AlterPartitionAssignmentsResponses), nil
+	}
+
+	kreq := kmsg.NewPtrAlterPartitionAssignmentsRequest()
+	for t, ps := range req {
+		rt := kmsg.NewAlterPartitionAssignmentsRequestTopic()
+		rt.Topic = t
+		for p, rs := range ps {
+			rp := kmsg.NewAlterPartitionAssignmentsRequestTopicPartition()
+			rp.Partition = p
+			rp.Replicas = rs
+			rt.Partitions = append(rt.Partitions, rp)
+		}
+		kreq.Topics = append(kreq.Topics, rt)
+	}
+
+	kresp, err := kreq.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+
+	a := make(AlterPartitionAssignmentsResponses)
+	if err = kerr.ErrorForCode(kresp.ErrorCode); err != nil {
+		for t, ps := range req {
+			a[t] = make(map[int32]AlterPartitionAssignmentsResponse)
+			for p := range ps {
+				a[t][p] = AlterPartitionAssignmentsResponse{
+					Topic:     t,
+					Partition: p,
+					Err:       err,
+				}
+			}
+		}
+		return a, nil
+	}
+	for _, t := range kresp.Topics {
+		ps := a[t.Topic]
+		if ps == nil {
+			ps = make(map[int32]AlterPartitionAssignmentsResponse)
+			a[t.Topic] = ps
+		}
+		for _, p := range t.Partitions {
+			ps[p.Partition] = AlterPartitionAssignmentsResponse{
+				Topic:     t.Topic,
+				Partition: p.Partition,
+				Err:       kerr.ErrorForCode(p.ErrorCode),
+			}
+		}
+	}
+	return a, nil
+}
+
+// CancelPartitionAssignments cancels any in progress reassignment for the
+// given partitions. This is a shortcut for AlterPartitionAssignments with every
+// partition's brokers set to null.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses.
+func (cl *Client) CancelPartitionAssignments(ctx context.Context, s TopicsSet) (AlterPartitionAssignmentsResponses, error) {
+	var req AlterPartitionAssignmentsReq
+	s.Each(func(t string, p int32) {
+		req.CancelAssign(t, p)
+	})
+	return cl.AlterPartitionAssignments(ctx, req)
+}
+
+// ListPartitionReassignmentsResponse contains a response for an individual
+// partition that is being reassigned.
+type ListPartitionReassignmentsResponse struct {
+	Topic            string  // Topic is the topic that was assigned.
+	Partition        int32   // Partition is the partition that was assigned.
+	Replicas         []int32 // Replicas contains the current replica assignment.
+	AddingReplicas   []int32 // AddingReplicas contains any reassignments currently being added.
+	RemovingReplicas []int32 // RemovingReplicas contains any reassignments currently being removed.
+}
+
+// ListPartitionReassignmentsResponses contains responses to all partitions in
+// a list reassignment request.
+type ListPartitionReassignmentsResponses map[string]map[int32]ListPartitionReassignmentsResponse
+
+// Sorted returns the responses sorted by topic and partition.
+func (rs ListPartitionReassignmentsResponses) Sorted() []ListPartitionReassignmentsResponse {
+	var all []ListPartitionReassignmentsResponse
+	rs.Each(func(r ListPartitionReassignmentsResponse) {
+		all = append(all, r)
+	})
+	sort.Slice(all, func(i, j int) bool {
+		l, r := all[i], all[j]
+		return l.Topic < r.Topic || l.Topic == r.Topic && l.Partition < r.Partition
+	})
+	return all
+}
+
+// Each calls fn for every response.
+func (rs ListPartitionReassignmentsResponses) Each(fn func(ListPartitionReassignmentsResponse)) {
+	for _, ps := range rs {
+		for _, r := range ps {
+			fn(r)
+		}
+	}
+}
+
+// ListPartitionReassignments lists the state of any active reassignments for
+// all requested partitions. If the set is empty, this lists all active
+// reassignments in the cluster. Partitions that are not being reassigned are
+// not included in the response.
+//
+// This returns an error if the request fails to be issued, or an *AuthError.
+func (cl *Client) ListPartitionReassignments(ctx context.Context, s TopicsSet) (ListPartitionReassignmentsResponses, error) {
+	kreq := kmsg.NewPtrListPartitionReassignmentsRequest()
+	for t, ps := range s {
+		rt := kmsg.NewListPartitionReassignmentsRequestTopic()
+		rt.Topic = t
+		for p := range ps {
+			rt.Partitions = append(rt.Partitions, p)
+		}
+		kreq.Topics = append(kreq.Topics, rt)
+	}
+
+	kresp, err := kreq.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err := maybeAuthErr(kresp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if err := kerr.ErrorForCode(kresp.ErrorCode); err != nil {
+		return nil, err
+	}
+
+	a := make(ListPartitionReassignmentsResponses)
+	for _, t := range kresp.Topics {
+		ps := a[t.Topic]
+		if ps == nil {
+			ps = make(map[int32]ListPartitionReassignmentsResponse)
+			a[t.Topic] = ps
+		}
+		for _, p := range t.Partitions {
+			ps[p.Partition] = ListPartitionReassignmentsResponse{
+				Topic:            t.Topic,
+				Partition:        p.Partition,
+				Replicas:         p.Replicas,
+				AddingReplicas:   p.AddingReplicas,
+				RemovingReplicas: p.RemovingReplicas,
+			}
+		}
+	}
+	return a, nil
+}
+
+// WaitPartitionReassignments polls ListPartitionReassignments every interval
+// until no partitions in the set are being reassigned, or until the context
+// is canceled. If the set is empty, this waits for all reassignments in the
+// cluster to complete.
+//
+// If fn is non-nil, it is called after every poll with the reassignments
+// still in progress, allowing you to report per-partition adding and removing
+// replicas. The final call has an empty map. Any error from listing
+// reassignments stops polling and is returned.
+func (cl *Client) WaitPartitionReassignments(
+	ctx context.Context,
+	s TopicsSet,
+	interval time.Duration,
+	fn func(ListPartitionReassignmentsResponses),
+) error {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		rs, err := cl.ListPartitionReassignments(ctx, s)
+		if err != nil {
+			return err
+		}
+		if fn != nil {
+			fn(rs)
+		}
+		var inProgress bool
+		rs.Each(func(ListPartitionReassignmentsResponse) { inProgress = true })
+		if !inProgress {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ElectionType is the type of leader election to perform.
+type ElectionType int8
+
+const (
+	// ElectPreferredReplica elects the preferred replica (the first replica
+	// in the replica list) for a partition, if it is in sync.
+	ElectPreferredReplica ElectionType = 0
+	// ElectLiveReplica elects the first live replica if there are no
+	// in-sync replicas (i.e., this is unclean leader election).
+	ElectLiveReplica ElectionType = 1
+)
+
+// String returns "PREFERRED", "UNCLEAN", or "UNKNOWN".
+func (e ElectionType) String() string {
+	switch e {
+	case ElectPreferredReplica:
+		return "PREFERRED"
+	case ElectLiveReplica:
+		return "UNCLEAN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ElectLeadersResult is the result for a single partition in an elect leaders
+// request.
+type ElectLeadersResult struct {
+	Topic     string       // Topic is the topic this result is for.
+	Partition int32        // Partition is the partition this result is for.
+	How       ElectionType // How is the type of election that was performed.
+	Err       error        // Err is non-nil if electing this partition's leader failed, such as the partition not existing or the preferred leader is not available and you used ElectPreferredReplica.
+}
+
+// ElectLeadersResults contains per-topic, per-partition results for an elect
+// leaders request.
+type ElectLeadersResults map[string]map[int32]ElectLeadersResult
+
+// Sorted returns the results sorted by topic and partition.
+func (rs ElectLeadersResults) Sorted() []ElectLeadersResult {
+	var all []ElectLeadersResult
+	rs.Each(func(r ElectLeadersResult) {
+		all = append(all, r)
+	})
+	sort.Slice(all, func(i, j int) bool {
+		l, r := all[i], all[j]
+		return l.Topic < r.Topic || l.Topic == r.Topic && l.Partition < r.Partition
+	})
+	return all
+}
+
+// Each calls fn for every result.
+func (rs ElectLeadersResults) Each(fn func(ElectLeadersResult)) {
+	for _, ps := range rs {
+		for _, r := range ps {
+			fn(r)
+		}
+	}
+}
+
+// EachError calls fn for every result that has a non-nil error. Note that
+// partitions that already have their preferred leader when using
+// ElectPreferredReplica have kerr.ElectionNotNeeded, which you may want to
+// ignore.
+func (rs ElectLeadersResults) EachError(fn func(ElectLeadersResult)) {
+	rs.Each(func(r ElectLeadersResult) {
+		if r.Err != nil {
+			fn(r)
+		}
+	})
+}
+
+// ElectLeaders elects leaders for partitions. This request was added in Kafka
+// 2.2 to replace the previously-ZooKeeper-only option of triggering leader
+// elections. See KIP-183 for more details.
+//
+// Kafka 2.4 introduced the ability to use unclean leader election. If you use
+// unclean leader election on a Kafka 2.2 or 2.3 cluster, the client will
+// instead fall back to preferred replica (clean) leader election. You can
+// check the result's How field to see.
+//
+// If s is nil, this will elect leaders for all partitions. If s is non-nil
+// but empty, this elects nothing and returns empty results without issuing a
+// request.
+//
+// This will return *AuthError if you do not have ALTER on CLUSTER for
+// kafka-cluster.
+func (cl *Client) ElectLeaders(ctx context.Context, how ElectionType, s TopicsSet) (ElectLeadersResults, error) {
+	if s != nil && len(s) == 0 {
+		return make(ElectLeadersResults), nil
+	}
+
+	resp, err := electLeadersReq(how, s).RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if resp.Version == 0 { // v0 does not support election type
+		how = ElectPreferredReplica
+	}
+	rs := make(ElectLeadersResults)
+	for _, t := range resp.Topics {
+		rt := make(map[int32]ElectLeadersResult)
+		rs[t.Topic] = rt
+		for _, p := range t.Partitions {
+			if err := maybeAuthErr(p.ErrorCode); err != nil {
+				return nil, err // v0 has no top-level err
+			}
+			rt[p.Partition] = ElectLeadersResult{
+				Topic:     t.Topic,
+				Partition: p.Partition,
+				How:       how,
+				Err:       kerr.ErrorForCode(p.ErrorCode),
+			}
+		}
+	}
+	return rs, nil
+}
+
+// electLeadersReq returns a request for the input set. Null topics elects
+// leaders for all partitions, so we only leave topics null if the set is nil.
+func electLeadersReq(how ElectionType, s TopicsSet) *kmsg.ElectLeadersRequest {
+	req := kmsg.NewPtrElectLeadersRequest()
+	req.ElectionType = int8(how)
+	for t, ps := range s {
+		rt := kmsg.NewElectLeadersRequestTopic()
+		rt.Topic = t
+		for p := range ps {
+			rt.Partitions = append(rt.Partitions, p)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+	return req
+}
+
+// NonPreferredLeaders returns all partitions in the topic details whose
+// current leader is not the preferred replica (the first replica in the
+// replica list). Partitions with load errors or without a leader are
+// included. The returned set can be used directly in ElectLeaders with
+// ElectPreferredReplica to move leadership back to preferred replicas.
+//
+// The returned set is never nil: if every leader is preferred, the set is
+// empty, and ElectLeaders elects nothing.
+func (ds TopicDetails) NonPreferredLeaders() TopicsSet {
+	s := make(TopicsSet)
+	for _, t := range ds {
+		for _, p := range t.Partitions {
+			if len(p.Replicas) == 0 || p.Leader != p.Replicas[0] {
+				s.Add(t.Topic, p.Partition)
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package kadm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestAlterPartitionAssignmentsEmpty(t *testing.T) {
+	// With nothing to assign, no request is issued: the client has no
+	// underlying kgo client, so issuing a request would panic.
+	cl := new(Client)
+	ctx := context.Background()
+
+	rs, err := cl.AlterPartitionAssignments(ctx, nil)
+	if err != nil || rs == nil || len(rs) != 0 {
+		t.Errorf("alter: got %v (%v), exp empty non-nil responses", rs, err)
+	}
+	rs, err = cl.CancelPartitionAssignments(ctx, make(TopicsSet))
+	if err != nil || rs == nil || len(rs) != 0 {
+		t.Errorf("cancel: got %v (%v), exp empty non-nil responses", rs, err)
+	}
+}
+
+func TestNonPreferredLeaders(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		ds   TopicDetails
+		exp  TopicsSet
+	}{
+		{
+			name: "all preferred",
+			ds: TopicDetails{
+				"t": {Topic: "t", Partitions: PartitionDetails{
+					0: {Topic: "t", Partition: 0, Leader: 1, Replicas: []int32{1, 2}},
+					1: {Topic: "t", Partition: 1, Leader: 2, Replicas: []int32{2, 1}},
+				}},
+			},
+			exp: TopicsSet{},
+		},
+		{
+			name: "not preferred, leaderless, and erroring",
+			ds: TopicDetails{
+				"t": {Topic: "t", Partitions: PartitionDetails{
+					0: {Topic: "t", Partition: 0, Leader: 2, Replicas: []int32{1, 2}},
+					1: {Topic: "t", Partition: 1, Leader: 2, Replicas: []int32{2, 1}},
+					2: {Topic: "t", Partition: 2, Leader: -1, Replicas: []int32{3, 1}},
+				}},
+				"u": {Topic: "u", Partitions: PartitionDetails{
+					0: {Topic: "u", Partition: 0, Leader: -1, Err: kerr.LeaderNotAvailable},
+				}},
+			},
+			exp: TopicsSet{
+				"t": {0: {}, 2: {}},
+				"u": {0: {}},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.ds.NonPreferredLeaders()
+			if got == nil {
+				t.Fatal("got nil set, exp non-nil")
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("got %v != exp %v", got, test.exp)
+			}
+		})
+	}
+}
+
+func TestElectLeadersEmpty(t *testing.T) {
+	// A non-nil empty set elects nothing and issues no request: the
+	// client has no underlying kgo client, so a request would panic.
+	rs, err := new(Client).ElectLeaders(context.Background(), ElectPreferredReplica, make(TopicsSet))
+	if err != nil || rs == nil || len(rs) != 0 {
+		t.Errorf("got %v (%v), exp empty non-nil results", rs, err)
+	}
+}
+
+func TestElectLeadersReq(t *testing.T) {
+	req := electLeadersReq(ElectLiveReplica, nil)
+	if req.Topics != nil {
+		t.Errorf("nil set: got topics %v, exp nil to elect all partitions", req.Topics)
+	}
+	if req.ElectionType != int8(ElectLiveReplica) {
+		t.Errorf("got election type %d != exp %d", req.ElectionType, ElectLiveReplica)
+	}
+
+	req = electLeadersReq(ElectPreferredReplica, TopicsSet{"t": {3: {}}})
+	if len(req.Topics) != 1 || req.Topics[0].Topic != "t" || !reflect.DeepEqual(req.Topics[0].Partitions, []int32{3}) {
+		t.Errorf("got topics %v, exp only t[3]", req.Topics)
+	}
+}
@@ -0,0 +1,175 @@
+package kadm
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// QuorumReplica is the state of a single replica in a KRaft quorum.
+type QuorumReplica struct {
+	ReplicaID int32 // ReplicaID is the node ID of this replica.
+
+	// LogEndOffset is the last known log end offset of this replica, as
+	// seen by the quorum leader, or -1 if unknown.
+	LogEndOffset int64
+
+	// LastFetch is the leader's wall clock time when this replica last
+	// fetched from the leader. This is unset for the leader itself, if
+	// the time is unknown, or if the broker does not support
+	// DescribeQuorum v1 (KIP-836).
+	LastFetch time.Time
+
+	// LastCaughtUp is the leader's wall clock time when this replica was
+	// last caught up to the high watermark. This is unset under the same
+	// conditions as LastFetch.
+	LastCaughtUp time.Time
+}
+
+// DescribedQuorum contains the state of a KRaft quorum.
+type DescribedQuorum struct {
+	Topic     string // Topic is the quorum topic, which is __cluster_metadata.
+	Partition int32  // Partition is the quorum partition, which is 0.
+
+	LeaderID      int32 // LeaderID is the node ID of the current quorum leader, or -1 if unknown.
+	LeaderEpoch   int32 // LeaderEpoch is the epoch of the current quorum leader.
+	HighWatermark int64 // HighWatermark is the high watermark of the quorum log.
+
+	Voters    []QuorumReplica // Voters are the current voters of the quorum, sorted by replica ID.
+	Observers []QuorumReplica // Observers are the current observers of the quorum, sorted by replica ID.
+}
+
+// Leader returns the quorum leader's replica state, and whether the leader
+// is one of the voters.
+func (d DescribedQuorum) Leader() (QuorumReplica, bool) {
+	for _, v := range d.Voters {
+		if v.ReplicaID == d.LeaderID {
+			return v, true
+		}
+	}
+	return QuorumReplica{}, false
+}
+
+// Lag returns how far each voter and observer is behind the quorum
+// leader's log end offset, keyed by replica ID. Replicas with an unknown log
+// end offset have a lag of -1. If the leader is unknown, this returns nil.
+func (d DescribedQuorum) Lag() map[int32]int64 {
+	leader, ok := d.Leader()
+	if !ok {
+		return nil
+	}
+	lag := make(map[int32]int64, len(d.Voters)+len(d.Observers))
+	for _, rs := range [][]QuorumReplica{d.Voters, d.Observers} {
+		for _, r := range rs {
+			var l int64 = -1 // unknown log end offset
+			if r.LogEndOffset >= 0 {
+				if l = leader.LogEndOffset - r.LogEndOffset; l < 0 {
+					l = 0
+				}
+			}
+			lag[r.ReplicaID] = l
+		}
+	}
+	return lag
+}
+
+func quorumReplicas(version int16, rs []kmsg.DescribeQuorumResponseTopicPartitionReplicaState) []QuorumReplica {
+	// Timestamps were added in v1 and are -1 if unknown.
+	ts := func(ms int64) time.Time {
+		if version < 1 || ms < 0 {
+			return time.Time{}
+		}
+		return time.Unix(0, ms*1e6)
+	}
+	qs := make([]QuorumReplica, 0, len(rs))
+	for _, r := range rs {
+		qs = append(qs, QuorumReplica{
+			ReplicaID:    r.ReplicaID,
+			LogEndOffset: r.LogEndOffset,
+			LastFetch:    ts(r.LastFetchTimestamp),
+			LastCaughtUp: ts(r.LastCaughtUpTimestamp),
+		})
+	}
+	sort.Slice(qs, func(i, j int) bool { return qs[i].ReplicaID < qs[j].ReplicaID })
+	return qs
+}
+
+// DescribeQuorum describes the KRaft metadata quorum: the leader, the voters,
+// and the observers, as well as how far along each replica is in the quorum
+// log. This requires a KRaft cluster (Kafka 2.8+); ZooKeeper based clusters
+// do not support this request.
+//
+// Lag per replica is available with DescribedQuorum.Lag.
+//
+// This returns an error if the request fails to be issued, or an *AuthError.
+func (cl *Client) DescribeQuorum(ctx context.Context) (DescribedQuorum, error) {
+	const (
+		topic     = "__cluster_metadata"
+		partition = 0
+	)
+
+	req := kmsg.NewPtrDescribeQuorumRequest()
+	rt := kmsg.NewDescribeQuorumRequestTopic()
+	rt.Topic = topic
+	rp := kmsg.NewDescribeQuorumRequestTopicPartition()
+	rp.Partition = partition
+	rt.Partitions = append(rt.Partitions, rp)
+	req.Topics = append(req.Topics, rt)
+
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return DescribedQuorum{}, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return DescribedQuorum{}, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return DescribedQuorum{}, err
+	}
+
+	for _, t := range resp.Topics {
+		if t.Topic != topic {
+			continue
+		}
+		for _, p := range t.Partitions {
+			if p.Partition != partition {
+				continue
+			}
+			if err := kerr.ErrorForCode(p.ErrorCode); err != nil {
+				return DescribedQuorum{}, err
+			}
+			return DescribedQuorum{
+				Topic:         t.Topic,
+				Partition:     p.Partition,
+				LeaderID:      p.LeaderID,
+				LeaderEpoch:   p.LeaderEpoch,
+				HighWatermark: p.HighWatermark,
+				Voters:        quorumReplicas(resp.Version, p.CurrentVoters),
+				Observers:     quorumReplicas(resp.Version, p.Observers),
+			}, nil
+		}
+	}
+	return DescribedQuorum{}, kerr.UnknownTopicOrPartition
+}
+
+// UnregisterBroker unregisters a broker from a KRaft cluster, which is
+// necessary to fully remove a broker that will not be coming back. This
+// requires a KRaft cluster (Kafka 3.0+ for the request to be supported by
+// the controller).
+//
+// This returns an error if the request fails to be issued, or an *AuthError.
+func (cl *Client) UnregisterBroker(ctx context.Context, broker int32) error {
+	req := kmsg.NewPtrUnregisterBrokerRequest()
+	req.BrokerID = broker
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return err
+	}
+	return kerr.ErrorForCode(resp.ErrorCode)
+}
@@ -0,0 +1,328 @@
+package kadm
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// QuotaEntityType is the type of a component of a quota entity.
+type QuotaEntityType string
+
+const (
+	// QuotaEntityUser is a quota entity component for a user principal.
+	QuotaEntityUser QuotaEntityType = "user"
+	// QuotaEntityClientID is a quota entity component for a client ID.
+	QuotaEntityClientID QuotaEntityType = "client-id"
+	// QuotaEntityIP is a quota entity component for an IP address, used
+	// for connection creation rate quotas.
+	QuotaEntityIP QuotaEntityType = "ip"
+)
+
+// QuotaKey is a quota to set on or describe for an entity.
+type QuotaKey string
+
+const (
+	// QuotaProducerByteRate is the upper bound of bytes per second a
+	// producer can publish.
+	QuotaProducerByteRate QuotaKey = "producer_byte_rate"
+	// QuotaConsumerByteRate is the upper bound of bytes per second a
+	// consumer can fetch.
+	QuotaConsumerByteRate QuotaKey = "consumer_byte_rate"
+	// QuotaRequestPercentage is the percentage of each quota window that
+	// request handler and network threads can be used for.
+	QuotaRequestPercentage QuotaKey = "request_percentage"
+	// QuotaControllerMutationRate is the rate at which topics and
+	// partitions can be created or deleted.
+	QuotaControllerMutationRate QuotaKey = "controller_mutation_rate"
+	// QuotaConnectionCreationRate is the upper bound of connections per
+	// second that can be created by an IP entity.
+	QuotaConnectionCreationRate QuotaKey = "connection_creation_rate"
+)
+
+// QuotaEntityComponent is a single component of a quota entity: a user, a
+// client ID, or an IP, or a default of any of those.
+type QuotaEntityComponent struct {
+	Type QuotaEntityType // Type is the type of this component.
+	Name *string         // Name is the name of this component, or nil for the default entity.
+}
+
+// String returns the component as "type=name", or "type=<default>".
+func (c QuotaEntityComponent) String() string {
+	name := "<default>"
+	if c.Name != nil {
+		name = *c.Name
+	}
+	return string(c.Type) + "=" + name
+}
+
+// QuotaUser returns a user entity component.
+func QuotaUser(user string) QuotaEntityComponent {
+	return QuotaEntityComponent{QuotaEntityUser, StringPtr(user)}
+}
+
+// QuotaDefaultUser returns a default user entity component.
+func QuotaDefaultUser() QuotaEntityComponent {
+	return QuotaEntityComponent{QuotaEntityUser, nil}
+}
+
+// QuotaClientID returns a client ID entity component.
+func QuotaClientID(id string) QuotaEntityComponent {
+	return QuotaEntityComponent{QuotaEntityClientID, StringPtr(id)}
+}
+
+// QuotaDefaultClientID returns a default client ID entity component.
+func QuotaDefaultClientID() QuotaEntityComponent {
+	return QuotaEntityComponent{QuotaEntityClientID, nil}
+}
+
+// QuotaIP returns an IP entity component.
+func QuotaIP(ip string) QuotaEntityComponent {
+	return QuotaEntityComponent{QuotaEntityIP, StringPtr(ip)}
+}
+
+// QuotaDefaultIP returns a default IP entity component.
+func QuotaDefaultIP() QuotaEntityComponent {
+	return QuotaEntityComponent{QuotaEntityIP, nil}
+}
+
+// QuotaEntity is an entity that quotas apply to, such as a user, a client ID,
+// or a user and client ID pair.
+type QuotaEntity []QuotaEntityComponent
+
+// String returns the entity's components joined by commas, e.g.
+// "user=foo,client-id=<default>".
+func (e QuotaEntity) String() string {
+	ss := make([]string, 0, len(e))
+	for _, c := range e {
+		ss = append(ss, c.String())
+	}
+	return strings.Join(ss, ",")
+}
+
+// QuotaValue is a quota key and its value.
+type QuotaValue struct {
+	Key   QuotaKey // Key is the quota key.
+	Value float64  // Value is the quota value.
+}
+
+// QuotaMatchType is how a describe component matches entity names.
+type QuotaMatchType int8
+
+const (
+	// QuotaMatchExact matches the component's name exactly.
+	QuotaMatchExact QuotaMatchType = 0
+	// QuotaMatchDefault matches the default entity for the component's
+	// type.
+	QuotaMatchDefault QuotaMatchType = 1
+	// QuotaMatchAny matches any entity for the component's type,
+	// including the default.
+	QuotaMatchAny QuotaMatchType = 2
+)
+
+// DescribeClientQuotaComponent is a filter component to use when describing
+// client quotas.
+type DescribeClientQuotaComponent struct {
+	Type      QuotaEntityType // Type is the entity type to match.
+	MatchName *string         // MatchName is the name to match, for QuotaMatchExact.
+	MatchType QuotaMatchType  // MatchType is how to match the name.
+}
+
+// MatchQuotaExact returns a describe component that matches the entity type
+// and name exactly.
+func MatchQuotaExact(typ QuotaEntityType, name string) DescribeClientQuotaComponent {
+	return DescribeClientQuotaComponent{typ, StringPtr(name), QuotaMatchExact}
+}
+
+// MatchQuotaDefault returns a describe component that matches the default
+// entity for the type.
+func MatchQuotaDefault(typ QuotaEntityType) DescribeClientQuotaComponent {
+	return DescribeClientQuotaComponent{typ, nil, QuotaMatchDefault}
+}
+
+// MatchQuotaAny returns a describe component that matches any entity for the
+// type.
+func MatchQuotaAny(typ QuotaEntityType) DescribeClientQuotaComponent {
+	return DescribeClientQuotaComponent{typ, nil, QuotaMatchAny}
+}
+
+// DescribedClientQuota contains the quotas for a single entity.
+type DescribedClientQuota struct {
+	Entity QuotaEntity  // Entity is the entity these quotas apply to.
+	Values []QuotaValue // Values are the quotas set on this entity.
+}
+
+// DescribedClientQuotas contains described quotas, one per entity.
+type DescribedClientQuotas []DescribedClientQuota
+
+// Sorted returns the described quotas sorted by entity string.
+func (qs DescribedClientQuotas) Sorted() DescribedClientQuotas {
+	s := append(DescribedClientQuotas(nil), qs...)
+	sort.SliceStable(s, func(i, j int) bool { return s[i].Entity.String() < s[j].Entity.String() })
+	return s
+}
+
+// DescribeClientQuotas describes client quotas for all entities matching the
+// given components. If strict is true, only entities with exactly the given
+// component types are returned; otherwise, entities that contain the
+// components and potentially more component types are returned as well.
+// Passing no components with strict false describes all quotas.
+//
+// This returns an error if the request fails to be issued, or an *AuthError.
+func (cl *Client) DescribeClientQuotas(ctx context.Context, strict bool, components ...DescribeClientQuotaComponent) (DescribedClientQuotas, error) {
+	resp, err := describeClientQuotasReq(strict, components).RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+
+	var qs DescribedClientQuotas
+	for _, entry := range resp.Entries {
+		var q DescribedClientQuota
+		for _, e := range entry.Entity {
+			q.Entity = append(q.Entity, QuotaEntityComponent{
+				Type: QuotaEntityType(e.Type),
+				Name: e.Name,
+			})
+		}
+		for _, v := range entry.Values {
+			q.Values = append(q.Values, QuotaValue{
+				Key:   QuotaKey(v.Key),
+				Value: v.Value,
+			})
+		}
+		qs = append(qs, q)
+	}
+	return qs, nil
+}
+
+func describeClientQuotasReq(strict bool, components []DescribeClientQuotaComponent) *kmsg.DescribeClientQuotasRequest {
+	req := kmsg.NewPtrDescribeClientQuotasRequest()
+	req.Strict = strict
+	for _, c := range components {
+		rc := kmsg.NewDescribeClientQuotasRequestComponent()
+		rc.EntityType = string(c.Type)
+		rc.MatchType = kmsg.QuotasMatchType(c.MatchType)
+		rc.Match = c.MatchName
+		req.Components = append(req.Components, rc)
+	}
+	return req
+}
+
+// AlterClientQuotaOp sets or removes a quota key for an entity.
+type AlterClientQuotaOp struct {
+	Key    QuotaKey // Key is the quota key to alter.
+	Value  float64  // Value is the value to set, if not removing.
+	Remove bool     // Remove is whether to remove the quota key.
+}
+
+// AlterClientQuotaEntry is an entity and the quota operations to perform on
+// it.
+type AlterClientQuotaEntry struct {
+	Entity QuotaEntity          // Entity is the entity to alter.
+	Ops    []AlterClientQuotaOp // Ops are the alterations to perform.
+}
+
+// AlteredClientQuota is the result of altering quotas for a single entity.
+type AlteredClientQuota struct {
+	Entity QuotaEntity // Entity is the entity that was altered.
+	Err    error       // Err is non-nil if the alteration failed.
+}
+
+// AlteredClientQuotas contains results for altered quotas, one per entity.
+type AlteredClientQuotas []AlteredClientQuota
+
+// EachError calls fn for every result that has a non-nil error.
+func (qs AlteredClientQuotas) EachError(fn func(AlteredClientQuota)) {
+	for _, q := range qs {
+		if q.Err != nil {
+			fn(q)
+		}
+	}
+}
+
+// Error iterates over all results and returns the first error encountered, if
+// any.
+func (qs AlteredClientQuotas) Error() error {
+	for _, q := range qs {
+		if q.Err != nil {
+			return q.Err
+		}
+	}
+	return nil
+}
+
+// AlterClientQuotas alters quotas for the given entities.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses. This only returns an
+// error if the request fails to be issued. You may consider checking
+// ValidateAlterClientQuotas before using this method.
+func (cl *Client) AlterClientQuotas(ctx context.Context, entries []AlterClientQuotaEntry) (AlteredClientQuotas, error) {
+	return cl.alterClientQuotas(ctx, false, entries)
+}
+
+// ValidateAlterClientQuotas validates an alter of quotas for the given
+// entities.
+//
+// This returns exactly what AlterClientQuotas returns, but does not actually
+// alter quotas.
+func (cl *Client) ValidateAlterClientQuotas(ctx context.Context, entries []AlterClientQuotaEntry) (AlteredClientQuotas, error) {
+	return cl.alterClientQuotas(ctx, true, entries)
+}
+
+func (cl *Client) alterClientQuotas(ctx context.Context, dry bool, entries []AlterClientQuotaEntry) (AlteredClientQuotas, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	resp, err := alterClientQuotasReq(dry, entries).RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+
+	var qs AlteredClientQuotas
+	for _, entry := range resp.Entries {
+		var q AlteredClientQuota
+		for _, e := range entry.Entity {
+			q.Entity = append(q.Entity, QuotaEntityComponent{
+				Type: QuotaEntityType(e.Type),
+				Name: e.Name,
+			})
+		}
+		q.Err = kerr.ErrorForCode(entry.ErrorCode)
+		qs = append(qs, q)
+	}
+	return qs, nil
+}
+
+func alterClientQuotasReq(dry bool, entries []AlterClientQuotaEntry) *kmsg.AlterClientQuotasRequest {
+	req := kmsg.NewPtrAlterClientQuotasRequest()
+	req.ValidateOnly = dry
+	for _, entry := range entries {
+		re := kmsg.NewAlterClientQuotasRequestEntry()
+		for _, c := range entry.Entity {
+			rc := kmsg.NewAlterClientQuotasRequestEntryEntity()
+			rc.Type = string(c.Type)
+			rc.Name = c.Name
+			re.Entity = append(re.Entity, rc)
+		}
+		for _, op := range entry.Ops {
+			ro := kmsg.NewAlterClientQuotasRequestEntryOp()
+			ro.Key = string(op.Key)
+			ro.Value = op.Value
+			ro.Remove = op.Remove
+			re.Ops = append(re.Ops, ro)
+		}
+		req.Entries = append(req.Entries, re)
+	}
+	return req
+}
@@ -0,0 +1,75 @@
+package kadm
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestDescribeClientQuotasReq(t *testing.T) {
+	req := describeClientQuotasReq(true, []DescribeClientQuotaComponent{
+		MatchQuotaExact(QuotaEntityUser, "u"),
+		MatchQuotaDefault(QuotaEntityClientID),
+		MatchQuotaAny(QuotaEntityIP),
+	})
+	if !req.Strict {
+		t.Error("got non-strict request, exp strict")
+	}
+
+	type component struct {
+		typ   string
+		match string
+		mt    kmsg.QuotasMatchType
+	}
+	exp := []component{
+		{"user", "u", kmsg.QuotasMatchTypeExact},
+		{"client-id", "", kmsg.QuotasMatchTypeDefault},
+		{"ip", "", kmsg.QuotasMatchTypeAny},
+	}
+	if len(req.Components) != len(exp) {
+		t.Fatalf("got %d components != exp %d", len(req.Components), len(exp))
+	}
+	for i, c := range req.Components {
+		got := component{c.EntityType, "", c.MatchType}
+		if c.Match != nil {
+			got.match = *c.Match
+		}
+		if got != exp[i] {
+			t.Errorf("component %d: got %v != exp %v", i, got, exp[i])
+		}
+		if exp[i].match == "" && c.Match != nil {
+			t.Errorf("component %d: got non-nil match, exp nil", i)
+		}
+	}
+}
+
+func TestAlterClientQuotasReq(t *testing.T) {
+	entity := QuotaEntity{QuotaUser("u"), QuotaDefaultClientID()}
+	req := alterClientQuotasReq(true, []AlterClientQuotaEntry{{
+		Entity: entity,
+		Ops: []AlterClientQuotaOp{
+			{Key: QuotaProducerByteRate, Value: 1024},
+			{Key: QuotaConsumerByteRate, Remove: true},
+		},
+	}})
+	if !req.ValidateOnly {
+		t.Error("got ValidateOnly false, exp true")
+	}
+	if len(req.Entries) != 1 {
+		t.Fatalf("got %d entries != exp 1", len(req.Entries))
+	}
+	e := req.Entries[0]
+	if len(e.Entity) != 2 ||
+		e.Entity[0].Type != "user" || e.Entity[0].Name == nil || *e.Entity[0].Name != "u" ||
+		e.Entity[1].Type != "client-id" || e.Entity[1].Name != nil {
+		t.Errorf("got unexpected entity %+v", e.Entity)
+	}
+	if len(e.Ops) != 2 ||
+		e.Ops[0].Key != "producer_byte_rate" || e.Ops[0].Value != 1024 || e.Ops[0].Remove ||
+		e.Ops[1].Key != "consumer_byte_rate" || !e.Ops[1].Remove {
+		t.Errorf("got unexpected ops %+v", e.Ops)
+	}
+	if s, exp := entity.String(), "user=u,client-id=<default>"; s != exp {
+		t.Errorf("got entity string %q != exp %q", s, exp)
+	}
+}
@@ -0,0 +1,391 @@
+package kadm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PlannedReassignment is a new replica assignment for a single partition.
+type PlannedReassignment struct {
+	Topic     string  `json:"topic"`     // Topic is the topic of the partition being reassigned.
+	Partition int32   `json:"partition"` // Partition is the partition being reassigned.
+	Replicas  []int32 `json:"replicas"`  // Replicas is the new replica assignment, with the preferred leader first.
+
+	// Current is the replica assignment at the time the plan was created.
+	// This is not serialized, so it is empty in a parsed plan until
+	// filled with ReassignmentPlan.WithCurrent. See Rollback to create a
+	// plan that reverts to the current assignments.
+	Current []int32 `json:"-"`
+}
+
+// Moves returns the number of replicas that must be moved to a new broker
+// to apply this reassignment. Reassignments that only reorder replicas
+// (changing the preferred leader) return 0. If Current is empty, every
+// replica is counted.
+func (r PlannedReassignment) Moves() int {
+	var n int
+	for _, b := range r.Replicas {
+		if !hasInt32(r.Current, b) {
+			n++
+		}
+	}
+	return n
+}
+
+// ReassignmentPlan is a set of planned partition reassignments. The plan
+// marshals to and unmarshals from the JSON format used by Kafka's
+// kafka-reassign-partitions.sh tool, and can be issued directly with
+// AlterPartitionAssignments via AlterReq.
+type ReassignmentPlan struct {
+	Version    int                   `json:"version"`    // Version is the plan format version; always 1.
+	Partitions []PlannedReassignment `json:"partitions"` // Partitions contains all planned reassignments, sorted by topic and partition.
+}
+
+// ParseReassignmentPlan parses a plan in the JSON format used by
+// kafka-reassign-partitions.sh. Any "log_dirs" in the input are ignored.
+//
+// The JSON format does not contain current assignments, so the parsed plan
+// has no Current replicas. Use WithCurrent to fill them in before using Moves
+// or Rollback.
+func ParseReassignmentPlan(b []byte) (ReassignmentPlan, error) {
+	var p ReassignmentPlan
+	if err := json.Unmarshal(b, &p); err != nil {
+		return p, err
+	}
+	if p.Version != 1 {
+		return p, fmt.Errorf("unknown reassignment plan version %d", p.Version)
+	}
+	return p, nil
+}
+
+// JSON returns the plan in the JSON format used by
+// kafka-reassign-partitions.sh.
+func (p ReassignmentPlan) JSON() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = 1
+	}
+	if p.Partitions == nil {
+		p.Partitions = []PlannedReassignment{}
+	}
+	return json.Marshal(p)
+}
+
+// Moves returns the total number of replicas that must be moved to a new
+// broker to apply this plan.
+func (p ReassignmentPlan) Moves() int {
+	var n int
+	for _, r := range p.Partitions {
+		n += r.Moves()
+	}
+	return n
+}
+
+// WithCurrent returns a copy of the plan with every partition's Current
+// replicas set from the metadata. Partitions that are not in the metadata are
+// left with no Current replicas.
+func (p ReassignmentPlan) WithCurrent(m Metadata) ReassignmentPlan {
+	wc := ReassignmentPlan{Version: p.Version}
+	for _, r := range p.Partitions {
+		r.Current = nil
+		if pd, ok := m.Topics[r.Topic].Partitions[r.Partition]; ok {
+			r.Current = append([]int32(nil), pd.Replicas...)
+		}
+		wc.Partitions = append(wc.Partitions, r)
+	}
+	return wc
+}
+
+// Rollback returns a plan that reassigns every partition in this plan back
+// to its assignment at the time this plan was created. This returns an error
+// if any partition has no Current replicas, which is the case for a parsed
+// plan that has not been filled in with WithCurrent.
+func (p ReassignmentPlan) Rollback() (ReassignmentPlan, error) {
+	rb := ReassignmentPlan{Version: 1}
+	for _, r := range p.Partitions {
+		if len(r.Current) == 0 {
+			return ReassignmentPlan{}, fmt.Errorf("%s[%d]: unable to roll back without current replicas", r.Topic, r.Partition)
+		}
+		rb.Partitions = append(rb.Partitions, PlannedReassignment{
+			Topic:     r.Topic,
+			Partition: r.Partition,
+			Replicas:  r.Current,
+			Current:   r.Replicas,
+		})
+	}
+	return rb, nil
+}
+
+// AlterReq returns the plan as input for AlterPartitionAssignments.
+func (p ReassignmentPlan) AlterReq() AlterPartitionAssignmentsReq {
+	var req AlterPartitionAssignmentsReq
+	for _, r := range p.Partitions {
+		req.Assign(r.Topic, r.Partition, r.Replicas)
+	}
+	return req
+}
+
+// PlanRemoveBrokers plans a reassignment that moves every replica off of the
+// given brokers, for example to decommission them. Only replicas on the
+// removed brokers are moved, and each is replaced in the same position in the
+// replica list, so a removed preferred leader is replaced with a new preferred
+// leader.
+//
+// Replacements prefer brokers in racks the partition does not already have a
+// replica in (if brokers have racks), then brokers with the fewest leaders
+// (for preferred leader replacements), then brokers with the fewest replicas.
+//
+// Brokers that are in replica lists but not in the metadata's brokers are
+// usually offline. Their replicas are left in place (unless the broker is
+// being removed), and they are never chosen as replacements.
+//
+// This returns an error if a replica on a removed broker cannot be replaced
+// because the partition already has a replica on every remaining broker.
+func PlanRemoveBrokers(m Metadata, remove ...int32) (ReassignmentPlan, error) {
+	return plan(m, remove, false)
+}
+
+// PlanBalance plans a reassignment that evens out replica and preferred
+// leader counts across all brokers in the metadata while moving as few
+// replicas as possible. Leader counts are evened out by reordering replicas
+// within a partition, which does not move any data.
+//
+// Replicas are only moved to brokers that do not reduce the partition's rack
+// diversity. Brokers that are not in the metadata's brokers (usually offline
+// brokers) are not balanced: their replicas are left in place, they are never
+// moved to, and leadership is never moved to them.
+func PlanBalance(m Metadata) (ReassignmentPlan, error) {
+	return plan(m, nil, true)
+}
+
+type planner struct {
+	brokers   []int32 // online brokers that can be assigned replicas, sorted
+	racks     map[int32]string
+	rackAware bool
+	replicas  map[int32]int
+	leaders   map[int32]int
+}
+
+// eligible returns whether b is a broker we can assign replicas to. Brokers
+// that are being removed or that are not in the metadata are not eligible.
+func (p *planner) eligible(b int32) bool {
+	_, ok := p.replicas[b]
+	return ok
+}
+
+// usedRacks returns the racks of all eligible replicas except the one at
+// skip. We do not know the racks of brokers that are not in the metadata.
+func (p *planner) usedRacks(replicas []int32, skip int) map[string]bool {
+	used := make(map[string]bool)
+	for i, b := range replicas {
+		if i != skip && p.eligible(b) {
+			used[p.racks[b]] = true
+		}
+	}
+	return used
+}
+
+// better returns whether broker l is a better candidate than r for a replica
+// at the given position.
+func (p *planner) better(l, r int32, used map[string]bool, leader bool) bool {
+	if p.rackAware {
+		if lu, ru := used[p.racks[l]], used[p.racks[r]]; lu != ru {
+			return !lu
+		}
+	}
+	if leader && p.leaders[l] != p.leaders[r] {
+		return p.leaders[l] < p.leaders[r]
+	}
+	if p.replicas[l] != p.replicas[r] {
+		return p.replicas[l] < p.replicas[r]
+	}
+	return l < r
+}
+
+func (p *planner) assign(replicas []int32, i int, b int32) {
+	old := replicas[i]
+	if p.eligible(old) {
+		p.replicas[old]--
+		if i == 0 {
+			p.leaders[old]--
+		}
+	}
+	replicas[i] = b
+	p.replicas[b]++
+	if i == 0 {
+		p.leaders[b]++
+	}
+}
+
+// replace replaces the replica at position i with the best eligible broker.
+func (p *planner) replace(replicas []int32, i int) bool {
+	used := p.usedRacks(replicas, i)
+	best := int32(-1)
+	for _, b := range p.brokers {
+		if hasInt32(replicas, b) {
+			continue
+		}
+		if best == -1 || p.better(b, best, used, i == 0) {
+			best = b
+		}
+	}
+	if best == -1 {
+		return false
+	}
+	p.assign(replicas, i, best)
+	return true
+}
+
+// rebalance moves the replica at position i to a less loaded broker if doing
+// so strictly improves balance and does not reduce rack diversity.
+func (p *planner) rebalance(replicas []int32, i int) bool {
+	old := replicas[i]
+	if !p.eligible(old) {
+		return false
+	}
+	used := p.usedRacks(replicas, i)
+	best := int32(-1)
+	for _, b := range p.brokers {
+		if hasInt32(replicas, b) || p.replicas[old]-p.replicas[b] <= 1 {
+			continue
+		}
+		if p.rackAware && used[p.racks[b]] && p.racks[b] != p.racks[old] {
+			continue
+		}
+		if best == -1 || p.better(b, best, used, i == 0) {
+			best = b
+		}
+	}
+	if best == -1 {
+		return false
+	}
+	p.assign(replicas, i, best)
+	return true
+}
+
+func plan(m Metadata, remove []int32, balance bool) (ReassignmentPlan, error) {
+	p := &planner{
+		racks:    make(map[int32]string),
+		replicas: make(map[int32]int),
+		leaders:  make(map[int32]int),
+	}
+
+	for _, b := range m.Brokers {
+		if hasInt32(remove, b.NodeID) {
+			continue
+		}
+		p.brokers = append(p.brokers, b.NodeID)
+		p.replicas[b.NodeID] = 0
+		if b.Rack != nil {
+			p.racks[b.NodeID] = *b.Rack
+			p.rackAware = true
+		}
+	}
+	p.brokers = int32s(p.brokers)
+
+	type partition struct {
+		topic     string
+		partition int32
+		current   []int32
+		replicas  []int32
+	}
+	var ps []*partition
+	for _, t := range m.Topics.Sorted() {
+		for _, pd := range t.Partitions.Sorted() {
+			if len(pd.Replicas) == 0 {
+				continue
+			}
+			part := &partition{
+				topic:     pd.Topic,
+				partition: pd.Partition,
+				current:   pd.Replicas,
+				replicas:  append([]int32(nil), pd.Replicas...),
+			}
+			for i, b := range part.replicas {
+				if p.eligible(b) {
+					p.replicas[b]++
+					if i == 0 {
+						p.leaders[b]++
+					}
+				}
+			}
+			ps = append(ps, part)
+		}
+	}
+
+	// We only replace replicas on brokers being removed, whether or not
+	// they are online; replicas on other offline brokers are left alone.
+	for _, part := range ps {
+		for i, b := range part.replicas {
+			if hasInt32(remove, b) && !p.replace(part.replicas, i) {
+				return ReassignmentPlan{}, fmt.Errorf("%s[%d]: unable to find a replacement for broker %d",
+					part.topic, part.partition, b)
+			}
+		}
+	}
+
+	if balance {
+		// Each move strictly decreases the spread of replica counts, and
+		// each swap strictly decreases the spread of leader counts, so
+		// both loops terminate.
+		for moved := true; moved; {
+			moved = false
+			for _, part := range ps {
+				for i := range part.replicas {
+					moved = p.rebalance(part.replicas, i) || moved
+				}
+			}
+		}
+		for swapped := true; swapped; {
+			swapped = false
+			for _, part := range ps {
+				rs := part.replicas
+				if !p.eligible(rs[0]) {
+					continue
+				}
+				for j := 1; j < len(rs); j++ {
+					if p.eligible(rs[j]) && p.leaders[rs[0]]-p.leaders[rs[j]] > 1 {
+						p.leaders[rs[0]]--
+						p.leaders[rs[j]]++
+						rs[0], rs[j] = rs[j], rs[0]
+						swapped = true
+					}
+				}
+			}
+		}
+	}
+
+	rp := ReassignmentPlan{Version: 1}
+	for _, part := range ps {
+		if int32sEqual(part.current, part.replicas) {
+			continue
+		}
+		rp.Partitions = append(rp.Partitions, PlannedReassignment{
+			Topic:     part.topic,
+			Partition: part.partition,
+			Replicas:  part.replicas,
+			Current:   part.current,
+		})
+	}
+	return rp, nil
+}
+
+func hasInt32(is []int32, i int32) bool {
+	for _, e := range is {
+		if e == i {
+			return true
+		}
+	}
+	return false
+}
+
+func int32sEqual(l, r []int32) bool {
+	if len(l) != len(r) {
+		return false
+	}
+	for i := range l {
+		if l[i] != r[i] {
+			return false
+		}
+	}
+	return true
+}
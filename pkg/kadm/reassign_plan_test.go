@@ -0,0 +1,173 @@
+package kadm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func planMeta(brokers []BrokerDetail, replicas ...[]int32) Metadata {
+	m := Metadata{
+		Brokers: brokers,
+		Topics: TopicDetails{"t": {
+			Topic:      "t",
+			Partitions: make(PartitionDetails),
+		}},
+	}
+	for i, rs := range replicas {
+		m.Topics["t"].Partitions[int32(i)] = PartitionDetail{
+			Topic:     "t",
+			Partition: int32(i),
+			Leader:    rs[0],
+			Replicas:  rs,
+		}
+	}
+	return m
+}
+
+func planBrokers(racks ...string) []BrokerDetail {
+	var bs []BrokerDetail
+	for i, rack := range racks {
+		b := BrokerDetail{NodeID: int32(i + 1)}
+		if rack != "" {
+			rack := rack
+			b.Rack = &rack
+		}
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+func TestPlan(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		m       Metadata
+		remove  []int32 // if nil, balance
+		exp     map[int32][]int32
+		expMove int
+		expErr  bool
+	}{
+		{
+			name: "balanced",
+			m:    planMeta(planBrokers("", "", ""), []int32{1, 2}, []int32{2, 3}, []int32{3, 1}),
+		},
+		{
+			name:    "balance replicas",
+			m:       planMeta(planBrokers("", "", ""), []int32{1}, []int32{1}, []int32{1}),
+			exp:     map[int32][]int32{0: {2}, 1: {3}},
+			expMove: 2,
+		},
+		{
+			name: "balance leaders without moving",
+			m:    planMeta(planBrokers("", ""), []int32{1, 2}, []int32{1, 2}),
+			exp:  map[int32][]int32{0: {2, 1}},
+		},
+		{
+			name: "balance leaves offline brokers alone",
+			m:    planMeta(planBrokers("", ""), []int32{3, 1}, []int32{3, 2}, []int32{1, 2}),
+		},
+		{
+			name:    "remove rack aware",
+			m:       planMeta(planBrokers("a", "a", "b", "b"), []int32{1, 3}),
+			remove:  []int32{3},
+			exp:     map[int32][]int32{0: {1, 4}},
+			expMove: 1,
+		},
+		{
+			name:    "remove offline broker",
+			m:       planMeta(planBrokers("", ""), []int32{3, 1}),
+			remove:  []int32{3},
+			exp:     map[int32][]int32{0: {2, 1}},
+			expMove: 1,
+		},
+		{
+			name:   "remove without replacement",
+			m:      planMeta(planBrokers("", ""), []int32{1, 2}),
+			remove: []int32{2},
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var p ReassignmentPlan
+			var err error
+			if test.remove == nil {
+				p, err = PlanBalance(test.m)
+			} else {
+				p, err = PlanRemoveBrokers(test.m, test.remove...)
+			}
+			if gotErr := err != nil; gotErr != test.expErr {
+				t.Fatalf("got err %v, exp err? %v", err, test.expErr)
+			}
+			if test.expErr {
+				return
+			}
+
+			got := make(map[int32][]int32)
+			for _, r := range p.Partitions {
+				got[r.Partition] = r.Replicas
+				if exp := test.m.Topics["t"].Partitions[r.Partition].Replicas; !reflect.DeepEqual(r.Current, exp) {
+					t.Errorf("p%d: got current %v != exp %v", r.Partition, r.Current, exp)
+				}
+			}
+			if test.exp == nil {
+				test.exp = make(map[int32][]int32)
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("got plan %v != exp %v", got, test.exp)
+			}
+			if moves := p.Moves(); moves != test.expMove {
+				t.Errorf("got %d moves != exp %d", moves, test.expMove)
+			}
+		})
+	}
+}
+
+func TestPlanJSONRollback(t *testing.T) {
+	m := planMeta(planBrokers("", "", ""), []int32{1, 2}, []int32{1, 2}, []int32{1, 2})
+	p, err := PlanBalance(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Partitions) == 0 {
+		t.Fatal("expected a non-empty plan")
+	}
+
+	rb, err := p.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range rb.Partitions {
+		if !reflect.DeepEqual(r.Replicas, p.Partitions[i].Current) || !reflect.DeepEqual(r.Current, p.Partitions[i].Replicas) {
+			t.Errorf("rollback %d: got %v (current %v), exp the reverse of %v", i, r.Replicas, r.Current, p.Partitions[i])
+		}
+	}
+	if rb.Moves() != p.Moves() {
+		t.Errorf("got rollback moves %d != exp %d", rb.Moves(), p.Moves())
+	}
+
+	b, err := p.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseReassignmentPlan(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parsed.Rollback(); err == nil {
+		t.Error("expected rollback of a parsed plan without current replicas to fail")
+	}
+	var replicas int
+	for _, r := range parsed.Partitions {
+		replicas += len(r.Replicas)
+	}
+	if moves := parsed.Moves(); moves != replicas {
+		t.Errorf("got parsed moves %d != exp every replica %d", moves, replicas)
+	}
+
+	parsed = parsed.WithCurrent(m)
+	if !reflect.DeepEqual(parsed, p) {
+		t.Errorf("got parsed plan with current %v != exp %v", parsed, p)
+	}
+	if _, err := parsed.Rollback(); err != nil {
+		t.Errorf("unexpected rollback err with current replicas: %v", err)
+	}
+}
@@ -0,0 +1,416 @@
+package kadm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// TopicConfigDiff is a single config change to reconcile a topic's configs.
+type TopicConfigDiff struct {
+	Key string        // Key is the config name.
+	Op  IncrementalOp // Op is either SetConfig or DeleteConfig.
+
+	Current       *string           // Current is the current config value, if any (sensitive values are nil).
+	CurrentSource kmsg.ConfigSource // CurrentSource is where the current value is defined from.
+	Value         *string           // Value is the value to set, for SetConfig.
+}
+
+// TopicDiff is the difference between a topic's desired state and the live
+// state of the topic in the cluster.
+type TopicDiff struct {
+	Topic string    // Topic is the topic this diff is for.
+	Spec  TopicSpec // Spec is the desired state this diff was computed from.
+
+	// Create is true if the topic does not exist and must be created. If
+	// true, the topic is created with the spec's partitions, replication
+	// factor, and configs, and there are no other changes.
+	Create bool
+
+	CurrentPartitions int32 // CurrentPartitions is the current number of partitions, or 0 if creating.
+	AddPartitions     int32 // AddPartitions is the number of partitions to add.
+
+	// CurrentReplicationFactor is the current replication factor, or 0 if
+	// creating. Replication factor differences cannot be reconciled by
+	// creating topics or altering configs; instead, see
+	// AlterPartitionAssignments. Use ReplicationFactorDiffers to check if
+	// the replication factor is not as desired.
+	CurrentReplicationFactor int16
+
+	Configs []TopicConfigDiff // Configs are the config changes, sorted by key.
+
+	Err error // Err is non-nil if the topic could not be diffed or cannot be reconciled.
+}
+
+// ReplicationFactorDiffers returns whether the topic exists and its current
+// replication factor is not the desired replication factor.
+func (d TopicDiff) ReplicationFactorDiffers() bool {
+	return !d.Create && d.Spec.ReplicationFactor > 0 && d.Spec.ReplicationFactor != d.CurrentReplicationFactor
+}
+
+// HasChanges returns whether there is anything to apply for this topic.
+func (d TopicDiff) HasChanges() bool {
+	return d.Err == nil && (d.Create || d.AddPartitions > 0 || len(d.Configs) > 0)
+}
+
+// TopicDiffs contains diffs for many topics.
+type TopicDiffs map[string]TopicDiff
+
+// Sorted returns the diffs sorted by topic.
+func (ds TopicDiffs) Sorted() []TopicDiff {
+	s := make([]TopicDiff, 0, len(ds))
+	for _, d := range ds {
+		s = append(s, d)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Topic < s[j].Topic })
+	return s
+}
+
+// HasChanges returns whether there is anything to apply for any topic.
+func (ds TopicDiffs) HasChanges() bool {
+	for _, d := range ds {
+		if d.HasChanges() {
+			return true
+		}
+	}
+	return false
+}
+
+// EachError calls fn for every diff that has a non-nil error.
+func (ds TopicDiffs) EachError(fn func(TopicDiff)) {
+	for _, d := range ds {
+		if d.Err != nil {
+			fn(d)
+		}
+	}
+}
+
+// Error returns the first error in the diffs, if any.
+func (ds TopicDiffs) Error() error {
+	for _, d := range ds {
+		if d.Err != nil {
+			return d.Err
+		}
+	}
+	return nil
+}
+
+// DiffTopics computes the differences between the desired topic specs and
+// the live state of the topics in the cluster.
+//
+// Configs are diffed with source awareness: a desired config whose live value
+// is already the same, even if the value comes from a broker default, is not
+// a change, and live configs that are not dynamically set on the topic are
+// never deleted. Partitions can only be increased; a spec with fewer
+// partitions than the topic currently has results in an error for that topic.
+//
+// This returns an error if the metadata or describe configs requests fail to
+// be issued, or an *AuthError.
+func (cl *Client) DiffTopics(ctx context.Context, specs ...TopicSpec) (TopicDiffs, error) {
+	ds := make(TopicDiffs, len(specs))
+	if len(specs) == 0 {
+		return ds, nil
+	}
+	topics := make([]string, 0, len(specs))
+	for _, s := range specs {
+		if _, exists := ds[s.Topic]; exists {
+			return nil, fmt.Errorf("duplicate topic spec for %q", s.Topic)
+		}
+		ds[s.Topic] = TopicDiff{Topic: s.Topic, Spec: s}
+		topics = append(topics, s.Topic)
+	}
+
+	tds, err := cl.ListTopics(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+
+	var describe []string
+	for _, t := range topics {
+		d := ds[t]
+		td := tds[t]
+		switch {
+		case !tds.Has(t):
+			d.Create = true
+		case td.Err != nil:
+			d.Err = td.Err
+		default:
+			d = diffTopicPartitions(d, td)
+			describe = append(describe, t)
+		}
+		ds[t] = d
+	}
+
+	rcs, err := cl.DescribeTopicConfigs(ctx, describe...)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(rcs))
+	for _, rc := range rcs {
+		seen[rc.Name] = true
+		d, ok := ds[rc.Name]
+		if !ok || d.Err != nil {
+			continue
+		}
+		if rc.Err != nil {
+			d.Err = rc.Err
+			ds[rc.Name] = d
+			continue
+		}
+		d.Configs = diffTopicConfigs(d.Spec.Configs, rc.Configs)
+		ds[rc.Name] = d
+	}
+	for _, t := range describe {
+		if d := ds[t]; d.Err == nil && !seen[t] {
+			d.Err = errors.New("topic configs were not described")
+			ds[t] = d
+		}
+	}
+	return ds, nil
+}
+
+// diffTopicPartitions sets the current partitions and replication factor of
+// an existing topic in the diff, as well as how many partitions to add.
+func diffTopicPartitions(d TopicDiff, td TopicDetail) TopicDiff {
+	d.CurrentPartitions = int32(len(td.Partitions))
+	if p, ok := td.Partitions[0]; ok {
+		d.CurrentReplicationFactor = int16(len(p.Replicas))
+	}
+	if want := d.Spec.Partitions; want > 0 {
+		if want < d.CurrentPartitions {
+			d.Err = fmt.Errorf("topic %q has %d partitions and cannot be decreased to %d", d.Topic, d.CurrentPartitions, want)
+		} else {
+			d.AddPartitions = want - d.CurrentPartitions
+		}
+	}
+	return d
+}
+
+// diffTopicConfigs returns the config changes, sorted by key, to go from the
+// live configs to the desired configs. Desired configs whose live value is
+// already the same are skipped regardless of the source, and only live
+// configs dynamically set on the topic are deleted.
+func diffTopicConfigs(want map[string]string, live []Config) []TopicConfigDiff {
+	byKey := make(map[string]Config, len(live))
+	for _, c := range live {
+		byKey[c.Key] = c
+	}
+	var diffs []TopicConfigDiff
+	for k, v := range want {
+		c, exists := byKey[k]
+		if exists && !c.Sensitive && c.Value != nil && *c.Value == v {
+			continue
+		}
+		cd := TopicConfigDiff{
+			Key:   k,
+			Op:    SetConfig,
+			Value: StringPtr(v),
+		}
+		if exists {
+			cd.Current = c.Value
+			cd.CurrentSource = c.Source
+		}
+		diffs = append(diffs, cd)
+	}
+	for _, c := range live {
+		if _, desired := want[c.Key]; desired || c.Source != kmsg.ConfigSourceDynamicTopicConfig {
+			continue
+		}
+		diffs = append(diffs, TopicConfigDiff{
+			Key:           c.Key,
+			Op:            DeleteConfig,
+			Current:       c.Value,
+			CurrentSource: c.Source,
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs
+}
+
+// ApplyTopicDiffResponse contains the response for applying the diff for a
+// single topic.
+type ApplyTopicDiffResponse struct {
+	Topic string    // Topic is the topic this response is for.
+	Diff  TopicDiff // Diff is the diff that was applied.
+	Err   error     // Err is the first error encountered applying the diff, if any.
+}
+
+// ApplyTopicDiffsResponses contains responses for applying diffs, keyed by
+// topic. Topics with no changes are not included.
+type ApplyTopicDiffsResponses map[string]ApplyTopicDiffResponse
+
+// Sorted returns the responses sorted by topic.
+func (rs ApplyTopicDiffsResponses) Sorted() []ApplyTopicDiffResponse {
+	s := make([]ApplyTopicDiffResponse, 0, len(rs))
+	for _, r := range rs {
+		s = append(s, r)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Topic < s[j].Topic })
+	return s
+}
+
+// EachError calls fn for every response that has a non-nil error.
+func (rs ApplyTopicDiffsResponses) EachError(fn func(ApplyTopicDiffResponse)) {
+	for _, r := range rs {
+		if r.Err != nil {
+			fn(r)
+		}
+	}
+}
+
+// Error returns the first error in the responses, if any.
+func (rs ApplyTopicDiffsResponses) Error() error {
+	for _, r := range rs {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
+
+// Ok returns true if there are no errors. This is a shortcut for rs.Error() ==
+// nil.
+func (rs ApplyTopicDiffsResponses) Ok() bool {
+	return rs.Error() == nil
+}
+
+// ApplyTopicDiffs applies topic diffs as returned from DiffTopics: topics are
+// created with CreateTopics, partitions are added with CreatePartitions, and
+// configs are set or deleted with AlterTopicConfigs. Diffs that have an error
+// or have no changes are skipped. Replication factor differences are not
+// applied.
+//
+// All diffs are attempted; any error, including a request failing to be
+// issued, is included in the responses for the affected topics.
+func (cl *Client) ApplyTopicDiffs(ctx context.Context, ds TopicDiffs) ApplyTopicDiffsResponses {
+	return cl.applyTopicDiffs(ctx, ds, false)
+}
+
+// ValidateApplyTopicDiffs validates applying topic diffs by using the
+// validate-only variants of each request: ValidateCreateTopics,
+// ValidateCreatePartitions, and ValidateAlterTopicConfigs. Nothing is changed
+// in the cluster.
+func (cl *Client) ValidateApplyTopicDiffs(ctx context.Context, ds TopicDiffs) ApplyTopicDiffsResponses {
+	return cl.applyTopicDiffs(ctx, ds, true)
+}
+
+// ReconcileTopics diffs the topic specs against the cluster and applies the
+// diff, returning the diff and the apply responses. This is a shortcut for
+// DiffTopics followed by ApplyTopicDiffs.
+//
+// This returns an error if DiffTopics returns an error.
+func (cl *Client) ReconcileTopics(ctx context.Context, specs ...TopicSpec) (TopicDiffs, ApplyTopicDiffsResponses, error) {
+	ds, err := cl.DiffTopics(ctx, specs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ds, cl.ApplyTopicDiffs(ctx, ds), nil
+}
+
+// topicDiffPlan is what applying topic diffs issues: topics to create,
+// topics to add partitions to keyed by how many partitions to add, and config
+// alterations per topic.
+type topicDiffPlan struct {
+	creates       []TopicSpec
+	addPartitions map[int32][]string
+	alters        map[string][]AlterConfig
+}
+
+// planTopicDiffs returns the plan for applying every diff that has changes.
+// Topics that are created have no other changes.
+func planTopicDiffs(ds TopicDiffs) topicDiffPlan {
+	plan := topicDiffPlan{
+		addPartitions: make(map[int32][]string),
+		alters:        make(map[string][]AlterConfig),
+	}
+	for _, d := range ds.Sorted() {
+		if !d.HasChanges() {
+			continue
+		}
+		if d.Create {
+			plan.creates = append(plan.creates, d.Spec)
+			continue
+		}
+		if d.AddPartitions > 0 {
+			plan.addPartitions[d.AddPartitions] = append(plan.addPartitions[d.AddPartitions], d.Topic)
+		}
+		for _, c := range d.Configs {
+			plan.alters[d.Topic] = append(plan.alters[d.Topic], AlterConfig{
+				Op:    c.Op,
+				Name:  c.Key,
+				Value: c.Value,
+			})
+		}
+	}
+	return plan
+}
+
+func (cl *Client) applyTopicDiffs(ctx context.Context, ds TopicDiffs, dry bool) ApplyTopicDiffsResponses {
+	rs := make(ApplyTopicDiffsResponses)
+	setErr := func(t string, err error) {
+		if r := rs[t]; r.Err == nil && err != nil {
+			r.Err = err
+			rs[t] = r
+		}
+	}
+
+	plan := planTopicDiffs(ds)
+	for _, d := range ds.Sorted() {
+		if !d.HasChanges() {
+			continue
+		}
+		rs[d.Topic] = ApplyTopicDiffResponse{Topic: d.Topic, Diff: d}
+
+		alters, ok := plan.alters[d.Topic]
+		if !ok {
+			continue
+		}
+		altered, err := cl.alterConfigs(ctx, dry, alters, kmsg.ConfigResourceTypeTopic, []string{d.Topic})
+		if err != nil {
+			setErr(d.Topic, err)
+		}
+		for _, a := range altered {
+			setErr(a.Name, a.Err)
+		}
+	}
+
+	if creates := plan.creates; len(creates) > 0 {
+		created, err := cl.createTopicSpecs(ctx, dry, creates)
+		responded := make(map[string]bool, len(created))
+		for _, c := range created {
+			responded[c.Topic] = true
+			setErr(c.Topic, c.Err)
+		}
+		for _, spec := range creates {
+			if err != nil {
+				setErr(spec.Topic, err)
+			} else if !responded[spec.Topic] {
+				setErr(spec.Topic, errors.New("topic missing from create topics response"))
+			}
+		}
+	}
+
+	for add, topics := range plan.addPartitions {
+		created, err := cl.createPartitions(ctx, dry, int(add), topics)
+		if err != nil {
+			for _, t := range topics {
+				setErr(t, err)
+			}
+			continue
+		}
+		responded := make(map[string]bool, len(created))
+		for _, c := range created {
+			responded[c.Topic] = true
+			setErr(c.Topic, c.Err)
+		}
+		for _, t := range topics {
+			if !responded[t] {
+				setErr(t, errors.New("topic missing from create partitions response"))
+			}
+		}
+	}
+	return rs
+}
@@ -0,0 +1,166 @@
+package kadm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestDiffTopicConfigs(t *testing.T) {
+	live := []Config{
+		{Key: "retention.ms", Value: StringPtr("1000"), Source: kmsg.ConfigSourceDynamicTopicConfig},
+		{Key: "cleanup.policy", Value: StringPtr("delete"), Source: kmsg.ConfigSourceDefaultConfig},
+		{Key: "segment.bytes", Value: StringPtr("100"), Source: kmsg.ConfigSourceStaticBrokerConfig},
+		{Key: "max.message.bytes", Value: StringPtr("5"), Source: kmsg.ConfigSourceDynamicTopicConfig},
+		{Key: "compression.type", Value: StringPtr("lz4"), Source: kmsg.ConfigSourceDynamicDefaultBrokerConfig},
+		{Key: "secret", Sensitive: true, Source: kmsg.ConfigSourceDynamicTopicConfig},
+	}
+
+	type diff struct {
+		key     string
+		op      IncrementalOp
+		current string
+		value   string
+	}
+	for _, test := range []struct {
+		name string
+		want map[string]string
+		exp  []diff
+	}{
+		{
+			name: "only dynamic topic configs are deleted",
+			exp: []diff{
+				{"max.message.bytes", DeleteConfig, "5", ""},
+				{"retention.ms", DeleteConfig, "1000", ""},
+				{"secret", DeleteConfig, "", ""},
+			},
+		},
+		{
+			name: "matching values are skipped regardless of source",
+			want: map[string]string{
+				"retention.ms":      "1000",
+				"cleanup.policy":    "delete",
+				"segment.bytes":     "100",
+				"max.message.bytes": "5",
+				"compression.type":  "lz4",
+			},
+			exp: []diff{
+				{"secret", DeleteConfig, "", ""},
+			},
+		},
+		{
+			name: "changed and new values are set",
+			want: map[string]string{
+				"retention.ms":      "2000",
+				"cleanup.policy":    "compact",
+				"max.message.bytes": "5",
+				"min.insync":        "2",
+				"secret":            "s",
+			},
+			exp: []diff{
+				{"cleanup.policy", SetConfig, "delete", "compact"},
+				{"min.insync", SetConfig, "", "2"},
+				{"retention.ms", SetConfig, "1000", "2000"},
+				{"secret", SetConfig, "", "s"}, // sensitive values are always set
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []diff
+			for _, d := range diffTopicConfigs(test.want, live) {
+				var current, value string
+				if d.Current != nil {
+					current = *d.Current
+				}
+				if d.Value != nil {
+					value = *d.Value
+				}
+				got = append(got, diff{d.Key, d.Op, current, value})
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("got %v != exp %v", got, test.exp)
+			}
+		})
+	}
+}
+
+func TestDiffTopicPartitions(t *testing.T) {
+	td := TopicDetail{
+		Topic: "t",
+		Partitions: PartitionDetails{
+			0: {Replicas: []int32{1, 2, 3}},
+			1: {Replicas: []int32{2, 3, 1}},
+		},
+	}
+	for _, test := range []struct {
+		name   string
+		spec   TopicSpec
+		expAdd int32
+		expErr bool
+		expRF  bool
+	}{
+		{name: "unset partitions are not diffed", spec: TopicSpec{Topic: "t"}},
+		{name: "same partitions", spec: TopicSpec{Topic: "t", Partitions: 2}},
+		{name: "add partitions", spec: TopicSpec{Topic: "t", Partitions: 5}, expAdd: 3},
+		{name: "decrease is an error", spec: TopicSpec{Topic: "t", Partitions: 1}, expErr: true},
+		{name: "replication factor differs", spec: TopicSpec{Topic: "t", ReplicationFactor: 2}, expRF: true},
+		{name: "replication factor matches", spec: TopicSpec{Topic: "t", ReplicationFactor: 3}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			d := diffTopicPartitions(TopicDiff{Topic: "t", Spec: test.spec}, td)
+			if d.CurrentPartitions != 2 || d.CurrentReplicationFactor != 3 {
+				t.Errorf("got current partitions %d, rf %d, exp 2, 3", d.CurrentPartitions, d.CurrentReplicationFactor)
+			}
+			if d.AddPartitions != test.expAdd {
+				t.Errorf("got add partitions %d != exp %d", d.AddPartitions, test.expAdd)
+			}
+			if gotErr := d.Err != nil; gotErr != test.expErr {
+				t.Errorf("got err? %v (%v), exp err? %v", gotErr, d.Err, test.expErr)
+			}
+			if got := d.ReplicationFactorDiffers(); got != test.expRF {
+				t.Errorf("got replication factor differs %v != exp %v", got, test.expRF)
+			}
+		})
+	}
+}
+
+func TestPlanTopicDiffs(t *testing.T) {
+	ds := TopicDiffs{
+		"create": {
+			Topic:  "create",
+			Spec:   TopicSpec{Topic: "create", Partitions: 3},
+			Create: true,
+		},
+		"grow-a": {Topic: "grow-a", AddPartitions: 2},
+		"grow-b": {Topic: "grow-b", AddPartitions: 2},
+		"grow-c": {
+			Topic:         "grow-c",
+			AddPartitions: 1,
+			Configs: []TopicConfigDiff{
+				{Key: "retention.ms", Op: SetConfig, Value: StringPtr("10")},
+				{Key: "old", Op: DeleteConfig, Current: StringPtr("x")},
+			},
+		},
+		"unchanged": {Topic: "unchanged"},
+		"errored":   {Topic: "errored", AddPartitions: 1, Err: kerr.UnknownTopicOrPartition},
+		"rf-only":   {Topic: "rf-only", Spec: TopicSpec{ReplicationFactor: 3}, CurrentReplicationFactor: 1},
+	}
+
+	plan := planTopicDiffs(ds)
+
+	if len(plan.creates) != 1 || plan.creates[0].Topic != "create" {
+		t.Errorf("got creates %v, exp only create", plan.creates)
+	}
+	if exp := map[int32][]string{2: {"grow-a", "grow-b"}, 1: {"grow-c"}}; !reflect.DeepEqual(plan.addPartitions, exp) {
+		t.Errorf("got add partitions %v != exp %v", plan.addPartitions, exp)
+	}
+	exp := map[string][]AlterConfig{"grow-c": {
+		{Op: SetConfig, Name: "retention.ms", Value: StringPtr("10")},
+		{Op: DeleteConfig, Name: "old"},
+	}}
+	if !reflect.DeepEqual(plan.alters, exp) {
+		t.Errorf("got alters %v != exp %v", plan.alters, exp)
+	}
+}
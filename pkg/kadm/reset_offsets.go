@@ -0,0 +1,381 @@
+package kadm
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+type resetKind uint8
+
+const (
+	resetEarliest resetKind = iota
+	resetLatest
+	resetDatetime
+	resetShift
+	resetOffset
+	resetFromOffsets
+)
+
+// OffsetReset is how to reset group offsets in ResetOffsets, equivalent to
+// the options of kafka-consumer-groups.sh --reset-offsets. Use one of the
+// Reset functions to create an OffsetReset.
+type OffsetReset struct {
+	kind    resetKind
+	at      int64
+	offsets Offsets
+}
+
+// ResetToEarliest resets offsets to the log start offset of each partition.
+func ResetToEarliest() OffsetReset { return OffsetReset{kind: resetEarliest} }
+
+// ResetToLatest resets offsets to the high watermark of each partition.
+func ResetToLatest() OffsetReset { return OffsetReset{kind: resetLatest} }
+
+// ResetToDatetime resets offsets to the first offset at or after the given
+// time in each partition. If no record is at or after the time, the offset is
+// reset to the high watermark.
+func ResetToDatetime(t time.Time) OffsetReset {
+	return OffsetReset{kind: resetDatetime, at: t.UnixNano() / 1e6}
+}
+
+// ResetShiftBy shifts each currently committed offset by n, which can be
+// negative. The new offset is clamped between the log start offset and the
+// high watermark. Partitions with no committed offset cannot be shifted and
+// have an error in the response.
+func ResetShiftBy(n int64) OffsetReset { return OffsetReset{kind: resetShift, at: n} }
+
+// ResetToOffset resets offsets to the given offset, clamped between the log
+// start offset and the high watermark of each partition.
+func ResetToOffset(o int64) OffsetReset { return OffsetReset{kind: resetOffset, at: o} }
+
+// ResetFromOffsets resets partitions to the given offsets, clamped between
+// the log start offset and the high watermark of each partition. This is the
+// equivalent of --from-file; see ParseOffsetsCSV.
+//
+// Only partitions in the given offsets are reset. If ResetOffsets is given a
+// non-nil topics set, the offsets are further filtered to that set.
+func ResetFromOffsets(os Offsets) OffsetReset {
+	return OffsetReset{kind: resetFromOffsets, offsets: os}
+}
+
+// ParseOffsetsCSV parses offsets in the CSV format used by
+// kafka-consumer-groups.sh --reset-offsets --from-file and --export, where
+// each line is "topic,partition,offset". Lines that are empty are skipped.
+func ParseOffsetsCSV(r io.Reader) (Offsets, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+
+	var os Offsets
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return os, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p, err := strconv.ParseInt(strings.TrimSpace(rec[1]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid partition %q: %v", line, rec[1], err)
+		}
+		o, err := strconv.ParseInt(strings.TrimSpace(rec[2]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid offset %q: %v", line, rec[2], err)
+		}
+		os.AddOffset(strings.TrimSpace(rec[0]), int32(p), o)
+	}
+}
+
+// ResetOffsetResponse contains the response for an individual partition in
+// an offset reset.
+type ResetOffsetResponse struct {
+	Topic     string // Topic is the topic this reset is for.
+	Partition int32  // Partition is the partition this reset is for.
+
+	Prev   int64 // Prev is the previously committed offset, or -1 if there was no committed offset.
+	Offset int64 // Offset is the offset the partition was (or, if validating, would be) reset to.
+
+	Err error // Err is non-nil if the offset could not be determined or committed.
+}
+
+// ResetOffsetsResponses contains per-partition responses to an offset reset.
+type ResetOffsetsResponses map[string]map[int32]ResetOffsetResponse
+
+// Sorted returns the responses sorted by topic and partition.
+func (rs ResetOffsetsResponses) Sorted() []ResetOffsetResponse {
+	var s []ResetOffsetResponse
+	for _, ps := range rs {
+		for _, r := range ps {
+			s = append(s, r)
+		}
+	}
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		return l.Topic < r.Topic || l.Topic == r.Topic && l.Partition < r.Partition
+	})
+	return s
+}
+
+// Each calls fn for every response.
+func (rs ResetOffsetsResponses) Each(fn func(ResetOffsetResponse)) {
+	for _, ps := range rs {
+		for _, r := range ps {
+			fn(r)
+		}
+	}
+}
+
+// EachError calls fn for every response that has a non-nil error.
+func (rs ResetOffsetsResponses) EachError(fn func(ResetOffsetResponse)) {
+	rs.Each(func(r ResetOffsetResponse) {
+		if r.Err != nil {
+			fn(r)
+		}
+	})
+}
+
+// Error returns the first error in the responses, if any.
+func (rs ResetOffsetsResponses) Error() error {
+	for _, ps := range rs {
+		for _, r := range ps {
+			if r.Err != nil {
+				return r.Err
+			}
+		}
+	}
+	return nil
+}
+
+// Ok returns true if there are no errors. This is a shortcut for rs.Error() ==
+// nil.
+func (rs ResetOffsetsResponses) Ok() bool {
+	return rs.Error() == nil
+}
+
+// Offsets returns the new offsets for all responses that have no error. This
+// can be used to commit the result of a validated reset, or to export offsets.
+func (rs ResetOffsetsResponses) Offsets() Offsets {
+	var os Offsets
+	rs.Each(func(r ResetOffsetResponse) {
+		if r.Err == nil {
+			os.AddOffset(r.Topic, r.Partition, r.Offset)
+		}
+	})
+	return os
+}
+
+// ResetOffsets resets the committed offsets of a group, similar to
+// kafka-consumer-groups.sh --reset-offsets --execute.
+//
+// Offsets can only be reset if the group has no active members: this first
+// describes the group and returns an error if the group is not Empty or
+// Dead.
+//
+// The topics set chooses which partitions to reset. A topic with no
+// partitions in the set resets all partitions of the topic. If the set is
+// nil, all partitions the group has committed offsets for are reset, or, for
+// ResetFromOffsets, all partitions in the given offsets.
+//
+// This returns an error if any request fails to be issued, if the group could
+// not be described or is not empty, or an *AuthError. Per-partition errors,
+// including commit errors, are included in the responses.
+func (cl *Client) ResetOffsets(ctx context.Context, group string, s TopicsSet, how OffsetReset) (ResetOffsetsResponses, error) {
+	return cl.resetOffsets(ctx, group, s, how, false)
+}
+
+// ValidateResetOffsets is ResetOffsets, but does not commit the new offsets.
+// This is the equivalent of kafka-consumer-groups.sh --reset-offsets
+// --dry-run, and can be used to print what a reset would do.
+func (cl *Client) ValidateResetOffsets(ctx context.Context, group string, s TopicsSet, how OffsetReset) (ResetOffsetsResponses, error) {
+	return cl.resetOffsets(ctx, group, s, how, true)
+}
+
+func (cl *Client) resetOffsets(ctx context.Context, group string, s TopicsSet, how OffsetReset, dry bool) (ResetOffsetsResponses, error) {
+	if err := cl.requireInactiveGroup(ctx, group); err != nil {
+		return nil, err
+	}
+
+	committed, err := cl.FetchOffsets(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	// We first determine the topics we are resetting, and then
+	// determine the partitions once we have the partitions per topic
+	// from listing start offsets.
+	var want TopicsSet
+	switch {
+	case how.kind == resetFromOffsets:
+		for t, ps := range how.offsets {
+			sps, ok := s[t]
+			if s != nil && !ok {
+				continue
+			}
+			for p := range ps {
+				if _, ok := sps[p]; len(sps) == 0 || ok {
+					want.Add(t, p)
+				}
+			}
+		}
+	case s != nil:
+		for t, ps := range s {
+			want.Add(t)
+			for p := range ps {
+				want.Add(t, p)
+			}
+		}
+	default:
+		committed.Each(func(o OffsetResponse) {
+			want.Add(o.Topic, o.Partition)
+		})
+	}
+	if len(want) == 0 {
+		return make(ResetOffsetsResponses), nil
+	}
+	topics := want.Topics()
+
+	starts, err := cl.ListStartOffsets(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	ends, err := cl.ListEndOffsets(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	var afters ListedOffsets
+	if how.kind == resetDatetime {
+		if afters, err = cl.ListOffsetsAfterMilli(ctx, how.at, topics...); err != nil {
+			return nil, err
+		}
+	}
+
+	rs, err := computeResets(want, how, committed, starts, ends, afters)
+	if err != nil {
+		return nil, err
+	}
+
+	if dry {
+		return rs, nil
+	}
+	commit := rs.Offsets()
+	if len(commit) == 0 {
+		return rs, nil
+	}
+	committedResps, err := cl.CommitOffsets(ctx, group, commit)
+	if err != nil {
+		return nil, err
+	}
+	committedResps.Each(func(c OffsetResponse) {
+		r := rs[c.Topic][c.Partition]
+		r.Err = c.Err
+		rs[c.Topic][c.Partition] = r
+	})
+	return rs, nil
+}
+
+var (
+	errResetNoCommit     = errors.New("cannot shift offset: the group has no committed offset")
+	errResetNotInOffsets = errors.New("partition is not in the offsets to reset from")
+)
+
+// computeResets returns the new offset for every wanted partition, clamped
+// between the partition's start and end offset. A wanted topic with no
+// partitions is expanded to every partition listed in starts. Afters are only
+// used for ResetToDatetime.
+func computeResets(want TopicsSet, how OffsetReset, committed OffsetResponses, starts, ends, afters ListedOffsets) (ResetOffsetsResponses, error) {
+	rs := make(ResetOffsetsResponses)
+	for _, t := range want.Topics() {
+		ps := want[t]
+		if len(ps) == 0 {
+			for p := range starts[t] {
+				want.Add(t, p)
+			}
+			if ps = want[t]; len(ps) == 0 {
+				return nil, fmt.Errorf("unable to reset offsets for topic %q: %w", t, kerr.UnknownTopicOrPartition)
+			}
+		}
+		rt := make(map[int32]ResetOffsetResponse, len(ps))
+		rs[t] = rt
+		for p := range ps {
+			r := ResetOffsetResponse{
+				Topic:     t,
+				Partition: p,
+				Prev:      -1,
+				Offset:    -1,
+			}
+			if c, ok := committed[t][p]; ok && c.Err == nil {
+				r.Prev = c.Offset.Offset
+			}
+
+			start, sok := starts[t][p]
+			end, eok := ends[t][p]
+			switch {
+			case !sok || !eok:
+				r.Err = kerr.UnknownTopicOrPartition
+			case start.Err != nil:
+				r.Err = start.Err
+			case end.Err != nil:
+				r.Err = end.Err
+			}
+			if r.Err != nil {
+				rt[p] = r
+				continue
+			}
+
+			var o int64
+			switch how.kind {
+			case resetEarliest:
+				o = start.Offset
+			case resetLatest:
+				o = end.Offset
+			case resetDatetime:
+				after, ok := afters[t][p]
+				switch {
+				case !ok:
+					r.Err = kerr.UnknownTopicOrPartition
+				case after.Err != nil:
+					r.Err = after.Err
+				case after.Offset < 0: // no record at or after the time
+					o = end.Offset
+				default:
+					o = after.Offset
+				}
+			case resetShift:
+				if r.Prev < 0 {
+					r.Err = errResetNoCommit
+				}
+				o = r.Prev + how.at
+			case resetOffset:
+				o = how.at
+			case resetFromOffsets:
+				from, ok := how.offsets[t][p]
+				if !ok {
+					r.Err = errResetNotInOffsets
+				}
+				o = from.Offset
+			}
+			if r.Err == nil {
+				if o < start.Offset {
+					o = start.Offset
+				}
+				if o > end.Offset {
+					o = end.Offset
+				}
+				r.Offset = o
+			}
+			rt[p] = r
+		}
+	}
+
+	return rs, nil
+}
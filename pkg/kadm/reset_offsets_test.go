@@ -0,0 +1,221 @@
+package kadm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestParseOffsetsCSV(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		in     string
+		exp    map[string]map[int32]int64
+		expErr bool
+	}{
+		{
+			name: "empty",
+			in:   "",
+		},
+		{
+			name: "basic",
+			in:   "foo,0,10\nfoo,1,20\nbar,0,5\n",
+			exp:  map[string]map[int32]int64{"foo": {0: 10, 1: 20}, "bar": {0: 5}},
+		},
+		{
+			name: "whitespace and empty lines",
+			in:   "  foo , 0 , 10 \n\nbar,\t1,\t2\n\n",
+			exp:  map[string]map[int32]int64{"foo": {0: 10}, "bar": {1: 2}},
+		},
+		{
+			name:   "bad partition",
+			in:     "foo,zero,10\n",
+			expErr: true,
+		},
+		{
+			name:   "partition overflows int32",
+			in:     "foo,2147483648,10\n",
+			expErr: true,
+		},
+		{
+			name:   "bad offset",
+			in:     "foo,0,ten\n",
+			expErr: true,
+		},
+		{
+			name:   "too few fields",
+			in:     "foo,0\n",
+			expErr: true,
+		},
+		{
+			name:   "too many fields",
+			in:     "foo,0,10,1\n",
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			os, err := ParseOffsetsCSV(strings.NewReader(test.in))
+			if gotErr := err != nil; gotErr != test.expErr {
+				t.Fatalf("got err? %v (%v), exp err? %v", gotErr, err, test.expErr)
+			}
+			if test.expErr {
+				return
+			}
+			var got map[string]map[int32]int64
+			for t, ps := range os {
+				if got == nil {
+					got = make(map[string]map[int32]int64)
+				}
+				got[t] = make(map[int32]int64)
+				for p, o := range ps {
+					got[t][p] = o.Offset
+				}
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("got %v != exp %v", got, test.exp)
+			}
+		})
+	}
+}
+
+func TestComputeResets(t *testing.T) {
+	// Partitions 0 through 2 of topic "t" have start offsets 10 and end
+	// offsets 100. The group has committed 50 to partition 0 and 95 to
+	// partition 1, and a commit error on partition 2.
+	listed := func(offset int64) ListedOffsets {
+		lo := make(ListedOffsets)
+		lo["t"] = make(map[int32]ListedOffset)
+		for p := int32(0); p < 3; p++ {
+			lo["t"][p] = ListedOffset{Topic: "t", Partition: p, Offset: offset}
+		}
+		return lo
+	}
+	starts, ends := listed(10), listed(100)
+	committed := OffsetResponses{"t": {
+		0: {Offset: Offset{Topic: "t", Partition: 0, Offset: 50}},
+		1: {Offset: Offset{Topic: "t", Partition: 1, Offset: 95}},
+		2: {Offset: Offset{Topic: "t", Partition: 2, Offset: 70}, Err: errors.New("commit error")},
+	}}
+	afters := ListedOffsets{"t": {
+		0: {Offset: 40},
+		1: {Offset: -1}, // no record after the time
+		2: {Offset: 5},
+	}}
+
+	type exp struct {
+		prev   int64
+		offset int64
+		err    error
+	}
+	for _, test := range []struct {
+		name string
+		how  OffsetReset
+		want TopicsSet // if nil, all partitions of "t"
+		exp  map[int32]exp
+	}{
+		{
+			name: "earliest",
+			how:  ResetToEarliest(),
+			exp:  map[int32]exp{0: {50, 10, nil}, 1: {95, 10, nil}, 2: {-1, 10, nil}},
+		},
+		{
+			name: "latest",
+			how:  ResetToLatest(),
+			exp:  map[int32]exp{0: {50, 100, nil}, 1: {95, 100, nil}, 2: {-1, 100, nil}},
+		},
+		{
+			name: "datetime",
+			how:  ResetToDatetime(time.Unix(1, 0)),
+			exp:  map[int32]exp{0: {50, 40, nil}, 1: {95, 100, nil}, 2: {-1, 10, nil}},
+		},
+		{
+			name: "shift clamps and requires a commit",
+			how:  ResetShiftBy(10),
+			exp:  map[int32]exp{0: {50, 60, nil}, 1: {95, 100, nil}, 2: {-1, -1, errResetNoCommit}},
+		},
+		{
+			name: "negative shift clamps to the start",
+			how:  ResetShiftBy(-45),
+			exp:  map[int32]exp{0: {50, 10, nil}, 1: {95, 50, nil}, 2: {-1, -1, errResetNoCommit}},
+		},
+		{
+			name: "offset clamps below the start",
+			how:  ResetToOffset(3),
+			exp:  map[int32]exp{0: {50, 10, nil}, 1: {95, 10, nil}, 2: {-1, 10, nil}},
+		},
+		{
+			name: "offset clamps above the end",
+			how:  ResetToOffset(1000),
+			exp:  map[int32]exp{0: {50, 100, nil}, 1: {95, 100, nil}, 2: {-1, 100, nil}},
+		},
+		{
+			name: "from offsets",
+			how: ResetFromOffsets(Offsets{"t": {
+				0: {Topic: "t", Partition: 0, Offset: 20},
+				1: {Topic: "t", Partition: 1, Offset: 200},
+			}}),
+			exp: map[int32]exp{0: {50, 20, nil}, 1: {95, 100, nil}, 2: {-1, -1, errResetNotInOffsets}},
+		},
+		{
+			name: "only wanted partitions",
+			how:  ResetToEarliest(),
+			want: TopicsSet{"t": {1: {}}},
+			exp:  map[int32]exp{1: {95, 10, nil}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			want := test.want
+			if want == nil {
+				want = TopicsSet{"t": nil}
+			}
+			var useAfters ListedOffsets
+			if test.how.kind == resetDatetime {
+				useAfters = afters
+			}
+			rs, err := computeResets(want, test.how, committed, starts, ends, useAfters)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if len(rs["t"]) != len(test.exp) {
+				t.Errorf("got %d partitions != exp %d", len(rs["t"]), len(test.exp))
+			}
+			for p, e := range test.exp {
+				r := rs["t"][p]
+				if r.Prev != e.prev || r.Offset != e.offset || !errors.Is(r.Err, e.err) {
+					t.Errorf("partition %d: got prev %d offset %d err %v, exp prev %d offset %d err %v",
+						p, r.Prev, r.Offset, r.Err, e.prev, e.offset, e.err)
+				}
+			}
+		})
+	}
+
+	t.Run("list errors", func(t *testing.T) {
+		ends := listed(100)
+		ends["t"][0] = ListedOffset{Topic: "t", Partition: 0, Err: kerr.NotLeaderForPartition}
+		delete(ends["t"], 1)
+		rs, err := computeResets(TopicsSet{"t": nil}, ResetToLatest(), committed, starts, ends, nil)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if err := rs["t"][0].Err; err != kerr.NotLeaderForPartition {
+			t.Errorf("partition 0: got err %v, exp %v", err, kerr.NotLeaderForPartition)
+		}
+		if err := rs["t"][1].Err; err != kerr.UnknownTopicOrPartition {
+			t.Errorf("partition 1: got err %v, exp %v", err, kerr.UnknownTopicOrPartition)
+		}
+		if got := rs.Offsets(); len(got["t"]) != 1 || got["t"][2].Offset != 100 {
+			t.Errorf("got commit offsets %v, exp only partition 2 at 100", got)
+		}
+	})
+
+	t.Run("unknown topic", func(t *testing.T) {
+		_, err := computeResets(TopicsSet{"missing": nil}, ResetToLatest(), committed, starts, ends, nil)
+		if !errors.Is(err, kerr.UnknownTopicOrPartition) {
+			t.Errorf("got err %v, exp %v", err, kerr.UnknownTopicOrPartition)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package kadm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Principal is a principal that owns or renews a delegation token. This is the
+// same as an ACL principal, and for default authorizers this is of the form
+// "User:<name>".
+type Principal struct {
+	Type string // Type is the type of a principal owner or renewer. If empty, this defaults to "User".
+	Name string // Name is the name of a principal owner or renewer.
+}
+
+// ParsePrincipal parses a principal of the form "Type:Name". If there is no
+// colon, the type defaults to "User".
+func ParsePrincipal(p string) Principal {
+	if i := strings.IndexByte(p, ':'); i >= 0 {
+		return Principal{Type: p[:i], Name: p[i+1:]}
+	}
+	return Principal{Type: "User", Name: p}
+}
+
+// String returns the principal as "Type:Name".
+func (p Principal) String() string {
+	return p.typ() + ":" + p.Name
+}
+
+func (p Principal) typ() string {
+	if p.Type == "" {
+		return "User"
+	}
+	return p.Type
+}
+
+// DelegationToken contains information about a delegation token.
+type DelegationToken struct {
+	// Owner is the owner of the delegation token.
+	Owner Principal
+	// TokenID is the ID of this token, which is used as the user when
+	// authenticating with the token.
+	TokenID string
+	// HMAC is the HMAC of this token, which is used (base64 encoded) as
+	// the password when authenticating with the token, and is used to
+	// renew, expire, or describe the token.
+	HMAC []byte
+	// Renewers are principals, other than the owner, that are allowed to
+	// renew this token.
+	Renewers []Principal
+
+	// IssueTimestamp is the time this delegation token was issued.
+	IssueTimestamp time.Time
+	// ExpiryTimestamp is the time this token will expire, unless it is
+	// renewed first.
+	ExpiryTimestamp time.Time
+	// MaxTimestamp is the time past which this token can no longer be
+	// renewed.
+	MaxTimestamp time.Time
+}
+
+// DelegationTokens contains a list of delegation tokens.
+type DelegationTokens []DelegationToken
+
+// Sorted returns the delegation tokens sorted by owner and then token ID.
+func (ts DelegationTokens) Sorted() DelegationTokens {
+	s := append(DelegationTokens(nil), ts...)
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		if lo, ro := l.Owner.String(), r.Owner.String(); lo != ro {
+			return lo < ro
+		}
+		return l.TokenID < r.TokenID
+	})
+	return s
+}
+
+// CreateDelegationToken is a create delegation token request, allowing you to
+// create scoped tokens with the same ACLs as the creator. This allows you to
+// more easily manage authorization for a wide array of clients. All
+// delegation tokens use SCRAM-SHA-256 or SCRAM-SHA-512 for authentication;
+// see the TokenSha256 and TokenSha512 functions in pkg/sasl/scram.
+type CreateDelegationToken struct {
+	// Renewers is a list of principals that can renew the delegation
+	// token in addition to the owner of the token.
+	Renewers []Principal
+	// MaxLifetime is how long the delegation token is valid for. If
+	// zero, the broker's delegation.token.max.lifetime.ms is used.
+	MaxLifetime time.Duration
+}
+
+// CreateDelegationToken creates a delegation token, which is a scoped SCRAM
+// username and password. The returned token is owned by the principal the
+// client is authenticated as.
+//
+// Creating delegation tokens allows for an (ideally) quicker and easier method
+// of enabling authorization for a wide array of clients. Rather than having to
+// manage many passwords external to Kafka, you only need to manage a few
+// accounts and use those to create delegation tokens per client.
+//
+// Note that delegation tokens inherit the same ACLs as the user creating the
+// token. Thus, if you want to properly scope ACLs, you should not create
+// delegation tokens with admin accounts.
+//
+// This can return *AuthError.
+func (cl *Client) CreateDelegationToken(ctx context.Context, d CreateDelegationToken) (DelegationToken, error) {
+	req := kmsg.NewPtrCreateDelegationTokenRequest()
+	for _, r := range d.Renewers {
+		rr := kmsg.NewCreateDelegationTokenRequestRenewer()
+		rr.PrincipalType = r.typ()
+		rr.PrincipalName = r.Name
+		req.Renewers = append(req.Renewers, rr)
+	}
+	req.MaxLifetimeMillis = -1
+	if d.MaxLifetime > 0 {
+		req.MaxLifetimeMillis = d.MaxLifetime.Milliseconds()
+	}
+
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return DelegationToken{}, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return DelegationToken{}, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return DelegationToken{}, err
+	}
+
+	return DelegationToken{
+		Owner:           Principal{Type: resp.PrincipalType, Name: resp.PrincipalName},
+		TokenID:         resp.TokenID,
+		HMAC:            resp.HMAC,
+		Renewers:        append([]Principal(nil), d.Renewers...),
+		IssueTimestamp:  time.Unix(0, resp.IssueTimestamp*1e6),
+		ExpiryTimestamp: time.Unix(0, resp.ExpiryTimestamp*1e6),
+		MaxTimestamp:    time.Unix(0, resp.MaxTimestamp*1e6),
+	}, nil
+}
+
+// RenewDelegationToken renews a delegation token that has the given HMAC for
+// the given renew time, returning the token's new expiry timestamp. A renew
+// time of zero uses the broker's delegation.token.expiry.time.ms. A token
+// cannot be renewed past its max timestamp.
+//
+// This can return *AuthError.
+func (cl *Client) RenewDelegationToken(ctx context.Context, hmac []byte, renewTime time.Duration) (expiryTimestamp time.Time, err error) {
+	req := kmsg.NewPtrRenewDelegationTokenRequest()
+	req.HMAC = hmac
+	req.RenewTimeMillis = -1
+	if renewTime > 0 {
+		req.RenewTimeMillis = renewTime.Milliseconds()
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return time.Time{}, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, resp.ExpiryTimestamp*1e6), nil
+}
+
+// ExpireDelegationToken changes a delegation token's expiry timestamp to now
+// plus the given expiry time, returning the new expiry timestamp. An expiry
+// time of zero (or negative) expires the token immediately.
+//
+// This can return *AuthError.
+func (cl *Client) ExpireDelegationToken(ctx context.Context, hmac []byte, expiry time.Duration) (expiryTimestamp time.Time, err error) {
+	req := kmsg.NewPtrExpireDelegationTokenRequest()
+	req.HMAC = hmac
+	req.ExpiryPeriodMillis = -1
+	if expiry > 0 {
+		req.ExpiryPeriodMillis = expiry.Milliseconds()
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return time.Time{}, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, resp.ExpiryTimestamp*1e6), nil
+}
+
+// DescribeDelegationTokens describes delegation tokens owned by the given
+// principals. If no owners are specified, this describes all tokens that the
+// client is authorized to describe: tokens the client owns or can renew, and
+// all tokens if the client has DESCRIBE_TOKENS on the user resource.
+//
+// This can return *AuthError.
+func (cl *Client) DescribeDelegationTokens(ctx context.Context, owners ...Principal) (DelegationTokens, error) {
+	req := kmsg.NewPtrDescribeDelegationTokenRequest()
+	for _, o := range owners {
+		ro := kmsg.NewDescribeDelegationTokenRequestOwner()
+		ro.PrincipalType = o.typ()
+		ro.PrincipalName = o.Name
+		req.Owners = append(req.Owners, ro)
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+
+	var ts DelegationTokens
+	for _, d := range resp.TokenDetails {
+		t := DelegationToken{
+			Owner:           Principal{Type: d.PrincipalType, Name: d.PrincipalName},
+			TokenID:         d.TokenID,
+			HMAC:            d.HMAC,
+			IssueTimestamp:  time.Unix(0, d.IssueTimestamp*1e6),
+			ExpiryTimestamp: time.Unix(0, d.ExpiryTimestamp*1e6),
+			MaxTimestamp:    time.Unix(0, d.MaxTimestamp*1e6),
+		}
+		for _, r := range d.Renewers {
+			t.Renewers = append(t.Renewers, Principal{Type: r.PrincipalType, Name: r.PrincipalName})
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+// String returns the token ID, owner, and expiry timestamp for this token.
+// The HMAC is not included.
+func (t DelegationToken) String() string {
+	return fmt.Sprintf("%s (owner %s, expires %s)", t.TokenID, t.Owner, t.ExpiryTimestamp.Format(time.RFC3339))
+}
@@ -2,6 +2,7 @@ package kadm
 
 import (
 	"context"
+	"sort"
 
 	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kmsg"
@@ -27,7 +28,11 @@ func (cl *Client) ListTopics(
 type CreateTopicResponse struct {
 	Topic string  // Topic is the topic that was created.
 	ID    TopicID // ID is the topic ID for this topic, if talking to Kafka v2.8+.
-	Err   error   // Err is any error preventing this topic from being created.
+
+	Partitions        int32 // Partitions is the number of partitions in the created topic, or -1 if unknown (Kafka < 2.4).
+	ReplicationFactor int16 // ReplicationFactor is the replication factor of the created topic, or -1 if unknown (Kafka < 2.4).
+
+	Err error // Err is any error preventing this topic from being created.
 }
 
 // CreateTopics issues a create topics request with the given partitions,
@@ -74,8 +79,7 @@ func (cl *Client) createTopics(ctx context.Context, dry bool, p int32, rf int16,
 		return nil, nil
 	}
 
-	req := kmsg.NewCreateTopicsRequest()
-	req.ValidateOnly = true
+	var rts []kmsg.CreateTopicsRequestTopic
 	for _, t := range topics {
 		rt := kmsg.NewCreateTopicsRequestTopic()
 		rt.Topic = t
@@ -87,9 +91,116 @@ func (cl *Client) createTopics(ctx context.Context, dry bool, p int32, rf int16,
 			rc.Value = v
 			rt.Configs = append(rt.Configs, rc)
 		}
-		req.Topics = append(req.Topics, rt)
+		rts = append(rts, rt)
 	}
+	return cl.issueCreateTopics(ctx, dry, rts)
+}
+
+// TopicSpec is the desired state of a topic, used for creating topics with
+// per-topic settings and for reconciling topics. The json tags allow for specs
+// to be easily decoded from JSON or YAML files.
+type TopicSpec struct {
+	// Topic is the name of the topic.
+	Topic string `json:"topic"`
+
+	// Partitions is the desired number of partitions. If non-positive,
+	// the broker default is used when creating the topic, and the number
+	// of partitions is not diffed for existing topics.
+	Partitions int32 `json:"partitions,omitempty"`
+
+	// ReplicationFactor is the desired replication factor. If
+	// non-positive, the broker default is used when creating the topic,
+	// and the replication factor is not diffed for existing topics.
+	ReplicationFactor int16 `json:"replication_factor,omitempty"`
+
+	// Configs are the desired topic configs. Any config that is
+	// dynamically set on an existing topic that is not in this map is
+	// deleted, reverting it to the broker default.
+	Configs map[string]string `json:"configs,omitempty"`
 
+	// ReplicaAssignment, if non-empty, explicitly assigns replicas for
+	// every partition of a created topic, with the first replica in each
+	// partition being the preferred leader. This can be used to pin
+	// replicas to specific brokers, for example for rack isolation. If
+	// set, Partitions and ReplicationFactor are not used when creating the
+	// topic; the partition count and replication factor come from the
+	// assignment.
+	//
+	// This is only used when creating topics and is not diffed for
+	// existing topics.
+	ReplicaAssignment map[int32][]int32 `json:"replica_assignment,omitempty"`
+}
+
+// CreateTopicSpecs issues a create topics request for the given topic specs,
+// allowing each topic to have its own partitions, replication factor,
+// configs, or explicit replica assignment. Non-positive partitions or
+// replication factors use the broker defaults (requires Kafka 2.4+). Explicit
+// replica assignments are passed through as is and validated by the broker.
+//
+// This does not return an error on authorization failures, instead,
+// authorization failures are included in the responses. This only returns an
+// error if the request fails to be issued. You may consider checking
+// ValidateCreateTopicSpecs before using this method.
+func (cl *Client) CreateTopicSpecs(ctx context.Context, specs ...TopicSpec) ([]CreateTopicResponse, error) {
+	return cl.createTopicSpecs(ctx, false, specs)
+}
+
+// ValidateCreateTopicSpecs validates a create topics request for the given
+// topic specs.
+//
+// This uses the same logic as CreateTopicSpecs, but with the request's
+// ValidateOnly field set to true. The response is the same response you would
+// receive from CreateTopicSpecs, but no topics are actually created.
+func (cl *Client) ValidateCreateTopicSpecs(ctx context.Context, specs ...TopicSpec) ([]CreateTopicResponse, error) {
+	return cl.createTopicSpecs(ctx, true, specs)
+}
+
+func (cl *Client) createTopicSpecs(ctx context.Context, dry bool, specs []TopicSpec) ([]CreateTopicResponse, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	var rts []kmsg.CreateTopicsRequestTopic
+	for _, s := range specs {
+		rt := kmsg.NewCreateTopicsRequestTopic()
+		rt.Topic = s.Topic
+		rt.NumPartitions = s.Partitions
+		rt.ReplicationFactor = s.ReplicationFactor
+		if rt.NumPartitions <= 0 || len(s.ReplicaAssignment) > 0 {
+			rt.NumPartitions = -1
+		}
+		if rt.ReplicationFactor <= 0 || len(s.ReplicaAssignment) > 0 {
+			rt.ReplicationFactor = -1
+		}
+		for p, replicas := range s.ReplicaAssignment {
+			ra := kmsg.NewCreateTopicsRequestTopicReplicaAssignment()
+			ra.Partition = p
+			ra.Replicas = replicas
+			rt.ReplicaAssignment = append(rt.ReplicaAssignment, ra)
+		}
+		sort.Slice(rt.ReplicaAssignment, func(i, j int) bool {
+			return rt.ReplicaAssignment[i].Partition < rt.ReplicaAssignment[j].Partition
+		})
+		for k, v := range s.Configs {
+			rc := kmsg.NewCreateTopicsRequestTopicConfig()
+			rc.Name = k
+			rc.Value = kmsg.StringPtr(v)
+			rt.Configs = append(rt.Configs, rc)
+		}
+		rts = append(rts, rt)
+	}
+	return cl.issueCreateTopics(ctx, dry, rts)
+}
+
+func createTopicsReq(dry bool, rts []kmsg.CreateTopicsRequestTopic) *kmsg.CreateTopicsRequest {
+	req := kmsg.NewPtrCreateTopicsRequest()
+	req.ValidateOnly = dry
+	req.Topics = rts
+	return req
+}
+
+func (cl *Client) issueCreateTopics(ctx context.Context, dry bool, rts []kmsg.CreateTopicsRequestTopic) ([]CreateTopicResponse, error) {
+	req := createTopicsReq(dry, rts)
 	resp, err := req.RequestWith(ctx, cl.cl)
 	if err != nil {
 		return nil, err
@@ -98,9 +209,11 @@ func (cl *Client) createTopics(ctx context.Context, dry bool, p int32, rf int16,
 	var rs []CreateTopicResponse
 	for _, t := range resp.Topics {
 		rs = append(rs, CreateTopicResponse{
-			Topic: t.Topic,
-			ID:    t.TopicID,
-			Err:   kerr.ErrorForCode(t.ErrorCode),
+			Topic:             t.Topic,
+			ID:                t.TopicID,
+			Partitions:        t.NumPartitions,
+			ReplicationFactor: t.ReplicationFactor,
+			Err:               kerr.ErrorForCode(t.ErrorCode),
 		})
 	}
 	return rs, nil
@@ -252,6 +365,20 @@ func (cl *Client) ValidateCreatePartitions(ctx context.Context, add int, topics
 	return cl.createPartitions(ctx, true, add, topics)
 }
 
+// createPartitionsReq returns a request to add "add" partitions to every
+// topic, using the current partition counts in td.
+func createPartitionsReq(dry bool, add int, topics []string, td TopicDetails) *kmsg.CreatePartitionsRequest {
+	req := kmsg.NewPtrCreatePartitionsRequest()
+	req.ValidateOnly = dry
+	for _, t := range topics {
+		rt := kmsg.NewCreatePartitionsRequestTopic()
+		rt.Topic = t
+		rt.Count = int32(len(td[t].Partitions) + add)
+		req.Topics = append(req.Topics, rt)
+	}
+	return req
+}
+
 func (cl *Client) createPartitions(ctx context.Context, dry bool, add int, topics []string) ([]CreatePartitionsResponse, error) {
 	if len(topics) == 0 {
 		return nil, nil
@@ -262,14 +389,7 @@ func (cl *Client) createPartitions(ctx context.Context, dry bool, add int, topic
 		return nil, err
 	}
 
-	req := kmsg.NewCreatePartitionsRequest()
-	req.ValidateOnly = dry
-	for _, t := range topics {
-		rt := kmsg.NewCreatePartitionsRequestTopic()
-		rt.Topic = t
-		rt.Count = int32(len(td[t].Partitions) + add)
-	}
-
+	req := createPartitionsReq(dry, add, topics, td)
 	resp, err := req.RequestWith(ctx, cl.cl)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,41 @@
+package kadm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCreateTopicsReqValidateOnly(t *testing.T) {
+	rts := []kmsg.CreateTopicsRequestTopic{kmsg.NewCreateTopicsRequestTopic()}
+	for _, dry := range []bool{false, true} {
+		req := createTopicsReq(dry, rts)
+		if req.ValidateOnly != dry {
+			t.Errorf("dry %v: got validate only %v", dry, req.ValidateOnly)
+		}
+		if len(req.Topics) != 1 {
+			t.Errorf("dry %v: got %d topics != exp 1", dry, len(req.Topics))
+		}
+	}
+}
+
+func TestCreatePartitionsReq(t *testing.T) {
+	td := TopicDetails{
+		"foo": {Topic: "foo", Partitions: PartitionDetails{0: {}, 1: {}}},
+		"bar": {Topic: "bar", Partitions: PartitionDetails{0: {}}},
+	}
+	for _, dry := range []bool{false, true} {
+		req := createPartitionsReq(dry, 3, []string{"foo", "bar"}, td)
+		if req.ValidateOnly != dry {
+			t.Errorf("dry %v: got validate only %v", dry, req.ValidateOnly)
+		}
+		got := make(map[string]int32)
+		for _, rt := range req.Topics {
+			got[rt.Topic] = rt.Count
+		}
+		if exp := map[string]int32{"foo": 5, "bar": 4}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("dry %v: got counts %v != exp %v", dry, got, exp)
+		}
+	}
+}
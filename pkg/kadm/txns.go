@@ -0,0 +1,651 @@
+package kadm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// DescribedProducer contains the state of a transactional producer's last
+// produce.
+type DescribedProducer struct {
+	Leader                int32  // Leader is the leader broker for this topic / partition.
+	Topic                 string // Topic is the topic being produced to.
+	Partition             int32  // Partition is the partition being produced to.
+	ProducerID            int64  // ProducerID is the producer ID that produced.
+	ProducerEpoch         int16  // ProducerEpoch is the epoch that produced.
+	LastSequence          int32  // LastSequence is the last sequence number the producer produced.
+	LastTimestamp         int64  // LastTimestamp is the last time this producer produced.
+	CoordinatorEpoch      int32  // CoordinatorEpoch is the epoch of the transactional coordinator for the last produce.
+	CurrentTxnStartOffset int64  // CurrentTxnStartOffset is the first offset in the transaction, or -1 if there is no open transaction.
+}
+
+// DescribedProducersPartition is a partition whose producers were described.
+type DescribedProducersPartition struct {
+	Leader          int32               // Leader is the leader broker for this topic / partition.
+	Topic           string              // Topic is the topic whose producers were described.
+	Partition       int32               // Partition is the partition whose producers were described.
+	ActiveProducers []DescribedProducer // ActiveProducers are producers actively transactionally producing to this partition.
+	Err             error               // Err is non-nil if describing this partition failed.
+}
+
+// DescribedProducersTopics contains per-topic, per-partition described
+// producers.
+type DescribedProducersTopics map[string]map[int32]DescribedProducersPartition
+
+// Sorted returns the described partitions sorted by topic and partition.
+func (ds DescribedProducersTopics) Sorted() []DescribedProducersPartition {
+	var all []DescribedProducersPartition
+	ds.EachPartition(func(p DescribedProducersPartition) {
+		all = append(all, p)
+	})
+	sort.Slice(all, func(i, j int) bool {
+		l, r := all[i], all[j]
+		return l.Topic < r.Topic || l.Topic == r.Topic && l.Partition < r.Partition
+	})
+	return all
+}
+
+// EachPartition calls fn for every partition.
+func (ds DescribedProducersTopics) EachPartition(fn func(DescribedProducersPartition)) {
+	for _, ps := range ds {
+		for _, p := range ps {
+			fn(p)
+		}
+	}
+}
+
+// EachProducer calls fn for every active producer in every partition.
+func (ds DescribedProducersTopics) EachProducer(fn func(DescribedProducer)) {
+	ds.EachPartition(func(p DescribedProducersPartition) {
+		for _, d := range p.ActiveProducers {
+			fn(d)
+		}
+	})
+}
+
+// DescribeProducers describes all producers that are transactionally
+// producing to the requested topic set. This request can be used to detect
+// hanging transactions or other transaction related problems.
+//
+// This may return *ShardErrors or an *AuthError.
+func (cl *Client) DescribeProducers(ctx context.Context, s TopicsSet) (DescribedProducersTopics, error) {
+	req := kmsg.NewPtrDescribeProducersRequest()
+	for t, ps := range s {
+		rt := kmsg.NewDescribeProducersRequestTopic()
+		rt.Topic = t
+		for p := range ps {
+			rt.Partitions = append(rt.Partitions, p)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+	shards := cl.cl.RequestSharded(ctx, req)
+	dts := make(DescribedProducersTopics)
+	return dts, shardErrEachBroker(req, shards, func(b BrokerDetail, kr kmsg.Response) error {
+		resp := kr.(*kmsg.DescribeProducersResponse)
+		for _, rt := range resp.Topics {
+			dps := dts[rt.Topic]
+			if dps == nil {
+				dps = make(map[int32]DescribedProducersPartition)
+				dts[rt.Topic] = dps
+			}
+			for _, rp := range rt.Partitions {
+				if err := maybeAuthErr(rp.ErrorCode); err != nil {
+					return err
+				}
+				dp := DescribedProducersPartition{
+					Leader:    b.NodeID,
+					Topic:     rt.Topic,
+					Partition: rp.Partition,
+					Err:       kerr.ErrorForCode(rp.ErrorCode),
+				}
+				for _, rd := range rp.ActiveProducers {
+					dp.ActiveProducers = append(dp.ActiveProducers, DescribedProducer{
+						Leader:                b.NodeID,
+						Topic:                 rt.Topic,
+						Partition:             rp.Partition,
+						ProducerID:            rd.ProducerID,
+						ProducerEpoch:         int16(rd.ProducerEpoch),
+						LastSequence:          rd.LastSequence,
+						LastTimestamp:         rd.LastTimestamp,
+						CoordinatorEpoch:      rd.CoordinatorEpoch,
+						CurrentTxnStartOffset: rd.CurrentTxnStartOffset,
+					})
+				}
+				dps[rp.Partition] = dp
+			}
+		}
+		return nil
+	})
+}
+
+// ListedTransaction contains data from a list transactions response for a
+// single transactional ID.
+type ListedTransaction struct {
+	Coordinator int32  // Coordinator the coordinator broker for this transactional ID.
+	TxnID       string // TxnID is the name of this transactional ID.
+	ProducerID  int64  // ProducerID is the producer ID for this transaction.
+	State       string // State is the state this transaction is in (Empty, Ongoing, PrepareCommit, PrepareAbort, CompleteCommit, CompleteAbort, Dead, PrepareEpochFence).
+}
+
+// ListedTransactions contains information from a list transactions response.
+type ListedTransactions map[string]ListedTransaction
+
+// Sorted returns all transactions sorted by transactional ID.
+func (ls ListedTransactions) Sorted() []ListedTransaction {
+	s := make([]ListedTransaction, 0, len(ls))
+	for _, l := range ls {
+		s = append(s, l)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].TxnID < s[j].TxnID })
+	return s
+}
+
+// TransactionalIDs returns a sorted list of all transactional IDs.
+func (ls ListedTransactions) TransactionalIDs() []string {
+	all := make([]string, 0, len(ls))
+	for t := range ls {
+		all = append(all, t)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// ListTransactions returns all transactions and their states in the cluster.
+// Filter states can be used to return transactions only in the requested
+// states, and producer IDs can be used to return transactions only for the
+// requested producer IDs. By default, this returns all transactions.
+//
+// This request requires Kafka 3.0+. This may return *ShardErrors or an
+// *AuthError.
+func (cl *Client) ListTransactions(ctx context.Context, producerIDs []int64, filterStates []string) (ListedTransactions, error) {
+	req := kmsg.NewPtrListTransactionsRequest()
+	req.ProducerIDFilters = producerIDs
+	req.StateFilters = filterStates
+	shards := cl.cl.RequestSharded(ctx, req)
+	list := make(ListedTransactions)
+	return list, shardErrEachBroker(req, shards, func(b BrokerDetail, kr kmsg.Response) error {
+		resp := kr.(*kmsg.ListTransactionsResponse)
+		if err := maybeAuthErr(resp.ErrorCode); err != nil {
+			return err
+		}
+		if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+			return err
+		}
+		for _, t := range resp.TransactionStates {
+			list[t.TransactionalID] = ListedTransaction{
+				Coordinator: b.NodeID,
+				TxnID:       t.TransactionalID,
+				ProducerID:  t.ProducerID,
+				State:       t.TransactionState,
+			}
+		}
+		return nil
+	})
+}
+
+// DescribedTransaction contains data from a describe transactions response for
+// a single transactional ID.
+type DescribedTransaction struct {
+	Coordinator    int32  // Coordinator is the coordinator broker for this transactional ID.
+	TxnID          string // TxnID is the name of this transactional ID.
+	State          string // State is the state this transaction is in (Empty, Ongoing, PrepareCommit, PrepareAbort, CompleteCommit, CompleteAbort, Dead, PrepareEpochFence).
+	TimeoutMillis  int32  // TimeoutMillis is the timeout of this transaction in milliseconds.
+	StartTimestamp int64  // StartTimestamp is millisecond when this transaction started.
+	ProducerID     int64  // ProducerID is the ID in use by the transactional ID.
+	ProducerEpoch  int16  // ProducerEpoch is the epoch associated with the producer ID.
+
+	// Topics is the set of partitions in the transaction, if active. When
+	// preparing to commit or abort, this includes only partitions which do
+	// not have markers.
+	Topics TopicsSet
+
+	Err error // Err is non-nil if the transaction could not be described.
+}
+
+// DescribedTransactions contains information from a describe transactions
+// response.
+type DescribedTransactions map[string]DescribedTransaction
+
+// Sorted returns all described transactions sorted by transactional ID.
+func (ds DescribedTransactions) Sorted() []DescribedTransaction {
+	s := make([]DescribedTransaction, 0, len(ds))
+	for _, d := range ds {
+		s = append(s, d)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].TxnID < s[j].TxnID })
+	return s
+}
+
+// TransactionalIDs returns a sorted list of all transactional IDs.
+func (ds DescribedTransactions) TransactionalIDs() []string {
+	all := make([]string, 0, len(ds))
+	for t := range ds {
+		all = append(all, t)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// EachError calls fn for every described transaction that has a non-nil
+// error.
+func (ds DescribedTransactions) EachError(fn func(DescribedTransaction)) {
+	for _, d := range ds {
+		if d.Err != nil {
+			fn(d)
+		}
+	}
+}
+
+// DescribeTransactions describes either all transactional IDs specified, or
+// all transactional IDs in the cluster if none are specified.
+//
+// This request requires Kafka 3.0+. This may return *ShardErrors or an
+// *AuthError.
+//
+// If no transactional IDs are specified and this method first lists
+// transactional IDs, and listing IDs returns a *ShardErrors, this function
+// describes all successfully listed IDs and appends the list shard errors to
+// any returned describe shard errors.
+func (cl *Client) DescribeTransactions(ctx context.Context, txnIDs ...string) (DescribedTransactions, error) {
+	var seList *ShardErrors
+	if len(txnIDs) == 0 {
+		listed, err := cl.ListTransactions(ctx, nil, nil)
+		switch {
+		case err == nil:
+		case errors.As(err, &seList):
+		default:
+			return nil, err
+		}
+		txnIDs = listed.TransactionalIDs()
+		if len(txnIDs) == 0 {
+			return nil, err
+		}
+	}
+
+	req := kmsg.NewPtrDescribeTransactionsRequest()
+	req.TransactionalIDs = txnIDs
+
+	shards := cl.cl.RequestSharded(ctx, req)
+	described := make(DescribedTransactions)
+	err := shardErrEachBroker(req, shards, func(b BrokerDetail, kr kmsg.Response) error {
+		resp := kr.(*kmsg.DescribeTransactionsResponse)
+		for _, rt := range resp.TransactionStates {
+			if err := maybeAuthErr(rt.ErrorCode); err != nil {
+				return err
+			}
+			t := DescribedTransaction{
+				Coordinator:    b.NodeID,
+				TxnID:          rt.TransactionalID,
+				State:          rt.State,
+				TimeoutMillis:  rt.TimeoutMillis,
+				StartTimestamp: rt.StartTimestamp,
+				ProducerID:     rt.ProducerID,
+				ProducerEpoch:  rt.ProducerEpoch,
+				Err:            kerr.ErrorForCode(rt.ErrorCode),
+			}
+			for _, rtt := range rt.Topics {
+				t.Topics.Add(rtt.Topic, rtt.Partitions...)
+			}
+			described[t.TxnID] = t
+		}
+		return nil
+	})
+
+	var seDesc *ShardErrors
+	switch {
+	case err == nil:
+		return described, seList.into()
+	case errors.As(err, &seDesc):
+		if seList != nil {
+			seDesc.Errs = append(seList.Errs, seDesc.Errs...)
+		}
+		return described, seDesc.into()
+	default:
+		return nil, err
+	}
+}
+
+// HangingTransaction is an open transaction on a partition that the
+// transaction's coordinator is no longer tracking. A hanging transaction
+// prevents the last stable offset of the partition from advancing, which
+// stalls consumers that read committed records.
+type HangingTransaction struct {
+	DescribedProducer // DescribedProducer is the producer with the open transaction on the partition.
+
+	// TxnID is the transactional ID using the producer ID, if the
+	// producer ID was found when listing transactions.
+	TxnID string
+
+	// Txn is the coordinator's view of the transaction, if the producer
+	// ID was found when listing transactions. A transaction is hanging if
+	// the coordinator does not know of the producer ID at all, if the
+	// coordinator's transaction uses a different producer epoch, if the
+	// coordinator's transaction does not include the partition, or if the
+	// coordinator's transaction is in any state other than Ongoing,
+	// PrepareCommit, or PrepareAbort.
+	//
+	// PrepareCommit and PrepareAbort are not hanging: the coordinator has
+	// decided the outcome and is still writing markers to the partitions
+	// in the transaction, and it retries until the markers are written.
+	Txn *DescribedTransaction
+}
+
+// FindHangingTransactions returns open transactions on the given partitions
+// whose producers last produced more than olderThan ago and that the
+// transaction coordinator is no longer tracking for the partition. This is
+// similar to Kafka's kafka-transactions.sh find-hanging command.
+//
+// A reasonable olderThan is the broker's transaction.max.timeout.ms (15
+// minutes by default): transactions older than the max timeout should have
+// been aborted by the coordinator.
+//
+// This requires Kafka 3.0+. This may return *ShardErrors or an *AuthError.
+// Partitions that fail to be described are skipped, as are producers whose
+// transaction state cannot be determined because listing or describing
+// transactions partially failed; all shard errors are merged into the
+// returned *ShardErrors. If the returned error is *ShardErrors, the returned
+// hanging transactions are still valid.
+func (cl *Client) FindHangingTransactions(ctx context.Context, s TopicsSet, olderThan time.Duration) ([]HangingTransaction, error) {
+	var se *ShardErrors
+	merge := func(err error) error {
+		var next *ShardErrors
+		switch {
+		case err == nil:
+		case errors.As(err, &next):
+			if se == nil {
+				se = next
+			} else {
+				se.Errs = append(se.Errs, next.Errs...)
+			}
+		default:
+			return err
+		}
+		return nil
+	}
+
+	described, err := cl.DescribeProducers(ctx, s)
+	if err = merge(err); err != nil {
+		return nil, err
+	}
+
+	threshold := time.Now().Add(-olderThan).UnixNano() / 1e6
+	var (
+		candidates []DescribedProducer
+		pids       []int64
+		seenPIDs   = make(map[int64]bool)
+	)
+	described.EachProducer(func(d DescribedProducer) {
+		if d.CurrentTxnStartOffset < 0 || d.LastTimestamp > threshold {
+			return
+		}
+		candidates = append(candidates, d)
+		if !seenPIDs[d.ProducerID] {
+			seenPIDs[d.ProducerID] = true
+			pids = append(pids, d.ProducerID)
+		}
+	})
+	if len(candidates) == 0 {
+		return nil, se.into()
+	}
+
+	// If listing fails on any broker, a producer ID missing from the
+	// listing may be known by the broker that failed, so we cannot
+	// report it as unknown to its coordinator.
+	listed, err := cl.ListTransactions(ctx, pids, nil)
+	listFailed := err != nil
+	if err = merge(err); err != nil {
+		return nil, err
+	}
+	byPID := make(map[int64]string)
+	for _, l := range listed {
+		byPID[l.ProducerID] = l.TxnID
+	}
+	var txns DescribedTransactions
+	if len(listed) > 0 {
+		txns, err = cl.DescribeTransactions(ctx, listed.TransactionalIDs()...)
+		if err = merge(err); err != nil {
+			return nil, err
+		}
+	}
+
+	var hanging []HangingTransaction
+	for _, d := range candidates {
+		h := HangingTransaction{DescribedProducer: d}
+		txnID, ok := byPID[d.ProducerID]
+		if !ok {
+			if !listFailed {
+				hanging = append(hanging, h)
+			}
+			continue
+		}
+		h.TxnID = txnID
+		txn, ok := txns[txnID]
+		if !ok || txn.Err != nil {
+			continue // we cannot determine the state; do not report a false positive
+		}
+		h.Txn = &txn
+		if !txnTracks(txn, d) {
+			hanging = append(hanging, h)
+		}
+	}
+	sort.Slice(hanging, func(i, j int) bool {
+		l, r := hanging[i], hanging[j]
+		return l.Topic < r.Topic ||
+			l.Topic == r.Topic && l.Partition < r.Partition ||
+			l.Topic == r.Topic && l.Partition == r.Partition && l.ProducerID < r.ProducerID
+	})
+	return hanging, se.into()
+}
+
+// txnTracks returns whether the coordinator's transaction is still tracking
+// the producer's open transaction on the producer's partition; see the
+// HangingTransaction.Txn docs.
+func txnTracks(txn DescribedTransaction, d DescribedProducer) bool {
+	switch txn.State {
+	case "Ongoing", "PrepareCommit", "PrepareAbort":
+	default:
+		return false
+	}
+	_, inTxn := txn.Topics[d.Topic][d.Partition]
+	return inTxn &&
+		txn.ProducerID == d.ProducerID &&
+		txn.ProducerEpoch == d.ProducerEpoch
+}
+
+// AbortTransactionReq is the input to AbortTransaction: a partition and the
+// producer whose open transaction on the partition should be aborted.
+//
+// Zero is a valid producer and coordinator epoch, so the zero value of this
+// struct aborts with epoch 0 for both. Use NewAbortTransactionReq to default
+// the epochs to -1 and have AbortTransaction use the described epochs, or
+// DescribedProducer.AbortReq to use a producer's epochs directly.
+type AbortTransactionReq struct {
+	Topic         string // Topic is the topic of the partition with the open transaction.
+	Partition     int32  // Partition is the partition with the open transaction.
+	ProducerID    int64  // ProducerID is the producer ID of the open transaction.
+	ProducerEpoch int16  // ProducerEpoch is the producer epoch of the open transaction, or -1 to use the described epoch.
+
+	// CoordinatorEpoch is the epoch of the transaction coordinator that
+	// the marker is written for, or -1 to use the coordinator epoch of
+	// the producer's last produce as described by DescribeProducers.
+	CoordinatorEpoch int32
+}
+
+// NewAbortTransactionReq returns an AbortTransactionReq for the producer ID's
+// open transaction on the given partition, with the producer epoch and
+// coordinator epoch defaulted to -1.
+func NewAbortTransactionReq(topic string, partition int32, producerID int64) AbortTransactionReq {
+	return AbortTransactionReq{
+		Topic:            topic,
+		Partition:        partition,
+		ProducerID:       producerID,
+		ProducerEpoch:    -1,
+		CoordinatorEpoch: -1,
+	}
+}
+
+// AbortReq returns an AbortTransactionReq for this described producer's open
+// transaction.
+func (d DescribedProducer) AbortReq() AbortTransactionReq {
+	return AbortTransactionReq{
+		Topic:            d.Topic,
+		Partition:        d.Partition,
+		ProducerID:       d.ProducerID,
+		ProducerEpoch:    d.ProducerEpoch,
+		CoordinatorEpoch: d.CoordinatorEpoch,
+	}
+}
+
+// AbortedTransaction is the result of aborting a transaction on a partition.
+type AbortedTransaction struct {
+	Leader        int32  // Leader is the partition leader the abort marker was written to.
+	Topic         string // Topic is the topic of the aborted transaction.
+	Partition     int32  // Partition is the partition of the aborted transaction.
+	ProducerID    int64  // ProducerID is the producer ID of the aborted transaction.
+	ProducerEpoch int16  // ProducerEpoch is the producer epoch the abort marker was written with.
+	StartOffset   int64  // StartOffset is the first offset of the aborted transaction.
+
+	PrevLSO     int64 // PrevLSO is the last stable offset of the partition before aborting.
+	LSO         int64 // LSO is the last stable offset of the partition after aborting.
+	LSOAdvanced bool  // LSOAdvanced is whether the last stable offset advanced after aborting.
+}
+
+// AbortTransaction aborts an open transaction on a single partition by
+// writing an abort marker directly to the partition leader, similar to
+// Kafka's kafka-transactions.sh abort command. This is meant for clearing
+// hanging transactions (see FindHangingTransactions) whose producer and
+// coordinator will never complete the transaction; aborting a transaction
+// that is still in use by a live producer will cause that producer to fail.
+//
+// The producer is first looked up with DescribeProducers to validate that the
+// producer has an open transaction on the partition and to find the partition
+// leader. The last stable offset is listed before and after writing the
+// marker so that you can see whether the abort unblocked the partition; the
+// LSO may not advance if other transactions are still open on the partition.
+//
+// Writing transaction markers requires CLUSTER_ACTION on the cluster. Unlike
+// most functions in this package, this returns any error, including
+// authorization errors and the error for writing the marker.
+func (cl *Client) AbortTransaction(ctx context.Context, req AbortTransactionReq) (AbortedTransaction, error) {
+	a := AbortedTransaction{
+		Topic:         req.Topic,
+		Partition:     req.Partition,
+		ProducerID:    req.ProducerID,
+		ProducerEpoch: req.ProducerEpoch,
+	}
+
+	var s TopicsSet
+	s.Add(req.Topic, req.Partition)
+	described, err := cl.DescribeProducers(ctx, s)
+	if err != nil {
+		return a, err
+	}
+	dp, ok := described[req.Topic][req.Partition]
+	if !ok {
+		return a, fmt.Errorf("partition %s[%d] was not described", req.Topic, req.Partition)
+	}
+	if dp.Err != nil {
+		return a, dp.Err
+	}
+	var producer *DescribedProducer
+	for i := range dp.ActiveProducers {
+		if dp.ActiveProducers[i].ProducerID == req.ProducerID {
+			producer = &dp.ActiveProducers[i]
+			break
+		}
+	}
+	if producer == nil || producer.CurrentTxnStartOffset < 0 {
+		return a, fmt.Errorf("producer ID %d has no open transaction on %s[%d]", req.ProducerID, req.Topic, req.Partition)
+	}
+	if req.ProducerEpoch < 0 {
+		req.ProducerEpoch = producer.ProducerEpoch
+	}
+	if req.CoordinatorEpoch < 0 {
+		req.CoordinatorEpoch = producer.CoordinatorEpoch
+	}
+	a.Leader = dp.Leader
+	a.ProducerEpoch = req.ProducerEpoch
+	a.StartOffset = producer.CurrentTxnStartOffset
+
+	if a.PrevLSO, err = cl.partitionLSO(ctx, dp.Leader, req.Topic, req.Partition); err != nil {
+		return a, err
+	}
+
+	wreq := kmsg.NewPtrWriteTxnMarkersRequest()
+	marker := kmsg.NewWriteTxnMarkersRequestMarker()
+	marker.ProducerID = req.ProducerID
+	marker.ProducerEpoch = req.ProducerEpoch
+	marker.Committed = false
+	marker.CoordinatorEpoch = req.CoordinatorEpoch
+	mt := kmsg.NewWriteTxnMarkersRequestMarkerTopic()
+	mt.Topic = req.Topic
+	mt.Partitions = []int32{req.Partition}
+	marker.Topics = append(marker.Topics, mt)
+	wreq.Markers = append(wreq.Markers, marker)
+
+	kresp, err := cl.cl.Broker(int(dp.Leader)).Request(ctx, wreq)
+	if err != nil {
+		return a, err
+	}
+	wresp := kresp.(*kmsg.WriteTxnMarkersResponse)
+	var found bool
+	for _, rm := range wresp.Markers {
+		for _, rt := range rm.Topics {
+			for _, rp := range rt.Partitions {
+				if rm.ProducerID != req.ProducerID || rt.Topic != req.Topic || rp.Partition != req.Partition {
+					continue
+				}
+				found = true
+				if err := maybeAuthErr(rp.ErrorCode); err != nil {
+					return a, err
+				}
+				if err := kerr.ErrorForCode(rp.ErrorCode); err != nil {
+					return a, err
+				}
+			}
+		}
+	}
+	if !found {
+		return a, fmt.Errorf("write txn markers response did not include %s[%d]", req.Topic, req.Partition)
+	}
+
+	if a.LSO, err = cl.partitionLSO(ctx, dp.Leader, req.Topic, req.Partition); err != nil {
+		return a, err
+	}
+	a.LSOAdvanced = a.LSO > a.PrevLSO
+	return a, nil
+}
+
+// partitionLSO returns the last stable offset for a single partition from
+// the given leader.
+func (cl *Client) partitionLSO(ctx context.Context, leader int32, topic string, partition int32) (int64, error) {
+	req := kmsg.NewPtrListOffsetsRequest()
+	req.IsolationLevel = 1
+	rt := kmsg.NewListOffsetsRequestTopic()
+	rt.Topic = topic
+	rp := kmsg.NewListOffsetsRequestTopicPartition()
+	rp.Partition = partition
+	rp.Timestamp = -1
+	rt.Partitions = append(rt.Partitions, rp)
+	req.Topics = append(req.Topics, rt)
+
+	kresp, err := cl.cl.Broker(int(leader)).Request(ctx, req)
+	if err != nil {
+		return -1, err
+	}
+	resp := kresp.(*kmsg.ListOffsetsResponse)
+	if len(resp.Topics) != 1 || len(resp.Topics[0].Partitions) != 1 {
+		return -1, fmt.Errorf("list offsets response for %s[%d] has an unexpected shape", topic, partition)
+	}
+	p := resp.Topics[0].Partitions[0]
+	if err := maybeAuthErr(p.ErrorCode); err != nil {
+		return -1, err
+	}
+	return p.Offset, kerr.ErrorForCode(p.ErrorCode)
+}
@@ -0,0 +1,37 @@
+package kadm
+
+import "testing"
+
+func TestTxnTracks(t *testing.T) {
+	d := DescribedProducer{
+		Topic:         "t",
+		Partition:     1,
+		ProducerID:    7,
+		ProducerEpoch: 2,
+	}
+	var in, notIn TopicsSet
+	in.Add("t", 1)
+	notIn.Add("t", 0)
+
+	for _, test := range []struct {
+		name  string
+		txn   DescribedTransaction
+		track bool
+	}{
+		{"ongoing", DescribedTransaction{State: "Ongoing", ProducerID: 7, ProducerEpoch: 2, Topics: in}, true},
+		{"prepare commit", DescribedTransaction{State: "PrepareCommit", ProducerID: 7, ProducerEpoch: 2, Topics: in}, true},
+		{"prepare abort", DescribedTransaction{State: "PrepareAbort", ProducerID: 7, ProducerEpoch: 2, Topics: in}, true},
+		{"complete commit", DescribedTransaction{State: "CompleteCommit", ProducerID: 7, ProducerEpoch: 2, Topics: in}, false},
+		{"empty", DescribedTransaction{State: "Empty", ProducerID: 7, ProducerEpoch: 2}, false},
+		{"prepare epoch fence", DescribedTransaction{State: "PrepareEpochFence", ProducerID: 7, ProducerEpoch: 2, Topics: in}, false},
+		{"other producer", DescribedTransaction{State: "Ongoing", ProducerID: 8, ProducerEpoch: 2, Topics: in}, false},
+		{"other epoch", DescribedTransaction{State: "Ongoing", ProducerID: 7, ProducerEpoch: 3, Topics: in}, false},
+		{"partition not in txn", DescribedTransaction{State: "Ongoing", ProducerID: 7, ProducerEpoch: 2, Topics: notIn}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := txnTracks(test.txn, d); got != test.track {
+				t.Errorf("got tracking %v != exp %v", got, test.track)
+			}
+		})
+	}
+}
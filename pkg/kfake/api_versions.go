@@ -0,0 +1,61 @@
+package kfake
+
+import (
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// apiVersions contains the min and max version of every request key that the
+// cluster supports.
+var apiVersions = map[int16][2]int16{
+	0:  {3, 9},  // Produce: v3+ uses record batches
+	1:  {4, 12}, // Fetch: v4+ uses record batches, v13+ uses topic IDs
+	2:  {1, 7},  // ListOffsets: v0 uses old style offsets
+	3:  {1, 12}, // Metadata
+	8:  {0, 8},  // OffsetCommit
+	9:  {0, 8},  // OffsetFetch
+	10: {0, 4},  // FindCoordinator
+	11: {0, 7},  // JoinGroup
+	12: {0, 4},  // Heartbeat
+	13: {0, 4},  // LeaveGroup
+	14: {0, 5},  // SyncGroup
+	15: {0, 5},  // DescribeGroups
+	16: {0, 4},  // ListGroups
+	18: {0, 3},  // ApiVersions
+	19: {0, 7},  // CreateTopics
+	22: {0, 4},  // InitProducerID
+	23: {2, 4},  // OffsetForLeaderEpoch: v2+ uses current leader epoch
+	24: {0, 3},  // AddPartitionsToTxn
+	25: {0, 3},  // AddOffsetsToTxn
+	26: {0, 3},  // EndTxn
+	28: {0, 3},  // TxnOffsetCommit
+}
+
+func supportedVersions(key int16) (min, max int16, ok bool) {
+	v, ok := apiVersions[key]
+	return v[0], v[1], ok
+}
+
+func (c *Cluster) handleApiVersions(req *kmsg.ApiVersionsRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.ApiVersionsResponse)
+
+	// As of Kafka 2.4, if a client uses a version that is too new, the
+	// broker replies with a v0 response containing UNSUPPORTED_VERSION
+	// and all of the versions it does support.
+	if max := apiVersions[18][1]; resp.Version > max {
+		resp.Version = 0
+		resp.ErrorCode = kerr.UnsupportedVersion.Code
+	}
+
+	for key, v := range apiVersions {
+		resp.ApiKeys = append(resp.ApiKeys, kmsg.ApiVersionsResponseApiKey{
+			ApiKey:     key,
+			MinVersion: v[0],
+			MaxVersion: v[1],
+		})
+	}
+	sort.Slice(resp.ApiKeys, func(i, j int) bool { return resp.ApiKeys[i].ApiKey < resp.ApiKeys[j].ApiKey })
+	return resp, nil
+}
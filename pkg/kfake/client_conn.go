@@ -0,0 +1,179 @@
+package kfake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// clientConn is a single connection from a client to a broker.
+//
+// Requests are read and parsed in one goroutine and sent to the cluster's run
+// loop. Responses can be generated out of order (a JoinGroup waits while a
+// later Metadata on the same connection is answered immediately), but Kafka
+// replies in order per connection. Thus, every request is given a sequence
+// number and the write goroutine buffers responses until it can write them in
+// order.
+type clientConn struct {
+	c      *Cluster
+	b      *broker
+	conn   net.Conn
+	respCh chan clientResp
+	done   chan struct{} // closed when the read goroutine exits
+}
+
+type clientReq struct {
+	cc   *clientConn
+	kreq kmsg.Request
+	at   time.Time
+	cid  string
+	corr int32
+	seq  uint32
+	ctl  *reqControl // non-nil if a control function delayed or rewrote the response
+}
+
+type clientResp struct {
+	kresp kmsg.Response
+	corr  int32
+	err   error
+	seq   uint32
+}
+
+func (cc *clientConn) read() {
+	defer func() {
+		cc.conn.Close()
+		close(cc.done)
+		cc.c.untrackConn(cc)
+	}()
+
+	var (
+		sizeBuf [4]byte
+		seq     uint32
+	)
+	for {
+		if _, err := io.ReadFull(cc.conn, sizeBuf[:]); err != nil {
+			return
+		}
+		size := int32(binary.BigEndian.Uint32(sizeBuf[:]))
+		if size < 0 {
+			return
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(cc.conn, body); err != nil {
+			return
+		}
+
+		kreq, cid, corr, err := parseReq(body)
+		if err != nil {
+			return
+		}
+
+		select {
+		case cc.c.reqCh <- clientReq{cc: cc, kreq: kreq, at: time.Now(), cid: cid, corr: corr, seq: seq}:
+			seq++
+		case <-cc.c.die:
+			return
+		}
+	}
+}
+
+func (cc *clientConn) write() {
+	defer cc.conn.Close()
+
+	var (
+		seq     uint32
+		oooresp = make(map[uint32]clientResp)
+		buf     []byte
+	)
+	for {
+		resp, ok := oooresp[seq]
+		if !ok {
+			select {
+			case resp = <-cc.respCh:
+				if resp.seq != seq {
+					oooresp[resp.seq] = resp
+					continue
+				}
+			case <-cc.done:
+				return
+			case <-cc.c.die:
+				return
+			}
+		} else {
+			delete(oooresp, seq)
+		}
+		seq++
+
+		if resp.err != nil {
+			return
+		}
+		if resp.kresp == nil {
+			continue // no response, i.e. produce with acks=0
+		}
+
+		buf = append(buf[:0], 0, 0, 0, 0) // reserve length
+		buf = kbin.AppendInt32(buf, resp.corr)
+
+		// ApiVersions responses never use the flexible response
+		// header; see KIP-511.
+		if resp.kresp.IsFlexible() && resp.kresp.Key() != 18 {
+			buf = append(buf, 0) // no tagged header fields
+		}
+		buf = resp.kresp.AppendTo(buf)
+		kbin.AppendInt32(buf[:0], int32(len(buf)-4))
+
+		if _, err := cc.conn.Write(buf); err != nil {
+			return
+		}
+	}
+}
+
+// parseReq parses a full request (minus its size prefix), returning the
+// request, the client ID, and the correlation ID.
+func parseReq(body []byte) (kreq kmsg.Request, cid string, corr int32, err error) {
+	b := kbin.Reader{Src: body}
+	key := b.Int16()
+	version := b.Int16()
+	corr = b.Int32()
+	if pcid := b.NullableString(); pcid != nil {
+		cid = *pcid
+	}
+	if err := b.Complete(); err != nil {
+		return nil, cid, corr, err
+	}
+
+	kreq = kmsg.RequestForKey(key)
+	if kreq == nil {
+		return nil, cid, corr, fmt.Errorf("unknown request key %d", key)
+	}
+
+	// If a client issues an ApiVersions request that is newer than what
+	// we support, we cannot parse it. We reply with an UNSUPPORTED_VERSION
+	// error and v0 response, after which the client should retry with v0
+	// (or with the max version we reply with).
+	min, max, ok := supportedVersions(key)
+	if key == 18 && version > max {
+		kreq.SetVersion(version)
+		return kreq, cid, corr, nil
+	}
+	if !ok || version < min || version > max {
+		return nil, cid, corr, fmt.Errorf("unsupported version %d for key %d", version, key)
+	}
+	kreq.SetVersion(version)
+
+	if kreq.IsFlexible() {
+		kmsg.SkipTags(&b)
+	}
+	if err := b.Complete(); err != nil {
+		return nil, cid, corr, err
+	}
+	if err := kreq.ReadFrom(b.Src); err != nil {
+		return nil, cid, corr, fmt.Errorf("unable to parse %s request: %w", kmsg.NameForKey(key), err)
+	}
+	return kreq, cid, corr, nil
+}
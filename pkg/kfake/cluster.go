@@ -0,0 +1,397 @@
+// Package kfake provides an in-process fake Kafka cluster for testing.
+//
+// The cluster in this package is not a real Kafka: there is no replication,
+// no disk persistence, no authentication, and many requests are not
+// supported. The purpose of this package is to allow unit testing clients
+// (notably kgo and kadm) against something that speaks the Kafka protocol,
+// without needing a real cluster. Requests are decoded with the kmsg package
+// and are handled serially in a single goroutine, so every request has a
+// consistent view of the cluster.
+//
+// The following requests are supported: Produce, Fetch, ListOffsets,
+// Metadata, OffsetCommit, OffsetFetch, FindCoordinator, JoinGroup,
+// Heartbeat, LeaveGroup, SyncGroup, DescribeGroups, ListGroups, ApiVersions,
+// CreateTopics, InitProducerID, OffsetForLeaderEpoch, AddPartitionsToTxn,
+// AddOffsetsToTxn, EndTxn, and TxnOffsetCommit.
+//
+// Faults can be injected per request key with ControlKey, which allows
+// returning arbitrary responses, dropping or delaying responses, rewriting
+// responses, or closing connections.
+//
+// To use the cluster, create it and then point your client at its listeners:
+//
+//	c, err := kfake.NewCluster(kfake.SeedTopics(3, "foo"))
+//	if err != nil {
+//	        // handle
+//	}
+//	defer c.Close()
+//
+//	cl, err := kgo.NewClient(kgo.SeedBrokers(c.ListenAddrs()...))
+package kfake
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Cluster is a mock Kafka broker cluster.
+type Cluster struct {
+	cfg cfg
+
+	controller *broker
+	bs         []*broker
+
+	reqCh   chan clientReq
+	adminCh chan func()
+
+	data   data
+	pids   pids
+	groups groups
+
+	controlMu      sync.Mutex
+	controls       map[int16][]*control
+	currentControl *control    // only used in the run loop
+	currentReq     *reqControl // only used in the run loop
+
+	connsMu sync.Mutex
+	conns   map[*clientConn]struct{}
+
+	die  chan struct{}
+	dead int32 // atomic
+}
+
+type broker struct {
+	c    *Cluster
+	ln   net.Listener
+	node int32
+}
+
+// NewCluster returns a new mocked Kafka cluster.
+func NewCluster(opts ...Opt) (c *Cluster, err error) {
+	cfg := cfg{
+		nbrokers:        3,
+		clusterID:       "kfake",
+		defaultNumParts: 10,
+	}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	if len(cfg.ports) > 0 {
+		cfg.nbrokers = len(cfg.ports)
+	}
+	if cfg.nbrokers <= 0 {
+		return nil, errors.New("invalid number of brokers; must be positive")
+	}
+	if cfg.defaultNumParts <= 0 {
+		return nil, errors.New("invalid default number of partitions; must be positive")
+	}
+
+	c = &Cluster{
+		cfg: cfg,
+
+		reqCh:   make(chan clientReq, 20),
+		adminCh: make(chan func()),
+
+		conns: make(map[*clientConn]struct{}),
+
+		controls: make(map[int16][]*control),
+
+		die: make(chan struct{}),
+	}
+	c.data = data{
+		c:         c,
+		tps:       make(map[string][]*partData),
+		id2t:      make(map[[16]byte]string),
+		t2id:      make(map[string][16]byte),
+		treplicas: make(map[string]int),
+		tcfgs:     make(map[string]map[string]*string),
+	}
+	c.pids = pids{
+		c:   c,
+		ids: make(map[int64]*pidinfo),
+		txs: make(map[string]*pidinfo),
+	}
+	c.groups = groups{
+		c:  c,
+		gs: make(map[string]*group),
+	}
+	defer func() {
+		if err != nil {
+			c.Close()
+		}
+	}()
+
+	for i := 0; i < cfg.nbrokers; i++ {
+		var port int
+		if len(cfg.ports) > 0 {
+			port = cfg.ports[i]
+		}
+		ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err != nil {
+			return nil, err
+		}
+		b := &broker{
+			c:    c,
+			ln:   ln,
+			node: int32(i),
+		}
+		c.bs = append(c.bs, b)
+		go b.listen()
+	}
+	c.controller = c.bs[len(c.bs)-1]
+
+	for _, seed := range cfg.seedTopics {
+		p := seed.p
+		if p < 1 {
+			p = int32(cfg.defaultNumParts)
+		}
+		for _, t := range seed.ts {
+			c.data.mkt(t, int(p), -1, nil)
+		}
+	}
+
+	go c.run()
+	return c, nil
+}
+
+// MustCluster is like NewCluster, but panics on error.
+func MustCluster(opts ...Opt) *Cluster {
+	c, err := NewCluster(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// ListenAddrs returns the hostports that the cluster is listening on.
+func (c *Cluster) ListenAddrs() []string {
+	var addrs []string
+	for _, b := range c.bs {
+		addrs = append(addrs, b.ln.Addr().String())
+	}
+	return addrs
+}
+
+// Close shuts down the cluster.
+func (c *Cluster) Close() {
+	if !atomic.CompareAndSwapInt32(&c.dead, 0, 1) {
+		return
+	}
+	close(c.die)
+	for _, b := range c.bs {
+		b.ln.Close()
+	}
+	c.connsMu.Lock()
+	defer c.connsMu.Unlock()
+	for cc := range c.conns {
+		cc.conn.Close()
+	}
+}
+
+func (b *broker) listen() {
+	defer b.ln.Close()
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		cc := &clientConn{
+			c:      b.c,
+			b:      b,
+			conn:   conn,
+			respCh: make(chan clientResp, 2),
+			done:   make(chan struct{}),
+		}
+		if !b.c.trackConn(cc) {
+			conn.Close()
+			return
+		}
+		go cc.read()
+		go cc.write()
+	}
+}
+
+func (c *Cluster) trackConn(cc *clientConn) bool {
+	c.connsMu.Lock()
+	defer c.connsMu.Unlock()
+	if atomic.LoadInt32(&c.dead) == 1 {
+		return false
+	}
+	c.conns[cc] = struct{}{}
+	return true
+}
+
+func (c *Cluster) untrackConn(cc *clientConn) {
+	c.connsMu.Lock()
+	defer c.connsMu.Unlock()
+	delete(c.conns, cc)
+}
+
+func (b *broker) hostport() (string, int32) {
+	h, p, _ := net.SplitHostPort(b.ln.Addr().String())
+	p32, _ := strconv.Atoi(p)
+	return h, int32(p32)
+}
+
+func (c *Cluster) run() {
+	for {
+		select {
+		case creq := <-c.reqCh:
+			c.handleReq(creq)
+		case fn := <-c.adminCh:
+			fn()
+		case <-c.die:
+			return
+		}
+	}
+}
+
+// admin runs fn within the cluster's run loop, which allows timers and other
+// goroutines to safely modify cluster state.
+func (c *Cluster) admin(fn func()) {
+	select {
+	case c.adminCh <- fn:
+	case <-c.die:
+	}
+}
+
+func (c *Cluster) handleReq(creq clientReq) {
+	var (
+		kreq  = creq.kreq
+		kresp kmsg.Response
+		err   error
+	)
+
+	if kresp, err, handled := c.tryControl(&creq); handled {
+		if kresp == nil && err == nil {
+			return // dropped: we never reply
+		}
+		c.reply(creq, kresp, err)
+		return
+	}
+
+	switch req := kreq.(type) {
+	case *kmsg.ProduceRequest:
+		kresp, err = c.handleProduce(creq.cc.b, req)
+		if err == nil && req.Acks == 0 {
+			c.skip(creq)
+			return
+		}
+	case *kmsg.FetchRequest:
+		kresp, err = c.handleFetch(creq, req)
+	case *kmsg.ListOffsetsRequest:
+		kresp, err = c.handleListOffsets(creq.cc.b, req)
+	case *kmsg.MetadataRequest:
+		kresp, err = c.handleMetadata(req)
+	case *kmsg.OffsetCommitRequest:
+		kresp, err = c.groups.handleOffsetCommit(creq.cc.b, req)
+	case *kmsg.OffsetFetchRequest:
+		kresp, err = c.groups.handleOffsetFetch(creq.cc.b, req)
+	case *kmsg.FindCoordinatorRequest:
+		kresp, err = c.handleFindCoordinator(req)
+	case *kmsg.JoinGroupRequest:
+		kresp, err = c.groups.handleJoin(creq, req)
+	case *kmsg.HeartbeatRequest:
+		kresp, err = c.groups.handleHeartbeat(creq.cc.b, req)
+	case *kmsg.LeaveGroupRequest:
+		kresp, err = c.groups.handleLeave(creq.cc.b, req)
+	case *kmsg.SyncGroupRequest:
+		kresp, err = c.groups.handleSync(creq, req)
+	case *kmsg.DescribeGroupsRequest:
+		kresp, err = c.groups.handleDescribe(creq.cc.b, req)
+	case *kmsg.ListGroupsRequest:
+		kresp, err = c.groups.handleList(creq.cc.b, req)
+	case *kmsg.ApiVersionsRequest:
+		kresp, err = c.handleApiVersions(req)
+	case *kmsg.CreateTopicsRequest:
+		kresp, err = c.handleCreateTopics(creq.cc.b, req)
+	case *kmsg.InitProducerIDRequest:
+		kresp, err = c.pids.handleInitProducerID(creq.cc.b, req)
+	case *kmsg.OffsetForLeaderEpochRequest:
+		kresp, err = c.handleOffsetForLeaderEpoch(creq.cc.b, req)
+	case *kmsg.AddPartitionsToTxnRequest:
+		kresp, err = c.pids.handleAddPartitionsToTxn(creq.cc.b, req)
+	case *kmsg.AddOffsetsToTxnRequest:
+		kresp, err = c.pids.handleAddOffsetsToTxn(creq.cc.b, req)
+	case *kmsg.EndTxnRequest:
+		kresp, err = c.pids.handleEndTxn(creq.cc.b, req)
+	case *kmsg.TxnOffsetCommitRequest:
+		kresp, err = c.groups.handleTxnOffsetCommit(creq.cc.b, req)
+	default:
+		err = fmt.Errorf("unhandled key %v", kreq.Key())
+	}
+
+	// A nil response and nil error means the request was accepted but the
+	// response will be sent later (fetch long polling, joining a group).
+	if kresp == nil && err == nil {
+		return
+	}
+	c.reply(creq, kresp, err)
+}
+
+// reply sends a response for a request back to the request's connection. If
+// the error is non-nil, the connection is closed.
+//
+// If a control function rewrote or delayed the request, the rewrite is
+// applied here and the response is sent after the delay.
+func (c *Cluster) reply(creq clientReq, kresp kmsg.Response, err error) {
+	var delay time.Duration
+	if ctl := creq.ctl; ctl != nil {
+		if kresp != nil && err == nil {
+			for _, fn := range ctl.rewrites {
+				if kresp, err = fn(kresp); err != nil {
+					break
+				}
+			}
+		}
+		delay = ctl.delay
+	}
+
+	send := func() {
+		select {
+		case creq.cc.respCh <- clientResp{kresp: kresp, corr: creq.corr, err: err, seq: creq.seq}:
+		case <-creq.cc.done:
+		case <-c.die:
+		}
+	}
+	if delay > 0 {
+		time.AfterFunc(delay, send)
+		return
+	}
+	send()
+}
+
+// skip tells the connection that a request has no response, allowing the
+// connection to continue writing responses for requests after this one.
+func (c *Cluster) skip(creq clientReq) {
+	c.reply(creq, nil, nil)
+}
+
+// coordinator returns the broker that coordinates the given group or
+// transactional ID.
+func (c *Cluster) coordinator(id string) *broker {
+	n := crc32.ChecksumIEEE([]byte(id))
+	return c.bs[n%uint32(len(c.bs))]
+}
+
+func (c *Cluster) broker(node int32) *broker {
+	for _, b := range c.bs {
+		if b.node == node {
+			return b
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) timer(d time.Duration, fn func()) *time.Timer {
+	return time.AfterFunc(d, func() { c.admin(fn) })
+}
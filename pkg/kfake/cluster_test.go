@@ -0,0 +1,250 @@
+package kfake
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func newTestCluster(t *testing.T, opts ...Opt) *Cluster {
+	t.Helper()
+	c, err := NewCluster(opts...)
+	if err != nil {
+		t.Fatalf("unable to create cluster: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func newTestClient(t *testing.T, c *Cluster, opts ...kgo.Opt) *kgo.Client {
+	t.Helper()
+	cl, err := kgo.NewClient(append([]kgo.Opt{
+		kgo.SeedBrokers(c.ListenAddrs()...),
+		kgo.FetchMaxWait(250 * time.Millisecond),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	t.Cleanup(cl.Close)
+	return cl
+}
+
+func produceN(t *testing.T, cl *kgo.Client, topic string, n int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var rs []*kgo.Record
+	for i := 0; i < n; i++ {
+		rs = append(rs, &kgo.Record{Topic: topic, Value: []byte(strconv.Itoa(i))})
+	}
+	if err := cl.ProduceSync(ctx, rs...).FirstErr(); err != nil {
+		t.Fatalf("unable to produce: %v", err)
+	}
+}
+
+// consumeN polls until n records are consumed, failing on any fetch error.
+func consumeN(t *testing.T, cl *kgo.Client, n int) []*kgo.Record {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var rs []*kgo.Record
+	for len(rs) < n {
+		fs := cl.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			t.Fatalf("timed out consuming, have %d of %d records", len(rs), n)
+		}
+		fs.EachError(func(t string, p int32, err error) {
+			panic(t + ": " + err.Error())
+		})
+		rs = append(rs, fs.Records()...)
+	}
+	return rs
+}
+
+func TestProduceConsume(t *testing.T) {
+	t.Parallel()
+
+	const topic = "foo"
+	c := newTestCluster(t, SeedTopics(3, topic))
+	producer := newTestClient(t, c)
+	produceN(t, producer, topic, 100)
+
+	consumer := newTestClient(t, c,
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	rs := consumeN(t, consumer, 100)
+	if len(rs) != 100 {
+		t.Errorf("got %d records, expected 100", len(rs))
+	}
+
+	// Offsets per partition must be contiguous from zero.
+	next := make(map[int32]int64)
+	for _, r := range rs {
+		if r.Offset != next[r.Partition] {
+			t.Errorf("p%d: got offset %d, expected %d", r.Partition, r.Offset, next[r.Partition])
+		}
+		next[r.Partition]++
+	}
+}
+
+func TestListOffsetsAndMetadata(t *testing.T) {
+	t.Parallel()
+
+	const topic = "foo"
+	c := newTestCluster(t, NumBrokers(2), SeedTopics(1, topic))
+	cl := newTestClient(t, c, kgo.DefaultProduceTopic(topic))
+	produceN(t, cl, topic, 10)
+
+	ctx := context.Background()
+
+	meta := kmsg.NewPtrMetadataRequest()
+	mt := kmsg.NewMetadataRequestTopic()
+	mt.Topic = kmsg.StringPtr(topic)
+	meta.Topics = append(meta.Topics, mt)
+	mresp, err := meta.RequestWith(ctx, cl)
+	if err != nil {
+		t.Fatalf("unable to request metadata: %v", err)
+	}
+	if len(mresp.Brokers) != 2 {
+		t.Errorf("got %d brokers, expected 2", len(mresp.Brokers))
+	}
+	if len(mresp.Topics) != 1 || len(mresp.Topics[0].Partitions) != 1 {
+		t.Fatalf("unexpected metadata topics: %v", mresp.Topics)
+	}
+
+	for _, test := range []struct {
+		ts  int64
+		exp int64
+	}{
+		{-2, 0},
+		{-1, 10},
+	} {
+		req := kmsg.NewPtrListOffsetsRequest()
+		rt := kmsg.NewListOffsetsRequestTopic()
+		rt.Topic = topic
+		rp := kmsg.NewListOffsetsRequestTopicPartition()
+		rp.Timestamp = test.ts
+		rt.Partitions = append(rt.Partitions, rp)
+		req.Topics = append(req.Topics, rt)
+
+		shards := cl.RequestSharded(ctx, req)
+		if len(shards) != 1 || shards[0].Err != nil {
+			t.Fatalf("unexpected list offsets shards: %v", shards)
+		}
+		resp := shards[0].Resp.(*kmsg.ListOffsetsResponse)
+		if got := resp.Topics[0].Partitions[0].Offset; got != test.exp {
+			t.Errorf("timestamp %d: got offset %d, expected %d", test.ts, got, test.exp)
+		}
+	}
+}
+
+func TestGroupConsume(t *testing.T) {
+	t.Parallel()
+
+	const (
+		topic = "foo"
+		group = "grp"
+	)
+	c := newTestCluster(t, SeedTopics(4, topic))
+	producer := newTestClient(t, c)
+	produceN(t, producer, topic, 40)
+
+	consumer := newTestClient(t, c,
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumerGroup(group),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.DisableAutoCommit(),
+	)
+	consumeN(t, consumer, 40)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := consumer.CommitUncommittedOffsets(ctx); err != nil {
+		t.Fatalf("unable to commit: %v", err)
+	}
+
+	req := kmsg.NewPtrOffsetFetchRequest()
+	req.Group = group
+	resp, err := req.RequestWith(ctx, producer)
+	if err != nil {
+		t.Fatalf("unable to fetch offsets: %v", err)
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		t.Fatalf("offset fetch error: %v", err)
+	}
+	var total int64
+	for _, rt := range resp.Topics {
+		for _, rp := range rt.Partitions {
+			total += rp.Offset
+		}
+	}
+	if total != 40 {
+		t.Errorf("got %d total committed offsets, expected 40", total)
+	}
+
+	// A commit from outside the group must fail while the group is
+	// active.
+	commit := kmsg.NewPtrOffsetCommitRequest()
+	commit.Group = group
+	commit.Generation = -1
+	ct := kmsg.NewOffsetCommitRequestTopic()
+	ct.Topic = topic
+	cp := kmsg.NewOffsetCommitRequestTopicPartition()
+	ct.Partitions = append(ct.Partitions, cp)
+	commit.Topics = append(commit.Topics, ct)
+	cresp, err := commit.RequestWith(ctx, producer)
+	if err != nil {
+		t.Fatalf("unable to commit: %v", err)
+	}
+	if err := kerr.ErrorForCode(cresp.Topics[0].Partitions[0].ErrorCode); !errors.Is(err, kerr.UnknownMemberID) {
+		t.Errorf("got commit err %v, expected UNKNOWN_MEMBER_ID", err)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	t.Parallel()
+
+	const topic = "foo"
+	c := newTestCluster(t, SeedTopics(2, topic))
+	producer := newTestClient(t, c,
+		kgo.TransactionalID("txn"),
+		kgo.DefaultProduceTopic(topic),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, commit := range []kgo.TransactionEndTry{kgo.TryAbort, kgo.TryCommit} {
+		if err := producer.BeginTransaction(); err != nil {
+			t.Fatalf("unable to begin transaction: %v", err)
+		}
+		produceN(t, producer, topic, 10)
+		if err := producer.EndTransaction(ctx, commit); err != nil {
+			t.Fatalf("unable to end transaction: %v", err)
+		}
+	}
+
+	consumer := newTestClient(t, c,
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+	)
+	rs := consumeN(t, consumer, 10)
+	if len(rs) != 10 {
+		t.Errorf("got %d records, expected 10", len(rs))
+	}
+
+	// Nothing else should arrive: the aborted records are skipped.
+	pollCtx, pollCancel := context.WithTimeout(ctx, time.Second)
+	defer pollCancel()
+	if extra := consumer.PollFetches(pollCtx).Records(); len(extra) != 0 {
+		t.Errorf("got %d unexpected extra records", len(extra))
+	}
+}
@@ -0,0 +1,65 @@
+package kfake
+
+// Opt is an option to configure a cluster.
+type Opt interface {
+	apply(*cfg)
+}
+
+type opt struct{ fn func(*cfg) }
+
+func (opt opt) apply(cfg *cfg) { opt.fn(cfg) }
+
+type seedTopics struct {
+	p  int32
+	ts []string
+}
+
+type cfg struct {
+	nbrokers        int
+	ports           []int
+	clusterID       string
+	allowAutoTopic  bool
+	defaultNumParts int
+	seedTopics      []seedTopics
+}
+
+// NumBrokers sets the number of brokers to start in the fake cluster, by
+// default 3.
+func NumBrokers(n int) Opt {
+	return opt{func(cfg *cfg) { cfg.nbrokers = n }}
+}
+
+// Ports sets the ports to listen on, overriding randomly choosing NumBrokers
+// amount of ports. The number of ports overrides NumBrokers.
+func Ports(ports ...int) Opt {
+	return opt{func(cfg *cfg) { cfg.ports = ports }}
+}
+
+// ClusterID sets the cluster ID to return in metadata responses, by default
+// "kfake".
+func ClusterID(clusterID string) Opt {
+	return opt{func(cfg *cfg) { cfg.clusterID = clusterID }}
+}
+
+// AllowAutoTopicCreation allows metadata requests to create topics if the
+// metadata request has its AllowAutoTopicCreation field set to true.
+func AllowAutoTopicCreation() Opt {
+	return opt{func(cfg *cfg) { cfg.allowAutoTopic = true }}
+}
+
+// DefaultNumPartitions sets the number of partitions to create by default for
+// auto created topics / CreateTopics with -1 partitions, overriding the
+// default of 10.
+func DefaultNumPartitions(n int) Opt {
+	return opt{func(cfg *cfg) { cfg.defaultNumParts = n }}
+}
+
+// SeedTopics provides topics to create by default in the cluster. Each topic
+// will use the given partitions and use the default internal replication
+// factor. If you use a non-positive number for partitions, DefaultNumPartitions
+// is used. This option can be provided multiple times if you want to seed
+// topics with different partition counts. If a topic is provided in multiple
+// options, the last specification wins.
+func SeedTopics(partitions int32, ts ...string) Opt {
+	return opt{func(cfg *cfg) { cfg.seedTopics = append(cfg.seedTopics, seedTopics{partitions, ts}) }}
+}
@@ -0,0 +1,160 @@
+package kfake
+
+import (
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// control is a registered control function.
+type control struct {
+	fn   func(kmsg.Request) (kmsg.Response, error, bool)
+	keep bool
+}
+
+// reqControl tracks how control functions modified the reply to a single
+// request.
+type reqControl struct {
+	delay    time.Duration
+	rewrites []func(kmsg.Response) (kmsg.Response, error)
+}
+
+// ControlKey adds a control function that is called for every request with
+// the given key (i.e., kmsg.Request.Key()) before the cluster handles the
+// request. Control functions allow tests to inject faults deterministically.
+//
+// The function is passed the decoded request and returns a response, an
+// error, and whether the function handled the request. If the request was not
+// handled, the next control function for the key is tried and then the
+// cluster handles the request as normal. If the request was handled:
+//
+//   - a non-nil error closes the connection the request was read from;
+//   - a non-nil response is sent as is back to the client;
+//   - a nil response and nil error drops the request: no response is ever
+//     sent. Because Kafka replies in order per connection, every later
+//     response on the same connection is blocked as well, just as with a
+//     broker that stopped responding.
+//
+// By default, a control function is removed once it handles a request. To
+// keep the function, call KeepControl from within it. Control functions are
+// called serially within the cluster's goroutine and must not block; the
+// request can be modified in place, but it must not be retained. It is safe
+// to add more control functions from within a control function.
+//
+// As an example, this returns NOT_LEADER_FOR_PARTITION for the next three
+// produce requests that contain partition 2:
+//
+//	var n int
+//	c.ControlKey(0, func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+//	        req := kreq.(*kmsg.ProduceRequest)
+//	        resp := req.ResponseKind().(*kmsg.ProduceResponse)
+//	        var has2 bool
+//	        for _, t := range req.Topics {
+//	                rt := kmsg.NewProduceResponseTopic()
+//	                rt.Topic = t.Topic
+//	                for _, p := range t.Partitions {
+//	                        has2 = has2 || p.Partition == 2
+//	                        rp := kmsg.NewProduceResponseTopicPartition()
+//	                        rp.Partition = p.Partition
+//	                        rp.ErrorCode = kerr.NotLeaderForPartition.Code
+//	                        rt.Partitions = append(rt.Partitions, rp)
+//	                }
+//	                resp.Topics = append(resp.Topics, rt)
+//	        }
+//	        if !has2 {
+//	                return nil, nil, false
+//	        }
+//	        if n++; n < 3 {
+//	                c.KeepControl()
+//	        }
+//	        return resp, nil, true
+//	})
+func (c *Cluster) ControlKey(key int16, fn func(kmsg.Request) (kmsg.Response, error, bool)) {
+	c.controlMu.Lock()
+	defer c.controlMu.Unlock()
+	c.controls[key] = append(c.controls[key], &control{fn: fn})
+}
+
+// KeepControl marks the currently running control function to be kept even
+// if it handles the request. This must only be called from within a control
+// function.
+func (c *Cluster) KeepControl() {
+	if c.currentControl != nil {
+		c.currentControl.keep = true
+	}
+}
+
+// DelayControl delays the response to the request currently being controlled
+// by d. This can be used whether or not the control function handles the
+// request; if the function does not handle the request, the response the
+// cluster generates is delayed. Delays from multiple control functions are
+// summed. This must only be called from within a control function.
+//
+// Because responses are written in order per connection, delaying a response
+// delays every later response on the same connection.
+func (c *Cluster) DelayControl(d time.Duration) {
+	if c.currentReq != nil {
+		c.currentReq.delay += d
+	}
+}
+
+// RewriteControl registers fn to rewrite the response the cluster generates
+// for the request currently being controlled. The function is called with the
+// cluster's response immediately before it is written, which for fetch and
+// join requests may be well after the control function returns. Returning an
+// error closes the connection. This must only be called from within a control
+// function that does not handle the request.
+func (c *Cluster) RewriteControl(fn func(kmsg.Response) (kmsg.Response, error)) {
+	if c.currentReq != nil {
+		c.currentReq.rewrites = append(c.currentReq.rewrites, fn)
+	}
+}
+
+// tryControl runs all control functions for the request's key, returning the
+// response / error from the first function that handles the request.
+func (c *Cluster) tryControl(creq *clientReq) (kresp kmsg.Response, err error, handled bool) {
+	key := creq.kreq.Key()
+	c.controlMu.Lock()
+	ctls := append([]*control(nil), c.controls[key]...)
+	c.controlMu.Unlock()
+	if len(ctls) == 0 {
+		return nil, nil, false
+	}
+
+	ctl := new(reqControl)
+	c.currentReq = ctl
+	defer func() {
+		c.currentReq = nil
+		c.currentControl = nil
+		if ctl.delay > 0 || len(ctl.rewrites) > 0 {
+			creq.ctl = ctl
+		}
+	}()
+
+	for _, fnctl := range ctls {
+		c.currentControl = fnctl
+		kresp, err, handled = fnctl.fn(creq.kreq)
+		if !handled {
+			continue
+		}
+		if !fnctl.keep {
+			c.removeControl(key, fnctl)
+		}
+		fnctl.keep = false
+		ctl.rewrites = nil // rewrites only apply to cluster generated responses
+		return kresp, err, true
+	}
+	return nil, nil, false
+}
+
+func (c *Cluster) removeControl(key int16, rm *control) {
+	c.controlMu.Lock()
+	defer c.controlMu.Unlock()
+	ctls := c.controls[key]
+	for i, ctl := range ctls {
+		if ctl == rm {
+			c.controls[key] = append(ctls[:i:i], ctls[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package kfake
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestControlProduceErrors(t *testing.T) {
+	t.Parallel()
+
+	const topic = "foo"
+	c := newTestCluster(t, SeedTopics(3, topic))
+
+	var n int32
+	c.ControlKey(0, func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+		req := kreq.(*kmsg.ProduceRequest)
+		resp := req.ResponseKind().(*kmsg.ProduceResponse)
+		var has2 bool
+		for _, t := range req.Topics {
+			rt := kmsg.NewProduceResponseTopic()
+			rt.Topic = t.Topic
+			for _, p := range t.Partitions {
+				has2 = has2 || p.Partition == 2
+				rp := kmsg.NewProduceResponseTopicPartition()
+				rp.Partition = p.Partition
+				rp.ErrorCode = kerr.NotLeaderForPartition.Code
+				rt.Partitions = append(rt.Partitions, rp)
+			}
+			resp.Topics = append(resp.Topics, rt)
+		}
+		if !has2 {
+			return nil, nil, false
+		}
+		if atomic.AddInt32(&n, 1) < 3 {
+			c.KeepControl()
+		}
+		return resp, nil, true
+	})
+
+	cl := newTestClient(t, c,
+		kgo.RecordPartitioner(kgo.ManualPartitioner()),
+		kgo.MetadataMinAge(50*time.Millisecond),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := cl.ProduceSync(ctx, &kgo.Record{Topic: topic, Partition: 2}).FirstErr(); err != nil {
+		t.Fatalf("unable to produce: %v", err)
+	}
+
+	if nafter := atomic.LoadInt32(&n); nafter != 3 {
+		t.Errorf("control handled %d requests, expected 3", nafter)
+	}
+}
+
+func TestControlCloseAndDelay(t *testing.T) {
+	t.Parallel()
+
+	const topic = "foo"
+	c := newTestCluster(t, SeedTopics(1, topic))
+
+	// Close the connection the first time a JoinGroup is read; the client
+	// should reconnect and join successfully.
+	c.ControlKey(11, func(kmsg.Request) (kmsg.Response, error, bool) {
+		return nil, errors.New("closing"), true
+	})
+
+	// Delay every fetch by some time; the delay should be visible when
+	// consuming.
+	const delay = 300 * time.Millisecond
+	c.ControlKey(1, func(kmsg.Request) (kmsg.Response, error, bool) {
+		c.DelayControl(delay)
+		return nil, nil, false
+	})
+
+	producer := newTestClient(t, c)
+	produceN(t, producer, topic, 1)
+
+	consumer := newTestClient(t, c,
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumerGroup("grp"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	start := time.Now()
+	consumeN(t, consumer, 1)
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("consumed in %v, expected at least %v", elapsed, delay)
+	}
+}
+
+func TestControlRewrite(t *testing.T) {
+	t.Parallel()
+
+	const topic = "foo"
+	c := newTestCluster(t, SeedTopics(1, topic))
+	cl := newTestClient(t, c)
+	produceN(t, cl, topic, 5)
+
+	c.ControlKey(2, func(kmsg.Request) (kmsg.Response, error, bool) {
+		c.RewriteControl(func(kresp kmsg.Response) (kmsg.Response, error) {
+			resp := kresp.(*kmsg.ListOffsetsResponse)
+			for i := range resp.Topics {
+				for j := range resp.Topics[i].Partitions {
+					resp.Topics[i].Partitions[j].Offset += 100
+				}
+			}
+			return resp, nil
+		})
+		return nil, nil, false
+	})
+
+	req := kmsg.NewPtrListOffsetsRequest()
+	rt := kmsg.NewListOffsetsRequestTopic()
+	rt.Topic = topic
+	rp := kmsg.NewListOffsetsRequestTopicPartition()
+	rp.Timestamp = -1
+	rt.Partitions = append(rt.Partitions, rp)
+	req.Topics = append(req.Topics, rt)
+
+	shards := cl.RequestSharded(context.Background(), req)
+	if len(shards) != 1 || shards[0].Err != nil {
+		t.Fatalf("unexpected list offsets shards: %v", shards)
+	}
+	resp := shards[0].Resp.(*kmsg.ListOffsetsResponse)
+	if got := resp.Topics[0].Partitions[0].Offset; got != 105 {
+		t.Errorf("got rewritten offset %d, expected 105", got)
+	}
+}
@@ -0,0 +1,123 @@
+package kfake
+
+import (
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func (c *Cluster) handleCreateTopics(b *broker, req *kmsg.CreateTopicsRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.CreateTopicsResponse)
+
+	donet := func(t string, errCode int16) *kmsg.CreateTopicsResponseTopic {
+		st := kmsg.NewCreateTopicsResponseTopic()
+		st.Topic = t
+		st.ErrorCode = errCode
+		resp.Topics = append(resp.Topics, st)
+		return &resp.Topics[len(resp.Topics)-1]
+	}
+	donets := func(errCode int16) {
+		for _, rt := range req.Topics {
+			donet(rt.Topic, errCode)
+		}
+	}
+
+	if b != c.controller {
+		donets(kerr.NotController.Code)
+		return resp, nil
+	}
+
+	uniq := make(map[string]struct{})
+	for _, rt := range req.Topics {
+		if _, ok := uniq[rt.Topic]; ok {
+			donets(kerr.InvalidRequest.Code)
+			return resp, nil
+		}
+		uniq[rt.Topic] = struct{}{}
+	}
+
+	for _, rt := range req.Topics {
+		if _, ok := c.data.tps[rt.Topic]; ok {
+			donet(rt.Topic, kerr.TopicAlreadyExists.Code)
+			continue
+		}
+		if rt.Topic == "" {
+			donet(rt.Topic, kerr.InvalidTopicException.Code)
+			continue
+		}
+
+		nparts := int(rt.NumPartitions)
+		nreplicas := int(rt.ReplicationFactor)
+		var leaders []*broker
+		if len(rt.ReplicaAssignment) > 0 {
+			if nparts != -1 || nreplicas != -1 {
+				donet(rt.Topic, kerr.InvalidRequest.Code)
+				continue
+			}
+			nparts = len(rt.ReplicaAssignment)
+			var invalid bool
+			for i, ra := range rt.ReplicaAssignment {
+				if int(ra.Partition) != i || len(ra.Replicas) == 0 || nreplicas != -1 && len(ra.Replicas) != nreplicas {
+					invalid = true
+					break
+				}
+				nreplicas = len(ra.Replicas)
+				leader := c.broker(ra.Replicas[0])
+				if leader == nil {
+					invalid = true
+					break
+				}
+				leaders = append(leaders, leader)
+			}
+			if invalid {
+				donet(rt.Topic, kerr.InvalidReplicaAssignment.Code)
+				continue
+			}
+		}
+		if nparts == -1 {
+			nparts = c.cfg.defaultNumParts
+		}
+		if nreplicas == -1 {
+			nreplicas = len(c.bs)
+			if nreplicas > 3 {
+				nreplicas = 3
+			}
+		}
+		if nparts <= 0 {
+			donet(rt.Topic, kerr.InvalidPartitions.Code)
+			continue
+		}
+		if nreplicas <= 0 || nreplicas > len(c.bs) {
+			donet(rt.Topic, kerr.InvalidReplicationFactor.Code)
+			continue
+		}
+
+		configs := make(map[string]*string)
+		for _, cfg := range rt.Configs {
+			configs[cfg.Name] = cfg.Value
+		}
+
+		st := donet(rt.Topic, 0)
+		if req.ValidateOnly {
+			st.NumPartitions = int32(nparts)
+			st.ReplicationFactor = int16(nreplicas)
+			continue
+		}
+
+		c.data.mkt(rt.Topic, nparts, nreplicas, configs)
+		for i, leader := range leaders {
+			c.data.tps[rt.Topic][i].leader = leader
+		}
+		st.TopicID = c.data.t2id[rt.Topic]
+		st.NumPartitions = int32(nparts)
+		st.ReplicationFactor = int16(c.data.treplicas[rt.Topic])
+		for name, value := range configs {
+			sc := kmsg.NewCreateTopicsResponseTopicConfig()
+			sc.Name = name
+			sc.Value = value
+			sc.Source = 1 // dynamic topic config
+			st.Configs = append(st.Configs, sc)
+		}
+	}
+
+	return resp, nil
+}
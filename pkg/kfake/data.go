@@ -0,0 +1,264 @@
+package kfake
+
+import (
+	"crypto/rand"
+	"hash/crc32"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// data contains all topics and partitions in the cluster. All fields are only
+// accessed within the cluster's run loop.
+type data struct {
+	c *Cluster
+
+	tps       map[string][]*partData
+	id2t      map[[16]byte]string
+	t2id      map[string][16]byte
+	treplicas map[string]int
+	tcfgs     map[string]map[string]*string
+}
+
+// partData is the data for a single partition.
+type partData struct {
+	t string
+	p int32
+
+	batches []partBatch
+	epochs  []epochStart
+
+	highWatermark    int64
+	lastStableOffset int64
+	logStartOffset   int64
+	epoch            int32
+	maxTimestamp     int64
+	nbytes           int64
+
+	// openTxns maps producer IDs to the first offset the producer wrote in
+	// its currently ongoing transaction. The minimum of all first offsets is
+	// the last stable offset.
+	openTxns    map[int64]int64
+	abortedTxns []abortedTxn
+
+	leader *broker
+	watch  map[*watchFetch]struct{}
+}
+
+type partBatch struct {
+	kmsg.RecordBatch
+	nbytes int
+}
+
+// epochStart tracks the offset that a leader epoch began at, which is used for
+// OffsetForLeaderEpoch.
+type epochStart struct {
+	epoch int32
+	start int64
+}
+
+type abortedTxn struct {
+	producerID  int64
+	firstOffset int64
+	lastOffset  int64 // the offset of the abort marker
+}
+
+func randUUID() [16]byte {
+	var id [16]byte
+	rand.Read(id[:])
+	return id
+}
+
+// mkt makes a topic with the given number of partitions and replication
+// factor. If the replication factor is non-positive, this uses the number of
+// brokers, capped at 3.
+func (d *data) mkt(t string, nparts int, nreplicas int, configs map[string]*string) {
+	if _, exists := d.tps[t]; exists {
+		return
+	}
+	if nreplicas <= 0 {
+		nreplicas = len(d.c.bs)
+		if nreplicas > 3 {
+			nreplicas = 3
+		}
+	}
+	id := randUUID()
+	for {
+		if _, exists := d.id2t[id]; !exists {
+			break
+		}
+		id = randUUID()
+	}
+	d.id2t[id] = t
+	d.t2id[t] = id
+	d.treplicas[t] = nreplicas
+	d.tcfgs[t] = configs
+	d.tps[t] = nil
+	for i := 0; i < nparts; i++ {
+		d.mkp(t)
+	}
+}
+
+// mkp adds a partition to an existing topic.
+func (d *data) mkp(t string) *partData {
+	ps := d.tps[t]
+	p := int32(len(ps))
+	pd := &partData{
+		t:      t,
+		p:      p,
+		epochs: []epochStart{{0, 0}},
+		leader: d.c.bs[(int(p)+len(d.tps))%len(d.c.bs)],
+		watch:  make(map[*watchFetch]struct{}),
+	}
+	d.tps[t] = append(ps, pd)
+	return pd
+}
+
+// getp returns the partition data for a topic / partition, or nil if it does
+// not exist.
+func (d *data) getp(t string, p int32) *partData {
+	ps, ok := d.tps[t]
+	if !ok || p < 0 || int(p) >= len(ps) {
+		return nil
+	}
+	return ps[p]
+}
+
+// replicas returns the replicas for a partition, beginning with the leader.
+func (d *data) replicas(pd *partData) []int32 {
+	n := d.treplicas[pd.t]
+	if n > len(d.c.bs) {
+		n = len(d.c.bs)
+	}
+	var start int
+	for i, b := range d.c.bs {
+		if b == pd.leader {
+			start = i
+			break
+		}
+	}
+	replicas := make([]int32, 0, n)
+	for i := 0; i < n; i++ {
+		replicas = append(replicas, d.c.bs[(start+i)%len(d.c.bs)].node)
+	}
+	return replicas
+}
+
+// pushBatch appends a batch to the partition, setting the batch's first offset
+// and leader epoch and returning the offset the batch begins at.
+func (pd *partData) pushBatch(nbytes int, b kmsg.RecordBatch) int64 {
+	b.FirstOffset = pd.highWatermark
+	b.PartitionLeaderEpoch = pd.epoch
+	pd.batches = append(pd.batches, partBatch{b, nbytes})
+	pd.highWatermark += int64(b.LastOffsetDelta) + 1
+	if b.MaxTimestamp > pd.maxTimestamp {
+		pd.maxTimestamp = b.MaxTimestamp
+	}
+	pd.nbytes += int64(nbytes)
+	pd.updateLSO()
+	for w := range pd.watch {
+		w.push(nbytes)
+	}
+	return b.FirstOffset
+}
+
+// pushControl appends a transaction control marker to the partition. If the
+// marker is an abort, the aborted range is tracked for read committed fetches.
+func (pd *partData) pushControl(producerID int64, producerEpoch int16, commit bool) {
+	const (
+		attrTxn     = 0x10
+		attrControl = 0x20
+	)
+	var typ int16 // abort is 0, commit is 1
+	if commit {
+		typ = 1
+	}
+	now := time.Now().UnixNano() / 1e6
+
+	rec := kmsg.Record{
+		Key:   kbin.AppendInt16(kbin.AppendInt16(nil, 0), typ), // version 0, type
+		Value: kbin.AppendInt32(kbin.AppendInt16(nil, 0), 0),   // version 0, coordinator epoch
+	}
+	rawRec := rec.AppendTo(nil)[1:] // strip the zero length we did not set
+	rawRec = append(kbin.AppendVarint(nil, int32(len(rawRec))), rawRec...)
+
+	b := kmsg.RecordBatch{
+		Magic:          2,
+		Attributes:     attrTxn | attrControl,
+		FirstTimestamp: now,
+		MaxTimestamp:   now,
+		ProducerID:     producerID,
+		ProducerEpoch:  producerEpoch,
+		FirstSequence:  -1,
+		NumRecords:     1,
+		Records:        rawRec,
+	}
+	raw := b.AppendTo(nil)
+	b.Length = int32(len(raw) - 12) // length excludes the first offset and length itself
+	b.CRC = int32(crc32.Checksum(raw[21:], crc32c))
+	raw = b.AppendTo(raw[:0])
+
+	first, inTxn := pd.openTxns[producerID]
+	delete(pd.openTxns, producerID)
+	marker := pd.pushBatch(len(raw), b)
+	if !commit && inTxn {
+		pd.abortedTxns = append(pd.abortedTxns, abortedTxn{
+			producerID:  producerID,
+			firstOffset: first,
+			lastOffset:  marker,
+		})
+	}
+}
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// trackTxn tracks that a producer wrote a transactional batch at the given
+// offset, which holds back the last stable offset until the transaction ends.
+func (pd *partData) trackTxn(producerID int64, offset int64) {
+	if pd.openTxns == nil {
+		pd.openTxns = make(map[int64]int64)
+	}
+	if _, exists := pd.openTxns[producerID]; !exists {
+		pd.openTxns[producerID] = offset
+		pd.updateLSO()
+	}
+}
+
+func (pd *partData) updateLSO() {
+	lso := pd.highWatermark
+	for _, first := range pd.openTxns {
+		if first < lso {
+			lso = first
+		}
+	}
+	pd.lastStableOffset = lso
+}
+
+// searchOffset returns the index of the batch containing the offset, and
+// whether the offset was found.
+func (pd *partData) searchOffset(o int64) (int, bool) {
+	if o < pd.logStartOffset || o >= pd.highWatermark {
+		return 0, false
+	}
+	idx := sort.Search(len(pd.batches), func(i int) bool {
+		b := &pd.batches[i]
+		return o < b.FirstOffset+int64(b.LastOffsetDelta)+1
+	})
+	return idx, idx < len(pd.batches)
+}
+
+// epochEnd returns the end offset of the requested epoch, and the largest
+// epoch less than or equal to the requested epoch. If the epoch is before the
+// oldest epoch we know of, this returns -1 -1.
+func (pd *partData) epochEnd(epoch int32) (int32, int64) {
+	if len(pd.epochs) == 0 || epoch < pd.epochs[0].epoch {
+		return -1, -1
+	}
+	idx := sort.Search(len(pd.epochs), func(i int) bool { return pd.epochs[i].epoch > epoch })
+	if idx == len(pd.epochs) {
+		return pd.epochs[idx-1].epoch, pd.highWatermark
+	}
+	return pd.epochs[idx-1].epoch, pd.epochs[idx].start
+}
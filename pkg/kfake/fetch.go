@@ -0,0 +1,168 @@
+package kfake
+
+import (
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Fetch sessions are not supported: we always reply with a session ID of 0,
+// which tells clients to continue issuing full fetch requests.
+
+// watchFetch is a fetch request that did not have enough data to satisfy
+// MinBytes and is waiting for either more data or MaxWaitMillis to elapse.
+type watchFetch struct {
+	c    *Cluster
+	creq clientReq
+	req  *kmsg.FetchRequest
+	pds  []*partData
+	t    *time.Timer
+	done bool
+}
+
+func (c *Cluster) handleFetch(creq clientReq, req *kmsg.FetchRequest) (kmsg.Response, error) {
+	resp, nbytes, pds, immediate := c.fetch(creq.cc.b, req)
+	if immediate || nbytes >= int(req.MinBytes) || req.MaxWaitMillis <= 0 {
+		return resp, nil
+	}
+
+	w := &watchFetch{
+		c:    c,
+		creq: creq,
+		req:  req,
+		pds:  pds,
+	}
+	for _, pd := range pds {
+		pd.watch[w] = struct{}{}
+	}
+	w.t = c.timer(time.Duration(req.MaxWaitMillis)*time.Millisecond, func() { w.fire() })
+	return nil, nil
+}
+
+// push is called when data is added to a partition this fetch is waiting
+// on. We recompute the response to see if we now have enough visible bytes.
+func (w *watchFetch) push(int) {
+	if w.done {
+		return
+	}
+	if _, nbytes, _, immediate := w.c.fetch(w.creq.cc.b, w.req); immediate || nbytes >= int(w.req.MinBytes) {
+		w.fire()
+	}
+}
+
+func (w *watchFetch) fire() {
+	if w.done {
+		return
+	}
+	w.done = true
+	w.t.Stop()
+	for _, pd := range w.pds {
+		delete(pd.watch, w)
+	}
+	resp, _, _, _ := w.c.fetch(w.creq.cc.b, w.req)
+	w.c.reply(w.creq, resp, nil)
+}
+
+// fetch builds a fetch response, returning the response, the number of record
+// bytes in it, the partitions that were fetched, and whether the response
+// should be returned immediately (because of partition errors).
+func (c *Cluster) fetch(b *broker, req *kmsg.FetchRequest) (resp *kmsg.FetchResponse, nbytes int, pds []*partData, immediate bool) {
+	resp = req.ResponseKind().(*kmsg.FetchResponse)
+
+	maxBytes := int(req.MaxBytes)
+	if req.Version < 3 || maxBytes <= 0 {
+		maxBytes = 50 << 20
+	}
+	readCommitted := req.IsolationLevel == 1
+
+	for _, rt := range req.Topics {
+		t := rt.Topic
+		if req.Version >= 13 {
+			t = c.data.id2t[rt.TopicID]
+		}
+		st := kmsg.NewFetchResponseTopic()
+		st.Topic = rt.Topic
+		st.TopicID = rt.TopicID
+
+		for _, rp := range rt.Partitions {
+			sp := kmsg.NewFetchResponseTopicPartition()
+			sp.Partition = rp.Partition
+			errp := func(code int16) {
+				sp.ErrorCode = code
+				immediate = true
+			}
+
+			pd := c.data.getp(t, rp.Partition)
+			switch {
+			case pd == nil && req.Version >= 13:
+				errp(kerr.UnknownTopicID.Code)
+			case pd == nil:
+				errp(kerr.UnknownTopicOrPartition.Code)
+			case pd.leader != b:
+				errp(kerr.NotLeaderForPartition.Code)
+			case rp.CurrentLeaderEpoch >= 0 && rp.CurrentLeaderEpoch < pd.epoch:
+				errp(kerr.FencedLeaderEpoch.Code)
+			case rp.CurrentLeaderEpoch > pd.epoch:
+				errp(kerr.UnknownLeaderEpoch.Code)
+			case rp.FetchOffset < pd.logStartOffset || rp.FetchOffset > pd.highWatermark:
+				errp(kerr.OffsetOutOfRange.Code)
+			}
+			if pd != nil {
+				pds = append(pds, pd)
+			}
+			if sp.ErrorCode != 0 {
+				st.Partitions = append(st.Partitions, sp)
+				continue
+			}
+
+			sp.HighWatermark = pd.highWatermark
+			sp.LastStableOffset = pd.lastStableOffset
+			sp.LogStartOffset = pd.logStartOffset
+
+			end := pd.highWatermark
+			if readCommitted {
+				end = pd.lastStableOffset
+			}
+
+			var (
+				pbytes     int
+				lastOffset int64
+			)
+			idx, ok := pd.searchOffset(rp.FetchOffset)
+			for ok && idx < len(pd.batches) {
+				batch := &pd.batches[idx]
+				if batch.FirstOffset >= end {
+					break
+				}
+				// We always return at least one batch, even if it is
+				// larger than the max bytes, to ensure progress.
+				if nbytes > 0 && (pbytes+batch.nbytes > int(rp.PartitionMaxBytes) || nbytes+batch.nbytes > maxBytes) {
+					break
+				}
+				sp.RecordBatches = batch.AppendTo(sp.RecordBatches)
+				pbytes += batch.nbytes
+				lastOffset = batch.FirstOffset + int64(batch.LastOffsetDelta)
+				nbytes += batch.nbytes
+				idx++
+			}
+
+			if readCommitted && pbytes > 0 {
+				for _, a := range pd.abortedTxns {
+					if a.lastOffset >= rp.FetchOffset && a.firstOffset <= lastOffset {
+						sa := kmsg.NewFetchResponseTopicPartitionAbortedTransaction()
+						sa.ProducerID = a.producerID
+						sa.FirstOffset = a.firstOffset
+						sp.AbortedTransactions = append(sp.AbortedTransactions, sa)
+					}
+				}
+			}
+
+			st.Partitions = append(st.Partitions, sp)
+		}
+
+		resp.Topics = append(resp.Topics, st)
+	}
+
+	return resp, nbytes, pds, immediate
+}
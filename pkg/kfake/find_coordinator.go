@@ -0,0 +1,43 @@
+package kfake
+
+import (
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func (c *Cluster) handleFindCoordinator(req *kmsg.FindCoordinatorRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+
+	var unknown bool
+	if req.CoordinatorType != 0 && req.CoordinatorType != 1 {
+		unknown = true
+	}
+
+	if req.Version <= 3 {
+		req.CoordinatorKeys = append(req.CoordinatorKeys, req.CoordinatorKey)
+		defer func() {
+			resp.ErrorCode = resp.Coordinators[0].ErrorCode
+			resp.ErrorMessage = resp.Coordinators[0].ErrorMessage
+			resp.NodeID = resp.Coordinators[0].NodeID
+			resp.Host = resp.Coordinators[0].Host
+			resp.Port = resp.Coordinators[0].Port
+		}()
+	}
+
+	for _, key := range req.CoordinatorKeys {
+		sc := kmsg.NewFindCoordinatorResponseCoordinator()
+		sc.Key = key
+
+		if unknown {
+			sc.ErrorCode = kerr.InvalidRequest.Code
+		} else {
+			b := c.coordinator(key)
+			sc.NodeID = b.node
+			sc.Host, sc.Port = b.hostport()
+		}
+
+		resp.Coordinators = append(resp.Coordinators, sc)
+	}
+
+	return resp, nil
+}
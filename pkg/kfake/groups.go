@@ -0,0 +1,933 @@
+package kfake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// groups tracks all consumer groups in the cluster.
+//
+// Groups follow Kafka's classic rebalance protocol: any join triggers a
+// rebalance, the rebalance completes once every known member has rejoined
+// (or the rebalance timeout elapses, kicking members that did not rejoin),
+// and the group is stable once the leader syncs the assignment. Static
+// membership and the initial rebalance delay are not implemented.
+type groups struct {
+	c  *Cluster
+	gs map[string]*group
+}
+
+type groupState int8
+
+const (
+	groupEmpty groupState = iota
+	groupStable
+	groupPreparingRebalance
+	groupCompletingRebalance
+	groupDead
+)
+
+func (s groupState) String() string {
+	switch s {
+	case groupEmpty:
+		return "Empty"
+	case groupStable:
+		return "Stable"
+	case groupPreparingRebalance:
+		return "PreparingRebalance"
+	case groupCompletingRebalance:
+		return "CompletingRebalance"
+	case groupDead:
+		return "Dead"
+	default:
+		return "Unknown"
+	}
+}
+
+type group struct {
+	c    *Cluster
+	name string
+
+	state groupState
+
+	generation   int32
+	protocolType string
+	protocol     string
+	leader       string
+
+	members map[string]*groupMember
+	pending map[string]*groupMember // members that must rejoin with their ID (KIP-394)
+	commits map[string]map[int32]committedOffset
+
+	tRebalance *time.Timer
+}
+
+type groupMember struct {
+	memberID   string
+	instanceID *string
+	clientID   string
+	clientHost string
+
+	join       *kmsg.JoinGroupRequest // the latest join request
+	assignment []byte
+
+	waitingJoin *clientReq
+	waitingSync *clientReq
+
+	t *time.Timer // session timer
+}
+
+type committedOffset struct {
+	offset      int64
+	leaderEpoch int32
+	metadata    *string
+}
+
+const (
+	minSessionTimeout = 6000
+	maxSessionTimeout = 1800000
+)
+
+func generateMemberID(clientID string) string {
+	var buf [16]byte
+	rand.Read(buf[:])
+	return clientID + "-" + hex.EncodeToString(buf[:])
+}
+
+// get returns the group with the given name, or nil if it does not exist. If
+// create is true, the group is created if it does not exist.
+func (gs *groups) get(name string, create bool) *group {
+	g := gs.gs[name]
+	if g == nil && create {
+		g = &group{
+			c:       gs.c,
+			name:    name,
+			members: make(map[string]*groupMember),
+			pending: make(map[string]*groupMember),
+			commits: make(map[string]map[int32]committedOffset),
+		}
+		gs.gs[name] = g
+	}
+	return g
+}
+
+///////////
+// JOINS //
+///////////
+
+func (gs *groups) handleJoin(creq clientReq, req *kmsg.JoinGroupRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.JoinGroupResponse)
+	resp.Generation = -1
+
+	if gs.c.coordinator(req.Group) != creq.cc.b {
+		resp.ErrorCode = kerr.NotCoordinator.Code
+		return resp, nil
+	}
+	if req.Version == 0 {
+		req.RebalanceTimeoutMillis = req.SessionTimeoutMillis
+	}
+	switch {
+	case req.Group == "":
+		resp.ErrorCode = kerr.InvalidGroupID.Code
+		return resp, nil
+	case req.SessionTimeoutMillis < minSessionTimeout || req.SessionTimeoutMillis > maxSessionTimeout:
+		resp.ErrorCode = kerr.InvalidSessionTimeout.Code
+		return resp, nil
+	case req.ProtocolType == "" || len(req.Protocols) == 0:
+		resp.ErrorCode = kerr.InconsistentGroupProtocol.Code
+		return resp, nil
+	}
+
+	g := gs.get(req.Group, req.MemberID == "")
+	if g == nil {
+		resp.ErrorCode = kerr.UnknownMemberID.Code
+		return resp, nil
+	}
+	if !g.protocolsCompatible(req) {
+		resp.ErrorCode = kerr.InconsistentGroupProtocol.Code
+		return resp, nil
+	}
+
+	var m *groupMember
+	if req.MemberID == "" {
+		m = &groupMember{
+			memberID:   generateMemberID(creq.cid),
+			instanceID: req.InstanceID,
+			clientID:   creq.cid,
+			clientHost: creq.cc.conn.RemoteAddr().String(),
+			join:       req,
+		}
+		// KIP-394: v4+ clients must rejoin with the member ID we
+		// give them before they are considered part of the group.
+		if req.Version >= 4 {
+			g.pending[m.memberID] = m
+			g.atSessionTimeout(m, func() { delete(g.pending, m.memberID) })
+			resp.ErrorCode = kerr.MemberIDRequired.Code
+			resp.MemberID = m.memberID
+			return resp, nil
+		}
+		g.members[m.memberID] = m
+	} else {
+		if m = g.pending[req.MemberID]; m != nil {
+			delete(g.pending, req.MemberID)
+			g.members[m.memberID] = m
+		} else if m = g.members[req.MemberID]; m == nil {
+			resp.ErrorCode = kerr.UnknownMemberID.Code
+			return resp, nil
+		}
+	}
+
+	if m.waitingJoin != nil {
+		old := *m.waitingJoin
+		oldResp := old.kreq.ResponseKind().(*kmsg.JoinGroupResponse)
+		oldResp.ErrorCode = kerr.RebalanceInProgress.Code
+		gs.c.reply(old, oldResp, nil)
+	}
+	m.join = req
+	m.waitingJoin = &creq
+	if m.t != nil {
+		m.t.Stop()
+	}
+
+	if g.state != groupPreparingRebalance {
+		g.rebalance()
+	}
+	g.maybeCompleteRebalance()
+	return nil, nil
+}
+
+// protocolsCompatible returns whether the join request's protocol type and
+// protocols are compatible with the group's existing members.
+func (g *group) protocolsCompatible(req *kmsg.JoinGroupRequest) bool {
+	if len(g.members) == 0 {
+		return true
+	}
+	if req.ProtocolType != g.protocolType {
+		return false
+	}
+	for _, p := range req.Protocols {
+		if g.allSupport(p.Name, req.MemberID) {
+			return true
+		}
+	}
+	return false
+}
+
+// allSupport returns whether every member (except the given member) supports
+// the given protocol.
+func (g *group) allSupport(protocol string, except string) bool {
+	for _, m := range g.members {
+		if m.memberID == except {
+			continue
+		}
+		var supports bool
+		for _, p := range m.join.Protocols {
+			if p.Name == protocol {
+				supports = true
+				break
+			}
+		}
+		if !supports {
+			return false
+		}
+	}
+	return true
+}
+
+// rebalance moves the group into PreparingRebalance, failing any member that
+// is waiting for a sync and starting the rebalance timeout.
+func (g *group) rebalance() {
+	if g.state == groupCompletingRebalance {
+		for _, m := range g.members {
+			g.failSync(m, kerr.RebalanceInProgress.Code)
+		}
+	}
+	g.state = groupPreparingRebalance
+
+	var timeout int32
+	for _, m := range g.members {
+		if m.join.RebalanceTimeoutMillis > timeout {
+			timeout = m.join.RebalanceTimeoutMillis
+		}
+	}
+	if g.tRebalance != nil {
+		g.tRebalance.Stop()
+	}
+	gen := g.generation
+	g.tRebalance = g.c.timer(time.Duration(timeout)*time.Millisecond, func() {
+		if g.state != groupPreparingRebalance || g.generation != gen {
+			return
+		}
+		for _, m := range g.members {
+			if m.waitingJoin == nil {
+				g.removeMember(m)
+			}
+		}
+		g.completeRebalance()
+	})
+}
+
+// maybeCompleteRebalance completes the rebalance if every member has joined.
+func (g *group) maybeCompleteRebalance() {
+	if g.state != groupPreparingRebalance {
+		return
+	}
+	for _, m := range g.members {
+		if m.waitingJoin == nil {
+			return
+		}
+	}
+	g.completeRebalance()
+}
+
+// completeRebalance bumps the generation, chooses a protocol and leader, and
+// replies to every waiting join.
+func (g *group) completeRebalance() {
+	if g.tRebalance != nil {
+		g.tRebalance.Stop()
+		g.tRebalance = nil
+	}
+	g.generation++
+	if len(g.members) == 0 {
+		g.state = groupEmpty
+		g.protocol = ""
+		g.protocolType = ""
+		g.leader = ""
+		return
+	}
+	g.state = groupCompletingRebalance
+
+	// We choose the protocol that all members support that is the most
+	// preferred among members.
+	votes := make(map[string]int)
+	for _, m := range g.members {
+		for i, p := range m.join.Protocols {
+			if g.allSupport(p.Name, "") {
+				votes[p.Name] += len(m.join.Protocols) - i
+			}
+		}
+	}
+	g.protocol = ""
+	var best int
+	for p, n := range votes {
+		if n > best || n == best && p < g.protocol {
+			g.protocol, best = p, n
+		}
+	}
+	if _, ok := g.members[g.leader]; !ok {
+		g.leader = ""
+		for id := range g.members {
+			if g.leader == "" || id < g.leader {
+				g.leader = id
+			}
+		}
+	}
+	g.protocolType = g.members[g.leader].join.ProtocolType
+
+	for _, m := range g.members {
+		creq := *m.waitingJoin
+		m.waitingJoin = nil
+
+		resp := creq.kreq.ResponseKind().(*kmsg.JoinGroupResponse)
+		resp.Generation = g.generation
+		resp.ProtocolType = kmsg.StringPtr(g.protocolType)
+		resp.Protocol = kmsg.StringPtr(g.protocol)
+		resp.LeaderID = g.leader
+		resp.MemberID = m.memberID
+		if m.memberID == g.leader {
+			for _, om := range g.members {
+				sm := kmsg.NewJoinGroupResponseMember()
+				sm.MemberID = om.memberID
+				sm.InstanceID = om.instanceID
+				sm.ProtocolMetadata = om.metadata(g.protocol)
+				resp.Members = append(resp.Members, sm)
+			}
+		}
+		g.c.reply(creq, resp, nil)
+		g.atSessionTimeout(m, func() { g.expireMember(m) })
+	}
+}
+
+func (m *groupMember) metadata(protocol string) []byte {
+	for _, p := range m.join.Protocols {
+		if p.Name == protocol {
+			return p.Metadata
+		}
+	}
+	return nil
+}
+
+// atSessionTimeout (re)starts the member's session timer.
+func (g *group) atSessionTimeout(m *groupMember, fn func()) {
+	if m.t != nil {
+		m.t.Stop()
+	}
+	var t *time.Timer
+	t = g.c.timer(time.Duration(m.join.SessionTimeoutMillis)*time.Millisecond, func() {
+		if m.t == t {
+			fn()
+		}
+	})
+	m.t = t
+}
+
+// refreshSession restarts a member's session timer after any activity.
+func (g *group) refreshSession(m *groupMember) {
+	g.atSessionTimeout(m, func() { g.expireMember(m) })
+}
+
+// expireMember removes a member whose session timed out.
+func (g *group) expireMember(m *groupMember) {
+	if g.members[m.memberID] != m || m.waitingJoin != nil {
+		return
+	}
+	g.removeMember(m)
+	g.membersChanged()
+}
+
+// removeMember removes a member from the group, replying to anything the
+// member is waiting on.
+func (g *group) removeMember(m *groupMember) {
+	delete(g.members, m.memberID)
+	if m.t != nil {
+		m.t.Stop()
+		m.t = nil
+	}
+	if m.waitingJoin != nil {
+		creq := *m.waitingJoin
+		m.waitingJoin = nil
+		resp := creq.kreq.ResponseKind().(*kmsg.JoinGroupResponse)
+		resp.Generation = -1
+		resp.ErrorCode = kerr.UnknownMemberID.Code
+		g.c.reply(creq, resp, nil)
+	}
+	g.failSync(m, kerr.UnknownMemberID.Code)
+}
+
+// membersChanged is called after members leave or expire, moving the group
+// to Empty or triggering a rebalance as appropriate.
+func (g *group) membersChanged() {
+	switch {
+	case len(g.members) == 0 && g.state != groupPreparingRebalance:
+		g.generation++
+		g.state = groupEmpty
+		g.protocol = ""
+		g.protocolType = ""
+		g.leader = ""
+	case g.state == groupPreparingRebalance:
+		g.maybeCompleteRebalance()
+	default:
+		g.rebalance()
+	}
+}
+
+///////////
+// SYNCS //
+///////////
+
+func (gs *groups) handleSync(creq clientReq, req *kmsg.SyncGroupRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.SyncGroupResponse)
+
+	if gs.c.coordinator(req.Group) != creq.cc.b {
+		resp.ErrorCode = kerr.NotCoordinator.Code
+		return resp, nil
+	}
+	g := gs.get(req.Group, false)
+	if g == nil {
+		resp.ErrorCode = kerr.UnknownMemberID.Code
+		return resp, nil
+	}
+	m := g.members[req.MemberID]
+	switch {
+	case m == nil:
+		resp.ErrorCode = kerr.UnknownMemberID.Code
+		return resp, nil
+	case req.Generation != g.generation:
+		resp.ErrorCode = kerr.IllegalGeneration.Code
+		return resp, nil
+	case req.ProtocolType != nil && *req.ProtocolType != g.protocolType,
+		req.Protocol != nil && *req.Protocol != g.protocol:
+		resp.ErrorCode = kerr.InconsistentGroupProtocol.Code
+		return resp, nil
+	}
+	g.refreshSession(m)
+
+	switch g.state {
+	case groupPreparingRebalance:
+		resp.ErrorCode = kerr.RebalanceInProgress.Code
+		return resp, nil
+	case groupStable:
+		resp.ProtocolType = kmsg.StringPtr(g.protocolType)
+		resp.Protocol = kmsg.StringPtr(g.protocol)
+		resp.MemberAssignment = m.assignment
+		return resp, nil
+	}
+
+	// CompletingRebalance: followers wait for the leader.
+	if m.waitingSync != nil {
+		g.failSync(m, kerr.RebalanceInProgress.Code)
+	}
+	m.waitingSync = &creq
+	if m.memberID != g.leader {
+		return nil, nil
+	}
+
+	for _, m := range g.members {
+		m.assignment = nil
+	}
+	for _, a := range req.GroupAssignment {
+		if am := g.members[a.MemberID]; am != nil {
+			am.assignment = a.MemberAssignment
+		}
+	}
+	g.state = groupStable
+	for _, m := range g.members {
+		if m.waitingSync == nil {
+			continue
+		}
+		creq := *m.waitingSync
+		m.waitingSync = nil
+		resp := creq.kreq.ResponseKind().(*kmsg.SyncGroupResponse)
+		resp.ProtocolType = kmsg.StringPtr(g.protocolType)
+		resp.Protocol = kmsg.StringPtr(g.protocol)
+		resp.MemberAssignment = m.assignment
+		g.c.reply(creq, resp, nil)
+	}
+	return nil, nil
+}
+
+// failSync replies to a member's waiting sync with the given error code.
+func (g *group) failSync(m *groupMember, errCode int16) {
+	if m.waitingSync == nil {
+		return
+	}
+	creq := *m.waitingSync
+	m.waitingSync = nil
+	resp := creq.kreq.ResponseKind().(*kmsg.SyncGroupResponse)
+	resp.ErrorCode = errCode
+	g.c.reply(creq, resp, nil)
+}
+
+////////////////////////
+// HEARTBEAT / LEAVES //
+////////////////////////
+
+func (gs *groups) handleHeartbeat(b *broker, req *kmsg.HeartbeatRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.HeartbeatResponse)
+
+	if gs.c.coordinator(req.Group) != b {
+		resp.ErrorCode = kerr.NotCoordinator.Code
+		return resp, nil
+	}
+	g := gs.get(req.Group, false)
+	if g == nil {
+		resp.ErrorCode = kerr.UnknownMemberID.Code
+		return resp, nil
+	}
+	m := g.members[req.MemberID]
+	switch {
+	case m == nil:
+		resp.ErrorCode = kerr.UnknownMemberID.Code
+	case req.Generation != g.generation:
+		resp.ErrorCode = kerr.IllegalGeneration.Code
+	case g.state == groupPreparingRebalance:
+		resp.ErrorCode = kerr.RebalanceInProgress.Code
+	}
+	if m != nil && m.waitingJoin == nil {
+		g.refreshSession(m)
+	}
+	return resp, nil
+}
+
+func (gs *groups) handleLeave(b *broker, req *kmsg.LeaveGroupRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.LeaveGroupResponse)
+
+	if gs.c.coordinator(req.Group) != b {
+		resp.ErrorCode = kerr.NotCoordinator.Code
+		return resp, nil
+	}
+	g := gs.get(req.Group, false)
+	if g == nil {
+		resp.ErrorCode = kerr.UnknownMemberID.Code
+		return resp, nil
+	}
+
+	if req.Version < 3 {
+		req.Members = append(req.Members, kmsg.LeaveGroupRequestMember{MemberID: req.MemberID})
+		defer func() { resp.ErrorCode = resp.Members[0].ErrorCode; resp.Members = nil }()
+	}
+
+	var removed bool
+	for _, rm := range req.Members {
+		sm := kmsg.NewLeaveGroupResponseMember()
+		sm.MemberID = rm.MemberID
+		sm.InstanceID = rm.InstanceID
+		if m := g.members[rm.MemberID]; m != nil {
+			g.removeMember(m)
+			removed = true
+		} else if m := g.pending[rm.MemberID]; m != nil {
+			delete(g.pending, rm.MemberID)
+			m.t.Stop()
+		} else {
+			sm.ErrorCode = kerr.UnknownMemberID.Code
+		}
+		resp.Members = append(resp.Members, sm)
+	}
+	if removed {
+		g.membersChanged()
+	}
+	return resp, nil
+}
+
+/////////////
+// OFFSETS //
+/////////////
+
+func (gs *groups) handleOffsetCommit(b *broker, req *kmsg.OffsetCommitRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.OffsetCommitResponse)
+
+	fill := func(errCode int16) (kmsg.Response, error) {
+		for _, rt := range req.Topics {
+			st := kmsg.NewOffsetCommitResponseTopic()
+			st.Topic = rt.Topic
+			for _, rp := range rt.Partitions {
+				sp := kmsg.NewOffsetCommitResponseTopicPartition()
+				sp.Partition = rp.Partition
+				sp.ErrorCode = errCode
+				st.Partitions = append(st.Partitions, sp)
+			}
+			resp.Topics = append(resp.Topics, st)
+		}
+		return resp, nil
+	}
+
+	if gs.c.coordinator(req.Group) != b {
+		return fill(kerr.NotCoordinator.Code)
+	}
+	if req.Group == "" {
+		return fill(kerr.InvalidGroupID.Code)
+	}
+
+	// A generation of -1 is a commit from outside of the group, which is
+	// only allowed if the group has no members.
+	g := gs.get(req.Group, req.Generation < 0)
+	if g == nil {
+		return fill(kerr.UnknownMemberID.Code)
+	}
+	if req.Generation < 0 {
+		if len(g.members) > 0 {
+			return fill(kerr.UnknownMemberID.Code)
+		}
+	} else {
+		m := g.members[req.MemberID]
+		switch {
+		case m == nil:
+			return fill(kerr.UnknownMemberID.Code)
+		case req.Generation != g.generation:
+			return fill(kerr.IllegalGeneration.Code)
+		case g.state == groupCompletingRebalance:
+			return fill(kerr.RebalanceInProgress.Code)
+		}
+		if m.waitingJoin == nil {
+			g.refreshSession(m)
+		}
+	}
+
+	for _, rt := range req.Topics {
+		for _, rp := range rt.Partitions {
+			g.commit(rt.Topic, rp.Partition, committedOffset{
+				offset:      rp.Offset,
+				leaderEpoch: rp.LeaderEpoch,
+				metadata:    rp.Metadata,
+			})
+		}
+	}
+	return fill(0)
+}
+
+func (g *group) commit(t string, p int32, o committedOffset) {
+	ps := g.commits[t]
+	if ps == nil {
+		ps = make(map[int32]committedOffset)
+		g.commits[t] = ps
+	}
+	ps[p] = o
+}
+
+func (gs *groups) handleOffsetFetch(b *broker, req *kmsg.OffsetFetchRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.OffsetFetchResponse)
+
+	if req.Version <= 7 {
+		rg := kmsg.NewOffsetFetchRequestGroup()
+		rg.Group = req.Group
+		for _, rt := range req.Topics {
+			rgt := kmsg.NewOffsetFetchRequestGroupTopic()
+			rgt.Topic = rt.Topic
+			rgt.Partitions = rt.Partitions
+			rg.Topics = append(rg.Topics, rgt)
+		}
+		if req.Topics == nil {
+			rg.Topics = nil
+		}
+		req.Groups = append(req.Groups, rg)
+
+		defer func() {
+			g0 := resp.Groups[0]
+			resp.ErrorCode = g0.ErrorCode
+			for _, t := range g0.Topics {
+				st := kmsg.NewOffsetFetchResponseTopic()
+				st.Topic = t.Topic
+				for _, p := range t.Partitions {
+					sp := kmsg.NewOffsetFetchResponseTopicPartition()
+					sp.Partition = p.Partition
+					sp.Offset = p.Offset
+					sp.LeaderEpoch = p.LeaderEpoch
+					sp.Metadata = p.Metadata
+					sp.ErrorCode = p.ErrorCode
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			resp.Groups = nil
+		}()
+	}
+
+	for _, rg := range req.Groups {
+		resp.Groups = append(resp.Groups, gs.fetchOffsets(b, req, rg))
+	}
+	return resp, nil
+}
+
+func (gs *groups) fetchOffsets(b *broker, req *kmsg.OffsetFetchRequest, rg kmsg.OffsetFetchRequestGroup) kmsg.OffsetFetchResponseGroup {
+	sg := kmsg.NewOffsetFetchResponseGroup()
+	sg.Group = rg.Group
+
+	// Prior to v2, errors were returned per partition rather than for the
+	// whole group.
+	errp := func(errCode int16) kmsg.OffsetFetchResponseGroup {
+		if req.Version >= 2 {
+			sg.ErrorCode = errCode
+			return sg
+		}
+		for _, rt := range rg.Topics {
+			st := kmsg.NewOffsetFetchResponseGroupTopic()
+			st.Topic = rt.Topic
+			for _, p := range rt.Partitions {
+				sp := kmsg.NewOffsetFetchResponseGroupTopicPartition()
+				sp.Partition = p
+				sp.ErrorCode = errCode
+				st.Partitions = append(st.Partitions, sp)
+			}
+			sg.Topics = append(sg.Topics, st)
+		}
+		return sg
+	}
+
+	if gs.c.coordinator(rg.Group) != b {
+		return errp(kerr.NotCoordinator.Code)
+	}
+
+	var (
+		g       = gs.get(rg.Group, false)
+		commits map[string]map[int32]committedOffset
+	)
+	if g != nil {
+		commits = g.commits
+	}
+	unstable := make(map[string]map[int32]bool)
+	if req.RequireStable {
+		for _, pinfo := range gs.c.pids.ids {
+			for t, ps := range pinfo.txOffsets[rg.Group] {
+				for p := range ps {
+					if unstable[t] == nil {
+						unstable[t] = make(map[int32]bool)
+					}
+					unstable[t][p] = true
+				}
+			}
+		}
+	}
+
+	addp := func(st *kmsg.OffsetFetchResponseGroupTopic, p int32) {
+		sp := kmsg.NewOffsetFetchResponseGroupTopicPartition()
+		sp.Partition = p
+		sp.Offset = -1
+		sp.LeaderEpoch = -1
+		if unstable[st.Topic][p] {
+			sp.ErrorCode = kerr.UnstableOffsetCommit.Code
+		} else if o, ok := commits[st.Topic][p]; ok {
+			sp.Offset = o.offset
+			sp.LeaderEpoch = o.leaderEpoch
+			sp.Metadata = o.metadata
+		}
+		st.Partitions = append(st.Partitions, sp)
+	}
+
+	if rg.Topics == nil {
+		for t, ps := range commits {
+			st := kmsg.NewOffsetFetchResponseGroupTopic()
+			st.Topic = t
+			for p := range ps {
+				addp(&st, p)
+			}
+			sg.Topics = append(sg.Topics, st)
+		}
+		return sg
+	}
+	for _, rt := range rg.Topics {
+		st := kmsg.NewOffsetFetchResponseGroupTopic()
+		st.Topic = rt.Topic
+		for _, p := range rt.Partitions {
+			addp(&st, p)
+		}
+		sg.Topics = append(sg.Topics, st)
+	}
+	return sg
+}
+
+func (gs *groups) handleTxnOffsetCommit(b *broker, req *kmsg.TxnOffsetCommitRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.TxnOffsetCommitResponse)
+
+	fill := func(errCode int16) (kmsg.Response, error) {
+		for _, rt := range req.Topics {
+			st := kmsg.NewTxnOffsetCommitResponseTopic()
+			st.Topic = rt.Topic
+			for _, rp := range rt.Partitions {
+				sp := kmsg.NewTxnOffsetCommitResponseTopicPartition()
+				sp.Partition = rp.Partition
+				sp.ErrorCode = errCode
+				st.Partitions = append(st.Partitions, sp)
+			}
+			resp.Topics = append(resp.Topics, st)
+		}
+		return resp, nil
+	}
+
+	if gs.c.coordinator(req.Group) != b {
+		return fill(kerr.NotCoordinator.Code)
+	}
+	pinfo, exists := gs.c.pids.txs[req.TransactionalID]
+	switch {
+	case !exists || pinfo.id != req.ProducerID:
+		return fill(kerr.InvalidProducerIDMapping.Code)
+	case pinfo.epoch != req.ProducerEpoch:
+		return fill(kerr.InvalidProducerEpoch.Code)
+	case !pinfo.inTx:
+		return fill(kerr.InvalidTxnState.Code)
+	}
+	if _, ok := pinfo.txGroups[req.Group]; !ok {
+		return fill(kerr.InvalidTxnState.Code)
+	}
+
+	// KIP-447: v3+ requests include the group generation and member ID,
+	// which we validate if the group is active.
+	if g := gs.get(req.Group, false); g != nil && req.Generation >= 0 && req.MemberID != "" {
+		if g.members[req.MemberID] == nil {
+			return fill(kerr.UnknownMemberID.Code)
+		}
+		if req.Generation != g.generation {
+			return fill(kerr.IllegalGeneration.Code)
+		}
+	}
+
+	ts := pinfo.txOffsets[req.Group]
+	if ts == nil {
+		ts = make(map[string]map[int32]committedOffset)
+		pinfo.txOffsets[req.Group] = ts
+	}
+	for _, rt := range req.Topics {
+		ps := ts[rt.Topic]
+		if ps == nil {
+			ps = make(map[int32]committedOffset)
+			ts[rt.Topic] = ps
+		}
+		for _, rp := range rt.Partitions {
+			ps[rp.Partition] = committedOffset{
+				offset:      rp.Offset,
+				leaderEpoch: rp.LeaderEpoch,
+				metadata:    rp.Metadata,
+			}
+		}
+	}
+	return fill(0)
+}
+
+// commitTxnOffsets applies offsets that were committed in a transaction.
+func (gs *groups) commitTxnOffsets(group string, ts map[string]map[int32]committedOffset) {
+	g := gs.get(group, true)
+	for t, ps := range ts {
+		for p, o := range ps {
+			g.commit(t, p, o)
+		}
+	}
+}
+
+//////////////////////
+// DESCRIBE / LISTS //
+//////////////////////
+
+func (gs *groups) handleDescribe(b *broker, req *kmsg.DescribeGroupsRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.DescribeGroupsResponse)
+
+	for _, rg := range req.Groups {
+		sg := kmsg.NewDescribeGroupsResponseGroup()
+		sg.Group = rg
+		if gs.c.coordinator(rg) != b {
+			sg.ErrorCode = kerr.NotCoordinator.Code
+			resp.Groups = append(resp.Groups, sg)
+			continue
+		}
+		g := gs.get(rg, false)
+		if g == nil {
+			sg.State = groupDead.String()
+			resp.Groups = append(resp.Groups, sg)
+			continue
+		}
+		sg.State = g.state.String()
+		sg.ProtocolType = g.protocolType
+		if g.state == groupStable {
+			sg.Protocol = g.protocol
+		}
+		for _, m := range g.members {
+			sm := kmsg.NewDescribeGroupsResponseGroupMember()
+			sm.MemberID = m.memberID
+			sm.InstanceID = m.instanceID
+			sm.ClientID = m.clientID
+			sm.ClientHost = m.clientHost
+			if g.state == groupStable {
+				sm.ProtocolMetadata = m.metadata(g.protocol)
+				sm.MemberAssignment = m.assignment
+			}
+			sg.Members = append(sg.Members, sm)
+		}
+		resp.Groups = append(resp.Groups, sg)
+	}
+	return resp, nil
+}
+
+func (gs *groups) handleList(b *broker, req *kmsg.ListGroupsRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.ListGroupsResponse)
+
+	states := make(map[string]bool)
+	for _, s := range req.StatesFilter {
+		states[s] = true
+	}
+	for _, g := range gs.gs {
+		if gs.c.coordinator(g.name) != b {
+			continue
+		}
+		if len(states) > 0 && !states[g.state.String()] {
+			continue
+		}
+		sg := kmsg.NewListGroupsResponseGroup()
+		sg.Group = g.name
+		sg.ProtocolType = g.protocolType
+		sg.GroupState = g.state.String()
+		resp.Groups = append(resp.Groups, sg)
+	}
+	return resp, nil
+}
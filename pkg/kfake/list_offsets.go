@@ -0,0 +1,93 @@
+package kfake
+
+import (
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func (c *Cluster) handleListOffsets(b *broker, req *kmsg.ListOffsetsRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.ListOffsetsResponse)
+
+	readCommitted := req.IsolationLevel == 1
+
+	for _, rt := range req.Topics {
+		st := kmsg.NewListOffsetsResponseTopic()
+		st.Topic = rt.Topic
+		for _, rp := range rt.Partitions {
+			sp := kmsg.NewListOffsetsResponseTopicPartition()
+			sp.Partition = rp.Partition
+
+			pd := c.data.getp(rt.Topic, rp.Partition)
+			switch {
+			case pd == nil:
+				sp.ErrorCode = kerr.UnknownTopicOrPartition.Code
+			case pd.leader != b:
+				sp.ErrorCode = kerr.NotLeaderForPartition.Code
+			case rp.CurrentLeaderEpoch >= 0 && rp.CurrentLeaderEpoch < pd.epoch:
+				sp.ErrorCode = kerr.FencedLeaderEpoch.Code
+			case rp.CurrentLeaderEpoch > pd.epoch:
+				sp.ErrorCode = kerr.UnknownLeaderEpoch.Code
+			}
+			if sp.ErrorCode != 0 {
+				st.Partitions = append(st.Partitions, sp)
+				continue
+			}
+
+			sp.LeaderEpoch = pd.epoch
+			switch rp.Timestamp {
+			case -2:
+				sp.Offset = pd.logStartOffset
+			case -1:
+				sp.Offset = pd.highWatermark
+				if readCommitted {
+					sp.Offset = pd.lastStableOffset
+				}
+			default:
+				sp.Timestamp, sp.Offset = pd.searchTimestamp(rp.Timestamp)
+			}
+			if req.Version == 0 {
+				sp.OldStyleOffsets = []int64{sp.Offset}
+			}
+			st.Partitions = append(st.Partitions, sp)
+		}
+		resp.Topics = append(resp.Topics, st)
+	}
+
+	return resp, nil
+}
+
+// searchTimestamp returns the timestamp and offset of the first record whose
+// timestamp is at or after the requested timestamp. If no such record exists,
+// this returns -1 and the high watermark.
+//
+// Records within compressed batches are not inspected; for those, the first
+// offset of the first batch whose max timestamp is at or after the requested
+// timestamp is returned.
+func (pd *partData) searchTimestamp(ts int64) (int64, int64) {
+	for i := range pd.batches {
+		b := &pd.batches[i]
+		if b.MaxTimestamp < ts || b.FirstOffset < pd.logStartOffset {
+			continue
+		}
+		if b.Attributes&0x0007 != 0 {
+			return b.MaxTimestamp, b.FirstOffset
+		}
+		r := kbin.Reader{Src: b.Records}
+		for r.Ok() && len(r.Src) > 0 {
+			full := r.Src
+			l := r.Varint()
+			raw := full[:len(full)-len(r.Src)+int(l)]
+			r.Span(int(l))
+			var rec kmsg.Record
+			if err := rec.ReadFrom(raw); err != nil {
+				break
+			}
+			if rts := b.FirstTimestamp + int64(rec.TimestampDelta); rts >= ts {
+				return rts, b.FirstOffset + int64(rec.OffsetDelta)
+			}
+		}
+		return b.MaxTimestamp, b.FirstOffset
+	}
+	return -1, pd.highWatermark
+}
@@ -0,0 +1,80 @@
+package kfake
+
+import (
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func (c *Cluster) handleMetadata(req *kmsg.MetadataRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.MetadataResponse)
+
+	for _, b := range c.bs {
+		sb := kmsg.NewMetadataResponseBroker()
+		sb.NodeID = b.node
+		sb.Host, sb.Port = b.hostport()
+		resp.Brokers = append(resp.Brokers, sb)
+	}
+	resp.ClusterID = &c.cfg.clusterID
+	resp.ControllerID = c.controller.node
+
+	donet := func(t string, id [16]byte, errCode int16) *kmsg.MetadataResponseTopic {
+		st := kmsg.NewMetadataResponseTopic()
+		if t != "" {
+			st.Topic = kmsg.StringPtr(t)
+		}
+		st.TopicID = id
+		st.ErrorCode = errCode
+		resp.Topics = append(resp.Topics, st)
+		return &resp.Topics[len(resp.Topics)-1]
+	}
+	okp := func(st *kmsg.MetadataResponseTopic, pd *partData) {
+		sp := kmsg.NewMetadataResponseTopicPartition()
+		sp.Partition = pd.p
+		sp.Leader = pd.leader.node
+		sp.LeaderEpoch = pd.epoch
+		sp.Replicas = c.data.replicas(pd)
+		sp.ISR = sp.Replicas
+		st.Partitions = append(st.Partitions, sp)
+	}
+	okt := func(t string) {
+		st := donet(t, c.data.t2id[t], 0)
+		for _, pd := range c.data.tps[t] {
+			okp(st, pd)
+		}
+	}
+
+	// In v0, an empty topic array means all topics; in v1+, a null array
+	// means all topics.
+	if req.Topics == nil || req.Version == 0 && len(req.Topics) == 0 {
+		for t := range c.data.tps {
+			okt(t)
+		}
+		return resp, nil
+	}
+
+	for _, rt := range req.Topics {
+		var t string
+		if rt.Topic != nil {
+			t = *rt.Topic
+		} else {
+			var ok bool
+			if t, ok = c.data.id2t[rt.TopicID]; !ok {
+				donet("", rt.TopicID, kerr.UnknownTopicID.Code)
+				continue
+			}
+		}
+		if t == "" {
+			donet(t, rt.TopicID, kerr.InvalidTopicException.Code)
+			continue
+		}
+		if _, ok := c.data.tps[t]; !ok {
+			if !c.cfg.allowAutoTopic || !req.AllowAutoTopicCreation {
+				donet(t, rt.TopicID, kerr.UnknownTopicOrPartition.Code)
+				continue
+			}
+			c.data.mkt(t, c.cfg.defaultNumParts, -1, nil)
+		}
+		okt(t)
+	}
+	return resp, nil
+}
@@ -0,0 +1,363 @@
+package kfake
+
+import (
+	"math"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// pids tracks all producer IDs and transactional IDs in the cluster.
+type pids struct {
+	c *Cluster
+
+	ids    map[int64]*pidinfo
+	txs    map[string]*pidinfo
+	nextID int64
+}
+
+// pidinfo is the state for a single producer ID.
+type pidinfo struct {
+	pids *pids
+
+	id    int64
+	epoch int16
+	txid  string // empty if not transactional
+
+	seqs map[string]map[int32]*pidseqs
+
+	txTimeout int32
+	inTx      bool
+	txSeq     int // bumped every transaction, to ignore stale timeouts
+	txTimer   *time.Timer
+	txParts   map[string]map[int32]*partData
+	txGroups  map[string]struct{}
+	txOffsets map[string]map[string]map[int32]committedOffset // group => topic => partition
+}
+
+// pidseqs tracks the last five batches a producer wrote to a partition, which
+// is what Kafka uses to deduplicate retried batches.
+type pidseqs struct {
+	epoch int16
+	seqs  []pidseq
+}
+
+type pidseq struct {
+	first  int32
+	last   int32
+	offset int64
+}
+
+// maxTxnTimeout mirrors Kafka's default transaction.max.timeout.ms.
+const maxTxnTimeout = 15 * 60 * 1000
+
+func (pids *pids) create(txid string) *pidinfo {
+	id := pids.nextID
+	pids.nextID++
+	pinfo := &pidinfo{
+		pids: pids,
+		id:   id,
+		txid: txid,
+	}
+	pids.ids[id] = pinfo
+	if txid != "" {
+		pids.txs[txid] = pinfo
+	}
+	return pinfo
+}
+
+// bumpEpoch bumps the producer's epoch, moving to a new producer ID if the
+// epoch would overflow. All sequence numbers are reset.
+func (pinfo *pidinfo) bumpEpoch() *pidinfo {
+	pids := pinfo.pids
+	if pinfo.epoch == math.MaxInt16-1 {
+		delete(pids.ids, pinfo.id)
+		npinfo := pids.create(pinfo.txid)
+		npinfo.txTimeout = pinfo.txTimeout
+		return npinfo
+	}
+	pinfo.epoch++
+	pinfo.seqs = nil
+	return pinfo
+}
+
+func (pids *pids) handleInitProducerID(b *broker, req *kmsg.InitProducerIDRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.InitProducerIDResponse)
+
+	if req.TransactionalID == nil || *req.TransactionalID == "" {
+		pinfo := pids.create("")
+		resp.ProducerID = pinfo.id
+		resp.ProducerEpoch = pinfo.epoch
+		return resp, nil
+	}
+
+	txid := *req.TransactionalID
+	if pids.c.coordinator(txid) != b {
+		resp.ErrorCode = kerr.NotCoordinator.Code
+		return resp, nil
+	}
+	if req.TransactionTimeoutMillis <= 0 || req.TransactionTimeoutMillis > maxTxnTimeout {
+		resp.ErrorCode = kerr.InvalidTransactionTimeout.Code
+		return resp, nil
+	}
+
+	pinfo, exists := pids.txs[txid]
+	if !exists {
+		pinfo = pids.create(txid)
+	} else {
+		// KIP-360: if the client provides its current producer ID and
+		// epoch, they must match what we have.
+		if req.ProducerID >= 0 && (req.ProducerID != pinfo.id || req.ProducerEpoch != pinfo.epoch) {
+			resp.ErrorCode = kerr.InvalidProducerEpoch.Code
+			return resp, nil
+		}
+		if pinfo.inTx {
+			pinfo.endTx(false)
+		}
+		pinfo = pinfo.bumpEpoch()
+	}
+	pinfo.txTimeout = req.TransactionTimeoutMillis
+
+	resp.ProducerID = pinfo.id
+	resp.ProducerEpoch = pinfo.epoch
+	return resp, nil
+}
+
+// checkTx validates a transactional request's ID and producer ID / epoch,
+// returning the producer or an error code.
+func (pids *pids) checkTx(b *broker, txid string, id int64, epoch int16) (*pidinfo, int16) {
+	if pids.c.coordinator(txid) != b {
+		return nil, kerr.NotCoordinator.Code
+	}
+	pinfo, exists := pids.txs[txid]
+	if !exists || pinfo.id != id {
+		return nil, kerr.InvalidProducerIDMapping.Code
+	}
+	if pinfo.epoch != epoch {
+		return nil, kerr.InvalidProducerEpoch.Code
+	}
+	return pinfo, 0
+}
+
+func (pids *pids) handleAddPartitionsToTxn(b *broker, req *kmsg.AddPartitionsToTxnRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.AddPartitionsToTxnResponse)
+
+	done := func(errCode func(t string, p int32) int16) (kmsg.Response, error) {
+		for _, rt := range req.Topics {
+			st := kmsg.NewAddPartitionsToTxnResponseTopic()
+			st.Topic = rt.Topic
+			for _, p := range rt.Partitions {
+				sp := kmsg.NewAddPartitionsToTxnResponseTopicPartition()
+				sp.Partition = p
+				sp.ErrorCode = errCode(rt.Topic, p)
+				st.Partitions = append(st.Partitions, sp)
+			}
+			resp.Topics = append(resp.Topics, st)
+		}
+		return resp, nil
+	}
+
+	pinfo, errCode := pids.checkTx(b, req.TransactionalID, req.ProducerID, req.ProducerEpoch)
+	if errCode != 0 {
+		return done(func(string, int32) int16 { return errCode })
+	}
+
+	// If any partition does not exist, no partition is added.
+	var anyUnknown bool
+	for _, rt := range req.Topics {
+		for _, p := range rt.Partitions {
+			if pids.c.data.getp(rt.Topic, p) == nil {
+				anyUnknown = true
+			}
+		}
+	}
+	if anyUnknown {
+		return done(func(t string, p int32) int16 {
+			if pids.c.data.getp(t, p) == nil {
+				return kerr.UnknownTopicOrPartition.Code
+			}
+			return kerr.OperationNotAttempted.Code
+		})
+	}
+
+	pinfo.beginTx()
+	for _, rt := range req.Topics {
+		for _, p := range rt.Partitions {
+			ps := pinfo.txParts[rt.Topic]
+			if ps == nil {
+				ps = make(map[int32]*partData)
+				pinfo.txParts[rt.Topic] = ps
+			}
+			ps[p] = pids.c.data.getp(rt.Topic, p)
+		}
+	}
+	return done(func(string, int32) int16 { return 0 })
+}
+
+func (pids *pids) handleAddOffsetsToTxn(b *broker, req *kmsg.AddOffsetsToTxnRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.AddOffsetsToTxnResponse)
+
+	pinfo, errCode := pids.checkTx(b, req.TransactionalID, req.ProducerID, req.ProducerEpoch)
+	if errCode != 0 {
+		resp.ErrorCode = errCode
+		return resp, nil
+	}
+	pinfo.beginTx()
+	pinfo.txGroups[req.Group] = struct{}{}
+	return resp, nil
+}
+
+func (pids *pids) handleEndTxn(b *broker, req *kmsg.EndTxnRequest) (kmsg.Response, error) {
+	resp := req.ResponseKind().(*kmsg.EndTxnResponse)
+
+	pinfo, errCode := pids.checkTx(b, req.TransactionalID, req.ProducerID, req.ProducerEpoch)
+	if errCode != 0 {
+		resp.ErrorCode = errCode
+		return resp, nil
+	}
+	if !pinfo.inTx {
+		resp.ErrorCode = kerr.InvalidTxnState.Code
+		return resp, nil
+	}
+	pinfo.endTx(req.Commit)
+	return resp, nil
+}
+
+// beginTx begins a transaction if one is not yet in progress, starting the
+// transaction timeout timer.
+func (pinfo *pidinfo) beginTx() {
+	if pinfo.inTx {
+		return
+	}
+	pinfo.inTx = true
+	pinfo.txSeq++
+	pinfo.txParts = make(map[string]map[int32]*partData)
+	pinfo.txGroups = make(map[string]struct{})
+	pinfo.txOffsets = make(map[string]map[string]map[int32]committedOffset)
+
+	seq := pinfo.txSeq
+	pinfo.txTimer = pinfo.pids.c.timer(time.Duration(pinfo.txTimeout)*time.Millisecond, func() {
+		if !pinfo.inTx || pinfo.txSeq != seq {
+			return
+		}
+		// Kafka aborts a timed out transaction and bumps the epoch,
+		// fencing the producer that was using it.
+		pinfo.endTx(false)
+		pinfo.bumpEpoch()
+	})
+}
+
+// endTx writes control markers to every partition in the transaction and, if
+// committing, commits any transactional offsets.
+func (pinfo *pidinfo) endTx(commit bool) {
+	if pinfo.txTimer != nil {
+		pinfo.txTimer.Stop()
+		pinfo.txTimer = nil
+	}
+	for _, ps := range pinfo.txParts {
+		for _, pd := range ps {
+			pd.pushControl(pinfo.id, pinfo.epoch, commit)
+		}
+	}
+	if commit {
+		for g, ts := range pinfo.txOffsets {
+			pinfo.pids.c.groups.commitTxnOffsets(g, ts)
+		}
+	}
+	pinfo.inTx = false
+	pinfo.txParts = nil
+	pinfo.txGroups = nil
+	pinfo.txOffsets = nil
+}
+
+// checkProduce validates the producer ID and epoch of a batch being produced
+// to a partition.
+func (pids *pids) checkProduce(pd *partData, txnal bool, batch *kmsg.RecordBatch) (*pidinfo, int16) {
+	pinfo, exists := pids.ids[batch.ProducerID]
+	if !exists {
+		if txnal {
+			return nil, kerr.InvalidProducerIDMapping.Code
+		}
+		return nil, kerr.UnknownProducerID.Code
+	}
+	if batch.ProducerEpoch != pinfo.epoch {
+		return nil, kerr.InvalidProducerEpoch.Code
+	}
+	if txnal {
+		if !pinfo.inTx || pinfo.txParts[pd.t][pd.p] == nil {
+			return nil, kerr.InvalidTxnState.Code
+		}
+	}
+	return pinfo, 0
+}
+
+func (pinfo *pidinfo) pseqs(pd *partData) *pidseqs {
+	ps := pinfo.seqs[pd.t]
+	if ps == nil {
+		return nil
+	}
+	return ps[pd.p]
+}
+
+// dupOffset returns the offset a batch was originally written at if the batch
+// is a retry of one of the last five batches.
+func (pinfo *pidinfo) dupOffset(pd *partData, batch *kmsg.RecordBatch) (int64, bool) {
+	s := pinfo.pseqs(pd)
+	if s == nil || s.epoch != batch.ProducerEpoch {
+		return 0, false
+	}
+	last := batch.FirstSequence + batch.LastOffsetDelta
+	for _, seq := range s.seqs {
+		if seq.first == batch.FirstSequence && seq.last == last {
+			return seq.offset, true
+		}
+	}
+	return 0, false
+}
+
+// checkSeq ensures a batch's sequence number immediately follows the prior
+// batch's sequence number.
+func (pinfo *pidinfo) checkSeq(pd *partData, batch *kmsg.RecordBatch) int16 {
+	s := pinfo.pseqs(pd)
+	if s == nil || s.epoch != batch.ProducerEpoch || len(s.seqs) == 0 {
+		if batch.FirstSequence != 0 {
+			return kerr.OutOfOrderSequenceNumber.Code
+		}
+		return 0
+	}
+	last := s.seqs[len(s.seqs)-1].last
+	expected := last + 1
+	if last == math.MaxInt32 {
+		expected = 0
+	}
+	if batch.FirstSequence != expected {
+		return kerr.OutOfOrderSequenceNumber.Code
+	}
+	return 0
+}
+
+// pushSeq tracks a successfully written batch.
+func (pinfo *pidinfo) pushSeq(pd *partData, batch *kmsg.RecordBatch, offset int64) {
+	if pinfo.seqs == nil {
+		pinfo.seqs = make(map[string]map[int32]*pidseqs)
+	}
+	ps := pinfo.seqs[pd.t]
+	if ps == nil {
+		ps = make(map[int32]*pidseqs)
+		pinfo.seqs[pd.t] = ps
+	}
+	s := ps[pd.p]
+	if s == nil || s.epoch != batch.ProducerEpoch {
+		s = &pidseqs{epoch: batch.ProducerEpoch}
+		ps[pd.p] = s
+	}
+	s.seqs = append(s.seqs, pidseq{
+		first:  batch.FirstSequence,
+		last:   batch.FirstSequence + batch.LastOffsetDelta,
+		offset: offset,
+	})
+	if len(s.seqs) > 5 {
+		s.seqs = s.seqs[1:]
+	}
+}
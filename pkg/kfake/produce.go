@@ -0,0 +1,123 @@
+package kfake
+
+import (
+	"hash/crc32"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func (c *Cluster) handleProduce(b *broker, req *kmsg.ProduceRequest) (kmsg.Response, error) {
+	var (
+		resp  = req.ResponseKind().(*kmsg.ProduceResponse)
+		tdone = make(map[string][]kmsg.ProduceResponseTopicPartition)
+	)
+
+	donep := func(t string, p kmsg.ProduceRequestTopicPartition, errCode int16) *kmsg.ProduceResponseTopicPartition {
+		sp := kmsg.NewProduceResponseTopicPartition()
+		sp.Partition = p.Partition
+		sp.ErrorCode = errCode
+		ps := tdone[t]
+		ps = append(ps, sp)
+		tdone[t] = ps
+		return &ps[len(ps)-1]
+	}
+	donet := func(t kmsg.ProduceRequestTopic, errCode int16) {
+		for _, p := range t.Partitions {
+			donep(t.Topic, p, errCode)
+		}
+	}
+	donets := func(errCode int16) {
+		for _, t := range req.Topics {
+			donet(t, errCode)
+		}
+	}
+	toresp := func() kmsg.Response {
+		for topic, partitions := range tdone {
+			st := kmsg.NewProduceResponseTopic()
+			st.Topic = topic
+			st.Partitions = partitions
+			resp.Topics = append(resp.Topics, st)
+		}
+		return resp
+	}
+
+	switch req.Acks {
+	case -1, 0, 1:
+	default:
+		donets(kerr.InvalidRequiredAcks.Code)
+		return toresp(), nil
+	}
+
+	for _, rt := range req.Topics {
+		for _, rp := range rt.Partitions {
+			pd := c.data.getp(rt.Topic, rp.Partition)
+			if pd == nil {
+				donep(rt.Topic, rp, kerr.UnknownTopicOrPartition.Code)
+				continue
+			}
+			if pd.leader != b {
+				donep(rt.Topic, rp, kerr.NotLeaderForPartition.Code)
+				continue
+			}
+
+			var batch kmsg.RecordBatch
+			if err := batch.ReadFrom(rp.Records); err != nil {
+				donep(rt.Topic, rp, kerr.CorruptMessage.Code)
+				continue
+			}
+			if batch.Magic != 2 {
+				donep(rt.Topic, rp, kerr.UnsupportedForMessageFormat.Code)
+				continue
+			}
+			if int(batch.Length)+12 != len(rp.Records) {
+				donep(rt.Topic, rp, kerr.CorruptMessage.Code)
+				continue
+			}
+			if uint32(batch.CRC) != crc32.Checksum(rp.Records[21:], crc32c) {
+				donep(rt.Topic, rp, kerr.CorruptMessage.Code)
+				continue
+			}
+			const attrTxn = 0x10
+			txnal := batch.Attributes&attrTxn != 0
+			if txnal && req.TransactionID == nil {
+				donep(rt.Topic, rp, kerr.InvalidTxnState.Code)
+				continue
+			}
+
+			var pinfo *pidinfo
+			if batch.ProducerID >= 0 {
+				var errCode int16
+				pinfo, errCode = c.pids.checkProduce(pd, txnal, &batch)
+				if errCode != 0 {
+					donep(rt.Topic, rp, errCode)
+					continue
+				}
+				if dup, ok := pinfo.dupOffset(pd, &batch); ok {
+					sp := donep(rt.Topic, rp, 0)
+					sp.BaseOffset = dup
+					sp.LogStartOffset = pd.logStartOffset
+					continue
+				}
+				if errCode := pinfo.checkSeq(pd, &batch); errCode != 0 {
+					donep(rt.Topic, rp, errCode)
+					continue
+				}
+			}
+
+			offset := pd.pushBatch(len(rp.Records), batch)
+			if pinfo != nil {
+				pinfo.pushSeq(pd, &batch, offset)
+				if txnal {
+					pd.trackTxn(batch.ProducerID, offset)
+				}
+			}
+
+			sp := donep(rt.Topic, rp, 0)
+			sp.BaseOffset = offset
+			sp.LogStartOffset = pd.logStartOffset
+		}
+	}
+
+	return toresp(), nil
+}
@@ -130,6 +130,7 @@ type cfg struct {
 	maxBytes       int32
 	maxPartBytes   int32
 	resetOffset    Offset
+	resetPolicy    func(string, int32) Offset
 	isolationLevel int8
 	keepControl    bool
 	rack           string
@@ -154,6 +155,8 @@ type cfg struct {
 	heartbeatInterval time.Duration
 	requireStable     bool
 
+	blockRebalanceOnPoll bool
+
 	onAssigned func(context.Context, *Client, map[string][]int32)
 	onRevoked  func(context.Context, *Client, map[string][]int32)
 	onLost     func(context.Context, *Client, map[string][]int32)
@@ -180,6 +183,14 @@ func (cfg *cfg) cooperative() bool {
 	return cooperative
 }
 
+// resetOffsetFor returns the offset to reset the given partition to.
+func (cfg *cfg) resetOffsetFor(topic string, partition int32) Offset {
+	if cfg.resetPolicy != nil {
+		return cfg.resetPolicy(topic, partition)
+	}
+	return cfg.resetOffset
+}
+
 func (cfg *cfg) validate() error {
 	if len(cfg.seedBrokers) == 0 {
 		return errors.New("config erroneously has no seed brokers")
@@ -319,9 +330,6 @@ func (cfg *cfg) validate() error {
 	}
 
 	if len(cfg.group) > 0 {
-		if len(cfg.topics) == 0 {
-			return errors.New("unable to consume from a group when no topics are specified")
-		}
 		if len(cfg.partitions) != 0 {
 			return errors.New("invalid direct-partition consuming option when consuming as a group")
 		}
@@ -1106,11 +1114,33 @@ func MaxConcurrentFetches(n int) ConsumerOpt {
 // partition (for direct partition consuming), or when a fetch sees an
 // OffsetOutOfRange error, overriding the default ConsumeStartOffset.
 //
+// If the offset is a timestamp offset (AfterMilli or BeforeMilli), the same
+// timestamp is listed every time the offset is used, including when resetting
+// after an OffsetOutOfRange error.
+//
+// To not reset at all and instead be notified of partitions that need
+// resetting, use NoResetOffset. To use a different offset per topic or
+// partition, use ConsumeResetPolicy.
+//
 // Defaults to: NewOffset().AtStart() / Earliest Offset
 func ConsumeResetOffset(offset Offset) ConsumerOpt {
 	return consumerOpt{func(cfg *cfg) { cfg.resetOffset = offset }}
 }
 
+// ConsumeResetPolicy sets a function that returns the offset to reset a
+// partition to, overriding ConsumeResetOffset. The function is called in
+// every case that ConsumeResetOffset is used: when a group has no commit for
+// a partition, when a direct consumer begins consuming a partition of a
+// topic, and when a fetch sees an OffsetOutOfRange error.
+//
+// The function can return NoResetOffset for partitions that should not be
+// reset; see NoResetOffset for how those partitions are signaled. This
+// function is called from internal goroutines, so it must be safe for
+// concurrent use and must not block.
+func ConsumeResetPolicy(fn func(topic string, partition int32) Offset) ConsumerOpt {
+	return consumerOpt{func(cfg *cfg) { cfg.resetPolicy = fn }}
+}
+
 // Rack specifies where the client is physically located and changes fetch
 // requests to consume from the closest replica as opposed to the leader
 // replica.
@@ -1152,6 +1182,10 @@ func KeepControlRecords() ConsumerOpt {
 //
 // By default, consuming will start at the beginning of partitions. To change
 // this, use the ConsumeResetOffset option.
+//
+// Topics can be added or removed while consuming with AddConsumeTopics and
+// RemoveConsumeTopics. If you want to directly consume but do not know any
+// topics yet, this option can be used with no topics.
 func ConsumeTopics(topics ...string) ConsumerOpt {
 	return consumerOpt{func(cfg *cfg) {
 		cfg.topics = make(map[string]*regexp.Regexp, len(topics))
@@ -1199,6 +1233,10 @@ func ConsumeRegex() ConsumerOpt {
 // client shuts down, you should issue one final synchronous commit before
 // leaving the group (because you will not be polling again, and you are not
 // waiting for an autocommit).
+//
+// Topics for the group do not need to be known when creating the client: the
+// client does not join the group until it has topics to consume, which can
+// be added later with AddConsumeTopics.
 func ConsumerGroup(group string) GroupOpt {
 	return groupOpt{func(cfg *cfg) { cfg.group = group }}
 }
@@ -1250,6 +1288,26 @@ func RebalanceTimeout(timeout time.Duration) GroupOpt {
 	return groupOpt{func(cfg *cfg) { cfg.rebalanceTimeout = timeout }}
 }
 
+// BlockRebalanceOnPoll switches the client to block rebalances from revoking
+// partitions while the records from a poll are being processed.
+//
+// By default, a rebalance can revoke partitions at any time, including while
+// records from those partitions are being processed, which can lead to
+// duplicate processing once the partitions are consumed by another member.
+// With this option, once a poll returns anything, revoking waits until you
+// call AllowRebalance, and polls return nothing while partitions are being
+// revoked. You must call AllowRebalance after processing every poll, which
+// can conveniently be done at the end of your poll loop.
+//
+// To ensure this group member is not kicked from the group, revoking waits
+// for AllowRebalance for at most half of the RebalanceTimeout, leaving the
+// rest for OnPartitionsRevoked and rejoining. Processing a poll should take
+// much less time than this. Leaving the group, including when closing the
+// client, does not wait for AllowRebalance.
+func BlockRebalanceOnPoll() GroupOpt {
+	return groupOpt{func(cfg *cfg) { cfg.blockRebalanceOnPoll = true }}
+}
+
 // HeartbeatInterval sets how long a group member goes between heartbeats to
 // Kafka, overriding the default 3,000ms.
 //
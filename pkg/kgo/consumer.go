@@ -2,7 +2,9 @@ package kgo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,10 +18,30 @@ type Offset struct {
 	at           int64
 	relative     int64
 	epoch        int32
-	currentEpoch int32 // set by us when mapping offsets to brokers
+	currentEpoch int32     // set by us when mapping offsets to brokers
+	milli        milliKind // if non-zero, at is a millisecond timestamp to list
+	noReset      bool      // if true, this offset fails rather than resets; see NoResetOffset
 }
 
+// milliKind is how an Offset uses a millisecond timestamp.
+type milliKind int8
+
+const (
+	milliNone   milliKind = iota // the offset is not a timestamp
+	milliAfter                   // first record at or after the timestamp
+	milliBefore                  // last record before the timestamp
+)
+
 func (o Offset) MarshalJSON() ([]byte, error) {
+	if o.noReset {
+		return []byte(`{"NoReset":true}`), nil
+	}
+	switch o.milli {
+	case milliAfter:
+		return []byte(fmt.Sprintf(`{"AfterMilli":%d,"Relative":%d,"CurrentEpoch":%d}`, o.at, o.relative, o.currentEpoch)), nil
+	case milliBefore:
+		return []byte(fmt.Sprintf(`{"BeforeMilli":%d,"Relative":%d,"CurrentEpoch":%d}`, o.at, o.relative, o.currentEpoch)), nil
+	}
 	if o.relative == 0 {
 		return []byte(fmt.Sprintf(`{"At":%d,"Epoch":%d,"CurrentEpoch":%d}`, o.at, o.epoch, o.currentEpoch)), nil
 	}
@@ -37,10 +59,34 @@ func NewOffset() Offset {
 	}
 }
 
+// NoResetOffset returns an offset to use from ConsumeResetOffset or
+// ConsumeResetPolicy when a partition should not be reset at all.
+//
+// Rather than resetting, the client injects an error for the partition into
+// the next poll, which can be seen in Fetches.Errors. If a partition has no
+// offset to begin consuming from (a group has no commit, or a direct consumer
+// begins consuming a topic), the error is ErrNoResetOffset and the partition
+// is not consumed. If a fetch sees an OffsetOutOfRange error, the error is
+// kerr.OffsetOutOfRange and the partition is not reset; fetching the
+// partition keeps returning the error until the partition is assigned anew
+// or seeked with SeekPartitions.
+//
+// Methods that modify an offset do not change that this is a no-reset
+// offset. This offset should not be used with ConsumePartitions, where it
+// always results in ErrNoResetOffset.
+func NoResetOffset() Offset {
+	return Offset{
+		at:      -1,
+		epoch:   -1,
+		noReset: true,
+	}
+}
+
 // AtStart returns a copy of the calling offset, changing the returned offset
 // to begin at the beginning of a partition.
 func (o Offset) AtStart() Offset {
 	o.at = -2
+	o.milli = milliNone
 	return o
 }
 
@@ -48,6 +94,7 @@ func (o Offset) AtStart() Offset {
 // begin at the end of a partition.
 func (o Offset) AtEnd() Offset {
 	o.at = -1
+	o.milli = milliNone
 	return o
 }
 
@@ -84,9 +131,59 @@ func (o Offset) At(at int64) Offset {
 		at = -2
 	}
 	o.at = at
+	o.milli = milliNone
+	return o
+}
+
+// AfterMilli returns a copy of the calling offset, changing the returned
+// offset to begin at the first record with a timestamp at or after the given
+// Unix millisecond timestamp. If no record has such a timestamp, consuming
+// begins at the end of the partition.
+//
+// Relative can be used with this offset, in which case the relative amount is
+// applied to the offset that is found. An epoch is ignored with this offset,
+// because the offset is not known until it is listed. Calling At, AtStart, or
+// AtEnd clears the timestamp.
+//
+// Like any offset, this can be used in ConsumePartitions, ConsumeResetOffset,
+// and SeekPartitions.
+func (o Offset) AfterMilli(millisec int64) Offset {
+	if millisec < 0 {
+		millisec = 0
+	}
+	o.at = millisec
+	o.milli = milliAfter
 	return o
 }
 
+// BeforeMilli returns a copy of the calling offset, changing the returned
+// offset to begin at the last record with a timestamp before the given Unix
+// millisecond timestamp, that is, one before the record AfterMilli would
+// begin at. If every record is before the timestamp, consuming begins at the
+// last record in the partition. If no record is before the timestamp,
+// consuming begins at the start of the partition.
+//
+// This assumes timestamps increase with offsets, which is true for
+// LogAppendTime topics and usually true for CreateTime topics.
+//
+// As with AfterMilli, Relative is applied to the offset that is found (but
+// the result is never before the start of the partition), an epoch is
+// ignored, and calling At, AtStart, or AtEnd clears the timestamp.
+func (o Offset) BeforeMilli(millisec int64) Offset {
+	if millisec < 0 {
+		millisec = 0
+	}
+	o.at = millisec
+	o.milli = milliBefore
+	return o
+}
+
+// exact returns whether this offset is an exact offset to consume from, as
+// opposed to an offset that must be listed.
+func (o Offset) exact() bool {
+	return o.at >= 0 && o.milli == milliNone && !o.noReset
+}
+
 type consumer struct {
 	cl *Client
 
@@ -130,6 +227,17 @@ type consumer struct {
 	sourcesReadyCond        *sync.Cond
 	sourcesReadyForDraining []*source
 	fakeReadyForDraining    []Fetch
+
+	// seeks are SeekPartitions calls waiting for partitions to be
+	// positioned; see cursorPositioned.
+	seeksMu sync.Mutex
+	seeks   []*seek
+}
+
+// seek tracks the partitions a SeekPartitions call is waiting on.
+type seek struct {
+	left map[string]map[int32]struct{}
+	done chan struct{} // closed once left is empty
 }
 
 func (c *consumer) loadPaused() pausedTopics   { return c.paused.Load().(pausedTopics) }
@@ -163,7 +271,7 @@ func (c *consumer) init(cl *Client) {
 	c.paused.Store(make(pausedTopics))
 	c.sourcesReadyCond = sync.NewCond(&c.sourcesReadyMu)
 
-	if len(cl.cfg.topics) == 0 && len(cl.cfg.partitions) == 0 {
+	if cl.cfg.topics == nil && cl.cfg.partitions == nil && len(cl.cfg.group) == 0 {
 		return // not consuming
 	}
 
@@ -305,6 +413,23 @@ func (cl *Client) PollRecords(ctx context.Context, maxPollRecords int) Fetches {
 		c.mu.Lock()
 		defer c.mu.Unlock()
 
+		// If blocking rebalances on poll, we return nothing while
+		// revoking, and otherwise block revoking if we return
+		// anything. Checking and blocking must be atomic with
+		// draining.
+		if g := c.g; g != nil && g.cfg.blockRebalanceOnPoll {
+			g.blockMu.Lock()
+			defer g.blockMu.Unlock()
+			if g.revoking != nil {
+				return
+			}
+			defer func() {
+				if len(fetches) > 0 && g.pollBlocked == nil {
+					g.pollBlocked = make(chan struct{})
+				}
+			}()
+		}
+
 		c.sourcesReadyMu.Lock()
 		if maxPollRecords < 0 {
 			for _, ready := range c.sourcesReadyForDraining {
@@ -348,6 +473,7 @@ func (cl *Client) PollRecords(ctx context.Context, maxPollRecords int) Fetches {
 		}
 	}
 
+	c.g.waitRevoking(ctx)
 	fill()
 	if len(fetches) > 0 || ctx == nil {
 		return fetches
@@ -390,6 +516,7 @@ func (cl *Client) PollRecords(ctx context.Context, maxPollRecords int) Fetches {
 	case <-done:
 	}
 
+	c.g.waitRevoking(ctx)
 	fill()
 	return fetches
 }
@@ -495,6 +622,260 @@ func (cl *Client) ResumeFetchPartitions(topicPartitions map[string][]int32) {
 	c.storePaused(paused)
 }
 
+// AddConsumeTopics adds new topics to be consumed. This function is a no-op if
+// the client is not configured to consume or is consuming via regex.
+//
+// For direct consumers, new topics are consumed from the ConsumeResetOffset.
+// For group consumers, the client rejoins the group with the new subscription
+// once metadata for the new topics is loaded.
+//
+// Adding a topic that is already being consumed does nothing. Note that if
+// you are directly consuming only some partitions of a topic via
+// ConsumePartitions, adding the topic begins consuming all partitions.
+func (cl *Client) AddConsumeTopics(topics ...string) {
+	c := &cl.consumer
+	if len(topics) == 0 || !c.consuming() || cl.cfg.regex {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var tps *topicsPartitions
+	var want map[string]*regexp.Regexp
+	if c.g != nil {
+		tps, want = c.g.tps, c.g.topics
+	} else {
+		tps, want = c.d.tps, c.d.topics
+	}
+	for _, topic := range topics {
+		want[topic] = nil
+	}
+	tps.storeTopics(topics)
+	cl.triggerUpdateMetadataNow()
+}
+
+// AddConsumePartitions adds new partitions to be directly consumed, starting
+// at the given offsets. This function is a no-op if the client is not directly
+// consuming or is consuming via regex; partitions cannot be added to a group
+// consumer, because the group decides what partitions each member consumes.
+//
+// Partitions that are already being consumed are not changed; to move a
+// partition that is being consumed, use SetOffsets.
+func (cl *Client) AddConsumePartitions(partitions map[string]map[int32]Offset) {
+	c := &cl.consumer
+	if len(partitions) == 0 || c.d == nil || cl.cfg.regex {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	topics := make([]string, 0, len(partitions))
+	for topic, offsets := range partitions {
+		topics = append(topics, topic)
+		c.d.addPartitions(topic, offsets)
+	}
+	c.d.tps.storeTopics(topics)
+	cl.triggerUpdateMetadataNow()
+}
+
+// RemoveConsumeTopics stops consuming the given topics, whether they were
+// specified when creating the client, added later, or matched by a regular
+// expression. If consuming via regex, a removed topic is permanently excluded
+// from matching.
+//
+// Any in flight fetches are canceled and any buffered fetches are dropped;
+// partitions of topics that are still being consumed are fetched again from
+// where they were. For group consumers, this rejoins the group with the new
+// subscription: eager consumers revoke everything as usual, while cooperative
+// consumers revoke only the partitions of the removed topics.
+func (cl *Client) RemoveConsumeTopics(topics ...string) {
+	c := &cl.consumer
+	if len(topics) == 0 || !c.consuming() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.g != nil {
+		g := c.g
+		g.mu.Lock()
+		for _, topic := range topics {
+			delete(g.using, topic)
+			if cl.cfg.regex {
+				g.reSeen[topic] = false
+			} else {
+				delete(g.topics, topic)
+			}
+		}
+		g.mu.Unlock()
+
+		c.purgeTopics(topics, g.tps)
+		g.rejoin()
+	} else {
+		c.d.removeTopics(topics)
+		c.purgeTopics(topics, c.d.tps)
+	}
+}
+
+// purgeTopics, called under the consumer mu, stops consuming the given topics.
+// Active fetches and buffered fetches are invalidated and any partitions that
+// are listing offsets or loading epochs are dropped. Unless we are consuming
+// via regex (in which case metadata tracks all topics), the topics are then
+// removed from tps so that metadata no longer loads them.
+func (c *consumer) purgeTopics(topics []string, tps *topicsPartitions) {
+	purge := make(map[string]map[int32]Offset, len(topics))
+	for _, topic := range topics {
+		purge[topic] = nil
+	}
+	c.assignPartitions(purge, assignPurgeMatching, tps)
+
+	if c.cl.cfg.regex {
+		return
+	}
+
+	// We remove the topics within the metadata loop so that a concurrent
+	// metadata update cannot add cursors for topics we are deleting.
+	cl := c.cl
+	done := make(chan struct{})
+	cl.blockingMetadataFn(func() {
+		defer close(done)
+		keep := tps.clone()
+		for _, topic := range topics {
+			parts, exists := keep[topic]
+			if !exists {
+				continue
+			}
+			delete(keep, topic)
+			for _, part := range parts.load().partitions {
+				part.cursor.source.removeCursor(part.cursor)
+			}
+		}
+		tps.storeData(keep)
+	})
+	select {
+	case <-done:
+	case <-cl.ctx.Done():
+	}
+}
+
+// SeekPartitions moves directly consumed partitions to the given offsets,
+// returning once every partition is positioned at its new offset. Offsets are
+// resolved the same as when consuming begins: exact offsets are used directly
+// (with truncation detection if an epoch is specified), while AtStart, AtEnd,
+// AfterMilli, BeforeMilli, and any Relative amount are resolved by listing
+// offsets.
+//
+// Any in flight fetches for the partitions are canceled and any buffered
+// fetches are dropped, so no records from before the seek are returned from
+// polling after this returns. Partitions that are not being consumed are
+// ignored.
+//
+// This function only works for direct consumers; group consumers can use
+// SetOffsets. NoResetOffset cannot be seeked to; if any offset is a
+// NoResetOffset, this returns an error without seeking anything. If the
+// context is canceled before every partition is positioned, this returns the
+// context error and any remaining partitions are positioned in the background.
+func (cl *Client) SeekPartitions(ctx context.Context, offsets map[string]map[int32]Offset) error {
+	c := &cl.consumer
+	if c.d == nil {
+		return errors.New("unable to seek partitions when not directly consuming")
+	}
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			if offset.noReset {
+				return fmt.Errorf("unable to seek topic %s partition %d to NoResetOffset", topic, partition)
+			}
+		}
+	}
+
+	sk := &seek{
+		left: make(map[string]map[int32]struct{}),
+		done: make(chan struct{}),
+	}
+	assigns := make(map[string]map[int32]Offset)
+
+	c.mu.Lock()
+	for topic, partitions := range offsets {
+		using := c.d.using[topic]
+		for partition, offset := range partitions {
+			if _, ok := using[partition]; !ok {
+				continue
+			}
+			if assigns[topic] == nil {
+				assigns[topic] = make(map[int32]Offset)
+				sk.left[topic] = make(map[int32]struct{})
+			}
+			assigns[topic][partition] = offset
+			sk.left[topic][partition] = struct{}{}
+		}
+	}
+	if len(assigns) > 0 {
+		// We first invalidate everything we are seeking, and then we
+		// assign anew. We add our seek between the two so that we
+		// only wait on the new assignment.
+		c.assignPartitions(assigns, assignInvalidateMatching, c.d.tps)
+		c.seeksMu.Lock()
+		c.seeks = append(c.seeks, sk)
+		c.seeksMu.Unlock()
+		c.assignPartitions(assigns, assignWithoutInvalidating, c.d.tps)
+	}
+	c.mu.Unlock()
+
+	if len(assigns) == 0 {
+		return nil
+	}
+
+	var err error
+	select {
+	case <-sk.done:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-cl.ctx.Done():
+		err = ErrClientClosed
+	}
+
+	c.seeksMu.Lock()
+	defer c.seeksMu.Unlock()
+	for i, wait := range c.seeks {
+		if wait == sk {
+			c.seeks = append(c.seeks[:i], c.seeks[i+1:]...)
+			break
+		}
+	}
+	return err
+}
+
+// cursorPositioned is called whenever a cursor is set to a new offset from an
+// assignment or from loading offsets, and wakes any seek that was waiting on
+// only this partition.
+func (c *consumer) cursorPositioned(topic string, partition int32) {
+	c.seeksMu.Lock()
+	defer c.seeksMu.Unlock()
+
+	keep := c.seeks[:0]
+	for _, sk := range c.seeks {
+		if ps := sk.left[topic]; ps != nil {
+			delete(ps, partition)
+			if len(ps) == 0 {
+				delete(sk.left, topic)
+			}
+		}
+		if len(sk.left) == 0 {
+			close(sk.done)
+			continue
+		}
+		keep = append(keep, sk)
+	}
+	for i := len(keep); i < len(c.seeks); i++ {
+		c.seeks[i] = nil // do not let the memory hang around
+	}
+	c.seeks = keep
+}
+
 // assignHow controls how assignPartitions operates.
 type assignHow int8
 
@@ -518,6 +899,11 @@ const (
 	// The counterpart to assignInvalidateMatching, assignSetMatching
 	// resets all matching partitions to the specified offset / epoch.
 	assignSetMatching
+
+	// Similar to assignInvalidateMatching, assignPurgeMatching invalidates
+	// all partitions in matching topics. The partitions in the map are
+	// ignored; only the topics are used.
+	assignPurgeMatching
 )
 
 func (h assignHow) String() string {
@@ -530,6 +916,8 @@ func (h assignHow) String() string {
 		return "assign invalidate matching"
 	case assignSetMatching:
 		return "assign set matching"
+	case assignPurgeMatching:
+		return "assign purge matching"
 	}
 	return ""
 }
@@ -575,6 +963,11 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 			if how == assignInvalidateAll {
 				usedCursor.unset()
 				shouldKeep = false
+			} else if how == assignPurgeMatching {
+				if _, ok := assignments[usedCursor.topic]; ok {
+					usedCursor.unset()
+					shouldKeep = false
+				}
 			} else { // invalidateMatching or setMatching
 				if assignTopic, ok := assignments[usedCursor.topic]; ok {
 					if assignPart, ok := assignTopic[usedCursor.partition]; ok {
@@ -620,12 +1013,17 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 				}
 				return true
 			})
+		case assignPurgeMatching:
+			loadOffsets.keepFilter(func(t string, _ int32) bool {
+				_, ok := assignments[t]
+				return !ok
+			})
 		}
 	}
 
 	// This assignment could contain nothing (for the purposes of
 	// invalidating active fetches), so we only do this if needed.
-	if len(assignments) == 0 || how == assignInvalidateMatching || how == assignSetMatching {
+	if len(assignments) == 0 || how == assignInvalidateMatching || how == assignSetMatching || how == assignPurgeMatching {
 		return
 	}
 
@@ -640,10 +1038,21 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 		}
 
 		for partition, offset := range partitions {
+			// If the offset came from a reset policy that does not
+			// allow resetting, we have nothing to consume from and
+			// instead signal the user. The partition is as
+			// positioned as it will be, which we signal for any
+			// seek waiting on it.
+			if offset.noReset {
+				c.addFakeReadyForDraining(topic, partition, ErrNoResetOffset)
+				c.cursorPositioned(topic, partition)
+				continue
+			}
+
 			// First, if the request is exact, get rid of the relative
 			// portion. We are modifying a copy of the offset, i.e. we
 			// are appropriately not modfying 'assignments' itself.
-			if offset.at >= 0 {
+			if offset.exact() {
 				offset.at = offset.at + offset.relative
 				if offset.at < 0 {
 					offset.at = 0
@@ -662,7 +1071,7 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 			// fetch offsets only if the broker supports KIP-320,
 			// but we do not override the user manually specifying
 			// an epoch.
-			if offset.at >= 0 && offset.epoch >= 0 {
+			if offset.exact() && offset.epoch >= 0 {
 				loadOffsets.addLoad(topic, partition, loadTypeEpoch, offsetLoad{
 					replica: -1,
 					Offset:  offset,
@@ -677,7 +1086,7 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 			// If an offset is unspecified or we have not loaded
 			// the partition, we list offsets to find out what to
 			// use.
-			if offset.at >= 0 && partition >= 0 && partition < int32(len(topicPartitions.partitions)) {
+			if offset.exact() && partition >= 0 && partition < int32(len(topicPartitions.partitions)) {
 				part := topicPartitions.partitions[partition]
 				cursor := part.cursor
 				cursor.setOffset(cursorOffset{
@@ -686,6 +1095,7 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 				})
 				cursor.allowUsable()
 				c.usingCursors.use(cursor)
+				c.cursorPositioned(topic, partition)
 				continue
 			}
 
@@ -758,10 +1168,15 @@ type offsetLoadMap map[string]map[int32]offsetLoad
 type offsetLoad struct {
 	replica int32 // -1 means leader
 	Offset
+
+	// If true, this is an exact offset that we list the start offset for
+	// and then bound the exact offset by. This is used for the second
+	// list of a BeforeMilli offset.
+	atLeastStart bool
 }
 
 func (o offsetLoad) MarshalJSON() ([]byte, error) {
-	if o.replica == -1 {
+	if o.replica == -1 || o.milli != milliNone {
 		return o.Offset.MarshalJSON()
 	}
 	if o.relative == 0 {
@@ -1327,6 +1742,7 @@ func (s *consumerSession) handleListOrEpochResults(loaded loadedOffsets) (reload
 			})
 			load.cursor.allowUsable()
 			s.c.usingCursors.use(load.cursor)
+			s.c.cursorPositioned(load.topic, load.partition)
 		}
 
 		switch load.err.(type) {
@@ -1447,10 +1863,23 @@ func (l *loadedOffsets) addAll(as []loadedOffset) loadedOffsets {
 func (cl *Client) listOffsetsForBrokerLoad(ctx context.Context, broker *broker, load offsetLoadMap, tps *topicsPartitions, results chan<- loadedOffsets) {
 	loaded := loadedOffsets{broker: broker.meta.NodeID, loadType: loadTypeList}
 
+	// Listing a timestamp that is after every record returns no offset,
+	// in which case we list the end of the partition. A partition can only
+	// be in a request once, so we loop until we have no more fallbacks.
+	for len(load) > 0 {
+		load = cl.listOffsetsForBrokerLoadOnce(ctx, broker, load, tps, &loaded)
+	}
+	results <- loaded
+}
+
+// listOffsetsForBrokerLoadOnce issues one list offsets request and adds every
+// result to loaded, returning any timestamp loads that need to fall back to
+// listing the end offset.
+func (cl *Client) listOffsetsForBrokerLoadOnce(ctx context.Context, broker *broker, load offsetLoadMap, tps *topicsPartitions, loaded *loadedOffsets) (fallback offsetLoadMap) {
 	kresp, err := broker.waitResp(ctx, load.buildListReq(cl.cfg.isolationLevel))
 	if err != nil {
-		results <- loaded.addAll(load.errToLoaded(err))
-		return
+		loaded.addAll(load.errToLoaded(err))
+		return nil
 	}
 
 	topics := tps.load()
@@ -1490,12 +1919,29 @@ func (cl *Client) listOffsetsForBrokerLoad(ctx context.Context, broker *broker,
 				delete(load, topic)
 			}
 
-			offset := rPartition.Offset + loadPart.relative
+			if next, again := loadPart.nextTimestampLoad(rPartition); again {
+				if fallback == nil {
+					fallback = make(offsetLoadMap)
+				}
+				fps := fallback[topic]
+				if fps == nil {
+					fps = make(map[int32]offsetLoad)
+					fallback[topic] = fps
+				}
+				fps[partition] = next
+				continue
+			}
+
+			listed := rPartition.Offset
 			if len(rPartition.OldStyleOffsets) > 0 { // if we have any, we used list offsets v0
-				offset = rPartition.OldStyleOffsets[0] + loadPart.relative
+				listed = rPartition.OldStyleOffsets[0]
 			}
-			if loadPart.at >= 0 {
+			offset := listed + loadPart.relative
+			if loadPart.exact() {
 				offset = loadPart.at + loadPart.relative // we obey exact requests, even if they end up past the end
+				if loadPart.atLeastStart && offset < listed {
+					offset = listed // we listed the start offset to ensure we are not before it
+				}
 			}
 			if offset < 0 {
 				offset = 0
@@ -1512,7 +1958,40 @@ func (cl *Client) listOffsetsForBrokerLoad(ctx context.Context, broker *broker,
 		}
 	}
 
-	results <- loaded.addAll(load.errToLoaded(kerr.UnknownTopicOrPartition))
+	loaded.addAll(load.errToLoaded(kerr.UnknownTopicOrPartition))
+	return fallback
+}
+
+// nextTimestampLoad returns the load to issue next for a timestamp offset
+// based on what the timestamp listed, if another load is necessary.
+//
+// For AfterMilli, if no record is at or after the timestamp, we list the end.
+//
+// For BeforeMilli, if no record is at or after the timestamp, every record is
+// before, so we list the end and back up one. Otherwise, we back up one from
+// the listed offset and list the start to ensure we are not before it.
+func (o offsetLoad) nextTimestampLoad(listed kmsg.ListOffsetsResponseTopicPartition) (offsetLoad, bool) {
+	if o.milli == milliNone || len(listed.OldStyleOffsets) > 0 {
+		return o, false
+	}
+	switch {
+	case listed.Offset == -1:
+		rel := o.relative
+		if o.milli == milliBefore {
+			rel--
+		}
+		o.Offset = o.Offset.AtEnd().Relative(rel)
+	case o.milli == milliBefore:
+		at := listed.Offset - 1 + o.relative
+		if at < 0 {
+			at = 0
+		}
+		o.Offset = o.Offset.At(at).Relative(0)
+		o.atLeastStart = true
+	default:
+		return o, false
+	}
+	return o, true
 }
 
 func (cl *Client) loadEpochsForBrokerLoad(ctx context.Context, broker *broker, load offsetLoadMap, tps *topicsPartitions, results chan<- loadedOffsets) {
@@ -1601,13 +2080,16 @@ func (o offsetLoadMap) buildListReq(isolationLevel int8) *kmsg.ListOffsetsReques
 			// loaded by the client (due to metadata). We use -1
 			// just to ensure the partition is loaded.
 			timestamp := offset.at
-			if timestamp >= 0 {
+			if offset.exact() {
 				timestamp = -1
+				if offset.atLeastStart {
+					timestamp = -2
+				}
 			}
 			p := kmsg.NewListOffsetsRequestTopicPartition()
 			p.Partition = partition
 			p.CurrentLeaderEpoch = offset.currentEpoch // KIP-320
-			p.Timestamp = offset.at
+			p.Timestamp = timestamp
 			p.MaxNumOffsets = 1
 
 			parts = append(parts, p)
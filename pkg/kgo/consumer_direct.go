@@ -1,35 +1,79 @@
 package kgo
 
+import "regexp"
+
 type directConsumer struct {
 	cfg    *cfg
 	tps    *topicsPartitions             // data for topics that the user assigned
 	reSeen map[string]bool               // topics we evaluated against regex, and whether we want them or not
-	using  map[string]map[int32]struct{} // topics we are currently using (this only grows)
+	using  map[string]map[int32]struct{} // topics we are currently using (this only grows, unless topics are removed)
+
+	// topics and partitions begin as copies of the config's topics and
+	// partitions, and are modified when the user adds or removes topics
+	// while consuming. These are only accessed under the consumer mu.
+	topics     map[string]*regexp.Regexp
+	partitions map[string]map[int32]Offset
 }
 
 func (c *consumer) initDirect() {
 	d := &directConsumer{
-		cfg:    &c.cl.cfg,
-		tps:    newTopicsPartitions(),
-		reSeen: make(map[string]bool),
-		using:  make(map[string]map[int32]struct{}),
+		cfg:        &c.cl.cfg,
+		tps:        newTopicsPartitions(),
+		reSeen:     make(map[string]bool),
+		using:      make(map[string]map[int32]struct{}),
+		topics:     make(map[string]*regexp.Regexp, len(c.cl.cfg.topics)),
+		partitions: make(map[string]map[int32]Offset, len(c.cl.cfg.partitions)),
 	}
 	c.d = d
 
+	for topic, re := range d.cfg.topics {
+		d.topics[topic] = re
+	}
+	for topic, partitions := range d.cfg.partitions {
+		d.addPartitions(topic, partitions)
+	}
+
 	if d.cfg.regex {
 		return
 	}
 
 	var topics []string
-	for topic := range d.cfg.topics {
+	for topic := range d.topics {
 		topics = append(topics, topic)
 	}
-	for topic := range d.cfg.partitions {
+	for topic := range d.partitions {
 		topics = append(topics, topic)
 	}
 	d.tps.storeTopics(topics) // prime topics to load if non-regex (this is of no benefit if regex)
 }
 
+// addPartitions adds partitions to directly consume, overwriting the offset
+// for any partition that was already added.
+func (d *directConsumer) addPartitions(topic string, partitions map[int32]Offset) {
+	dps := d.partitions[topic]
+	if dps == nil {
+		dps = make(map[int32]Offset, len(partitions))
+		d.partitions[topic] = dps
+	}
+	for partition, offset := range partitions {
+		dps[partition] = offset
+	}
+}
+
+// removeTopics stops tracking the given topics entirely. If we are consuming
+// via regex, the topics are marked as permanently unwanted.
+func (d *directConsumer) removeTopics(topics []string) {
+	for _, topic := range topics {
+		delete(d.using, topic)
+		if d.cfg.regex {
+			d.reSeen[topic] = false
+		} else {
+			delete(d.topics, topic)
+			delete(d.partitions, topic)
+		}
+	}
+}
+
 // findNewAssignments returns new partitions to consume at given offsets
 // based off the current topics.
 func (d *directConsumer) findNewAssignments() map[string]map[int32]Offset {
@@ -43,7 +87,7 @@ func (d *directConsumer) findNewAssignments() map[string]map[int32]Offset {
 		if d.cfg.regex {
 			want, seen := d.reSeen[topic]
 			if !seen {
-				for _, re := range d.cfg.topics {
+				for _, re := range d.topics {
 					if want = re.MatchString(topic); want {
 						break
 					}
@@ -52,7 +96,7 @@ func (d *directConsumer) findNewAssignments() map[string]map[int32]Offset {
 			}
 			useTopic = want
 		} else {
-			_, useTopic = d.cfg.topics[topic]
+			_, useTopic = d.topics[topic]
 		}
 
 		// If the above detected that we want to keep this topic, we
@@ -67,14 +111,14 @@ func (d *directConsumer) findNewAssignments() map[string]map[int32]Offset {
 			}
 			toUseTopic := make(map[int32]Offset, len(partitions.partitions))
 			for partition := range partitions.partitions {
-				toUseTopic[int32(partition)] = d.cfg.resetOffset
+				toUseTopic[int32(partition)] = d.cfg.resetOffsetFor(topic, int32(partition))
 			}
 			toUse[topic] = toUseTopic
 		}
 
 		// Lastly, if this topic has some specific partitions pinned,
 		// we set those.
-		for partition, offset := range d.cfg.partitions[topic] {
+		for partition, offset := range d.partitions[topic] {
 			toUseTopic, exists := toUse[topic]
 			if !exists {
 				toUseTopic = make(map[int32]Offset, 10)
@@ -0,0 +1,110 @@
+package kgo
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDirectConsumerChangeTopics(t *testing.T) {
+	cl := &Client{cfg: defaultCfg()}
+	cl.cfg.topics = map[string]*regexp.Regexp{"foo": nil}
+	c := &cl.consumer
+	c.cl = cl
+	c.initDirect()
+	d := c.d
+
+	// Metadata stores partitions for every topic we asked for; we mimic
+	// that here.
+	loadPartitions := func(topic string, n int) {
+		tps := d.tps.ensureTopics([]string{topic})
+		tps[topic].v.Store(&topicPartitionsData{partitions: make([]*topicPartition, n)})
+		d.tps.storeData(tps)
+	}
+
+	start := NewOffset().AtStart()
+	at := NewOffset().At(10)
+
+	for i, test := range []struct {
+		change func()
+		exp    map[string]map[int32]Offset
+	}{
+		{
+			change: func() { loadPartitions("foo", 2) },
+			exp:    map[string]map[int32]Offset{"foo": {0: start, 1: start}},
+		},
+
+		{
+			change: func() {}, // no change: nothing new
+		},
+
+		{
+			change: func() {
+				for _, topic := range []string{"bar", "foo"} {
+					d.topics[topic] = nil
+				}
+				d.addPartitions("baz", map[int32]Offset{1: at})
+				loadPartitions("bar", 1)
+				loadPartitions("baz", 3)
+			},
+			exp: map[string]map[int32]Offset{
+				"bar": {0: start},
+				"baz": {1: at},
+			},
+		},
+
+		{
+			change: func() { d.removeTopics([]string{"foo", "baz"}) },
+		},
+
+		{
+			// Re-adding a removed topic consumes it anew.
+			change: func() { d.topics["foo"] = nil },
+			exp:    map[string]map[int32]Offset{"foo": {0: start, 1: start}},
+		},
+	} {
+		test.change()
+		got := d.findNewAssignments()
+		if !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("#%d: got %v != exp %v", i, got, test.exp)
+		}
+	}
+
+	if _, exists := d.using["baz"]; exists {
+		t.Error("removed topic baz is still being used")
+	}
+}
+
+func TestDirectConsumerResetPolicy(t *testing.T) {
+	end := NewOffset().AtEnd()
+	cl := &Client{cfg: defaultCfg()}
+	cl.cfg.topics = map[string]*regexp.Regexp{"foo": nil, "bar": nil}
+	cl.cfg.resetPolicy = func(topic string, partition int32) Offset {
+		switch {
+		case topic == "bar":
+			return NoResetOffset()
+		case partition == 1:
+			return end
+		default:
+			return cl.cfg.resetOffset
+		}
+	}
+	c := &cl.consumer
+	c.cl = cl
+	c.initDirect()
+	d := c.d
+
+	tps := d.tps.ensureTopics([]string{"foo", "bar"})
+	tps["foo"].v.Store(&topicPartitionsData{partitions: make([]*topicPartition, 2)})
+	tps["bar"].v.Store(&topicPartitionsData{partitions: make([]*topicPartition, 1)})
+	d.tps.storeData(tps)
+
+	got := d.findNewAssignments()
+	exp := map[string]map[int32]Offset{
+		"foo": {0: NewOffset().AtStart(), 1: end},
+		"bar": {0: NoResetOffset()},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %v != exp %v", got, exp)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -31,6 +32,11 @@ type groupConsumer struct {
 
 	reSeen map[string]bool // topics we evaluated against regex, and whether we want them or not
 
+	// topics begins as a copy of the config's topics and is modified when
+	// the user adds or removes topics while consuming. This is only
+	// accessed under the consumer mu.
+	topics map[string]*regexp.Regexp
+
 	// Full lock grabbed in CommitOffsetsSync, read lock grabbed in
 	// CommitOffsets, this lock ensures that only one sync commit can
 	// happen at once, and if it is happening, no other commit can be
@@ -63,6 +69,15 @@ type groupConsumer struct {
 	// EndTransaction.
 	offsetsAddedToTxn bool
 
+	// With BlockRebalanceOnPoll, a poll that returns fetches blocks
+	// revoking until AllowRebalance, and revoking blocks polls from
+	// returning fetches. pollBlocked is non-nil while polled fetches are
+	// being processed and is closed by AllowRebalance; revoking is
+	// non-nil while revoking and is closed once revoking is done.
+	blockMu     sync.Mutex
+	pollBlocked chan struct{}
+	revoking    chan struct{}
+
 	//////////////
 	// mu block //
 	//////////////
@@ -76,6 +91,11 @@ type groupConsumer struct {
 	// This is read when joining a group or leaving a group.
 	using map[string]int // topics *we* are currently using => # partitions known in that topic
 
+	// managing is set once the manage goroutine is started, which happens
+	// the first time we find topics to use. Topics can be removed while
+	// consuming, so we cannot rely on using being non-empty.
+	managing bool
+
 	// uncommitted is read and updated all over:
 	// - updated before PollFetches returns
 	// - updated when directly setting offsets (to rewind, for transactions)
@@ -138,6 +158,7 @@ func (c *consumer) initGroup() {
 		cancel: cancel,
 
 		reSeen: make(map[string]bool),
+		topics: make(map[string]*regexp.Regexp, len(c.cl.cfg.topics)),
 
 		manageDone:       make(chan struct{}),
 		cooperative:      c.cl.cfg.cooperative(),
@@ -147,6 +168,9 @@ func (c *consumer) initGroup() {
 		using:            make(map[string]int),
 	}
 	c.g = g
+	for topic, re := range g.cfg.topics {
+		g.topics[topic] = re
+	}
 	if !g.cfg.setCommitCallback {
 		g.cfg.commitCallback = g.defaultCommitCallback
 	}
@@ -202,9 +226,9 @@ func (c *consumer) initGroup() {
 	// For non-regex topics, we explicitly ensure they exist for loading
 	// metadata. This is of no impact if we are *also* consuming via regex,
 	// but that is no problem.
-	if len(g.cfg.topics) > 0 {
-		topics := make([]string, 0, len(g.cfg.topics))
-		for topic := range g.cfg.topics {
+	if len(g.topics) > 0 {
+		topics := make([]string, 0, len(g.topics))
+		for topic := range g.topics {
 			topics = append(topics, topic)
 		}
 		g.tps.storeTopics(topics)
@@ -315,12 +339,12 @@ func (g *groupConsumer) manage() {
 }
 
 func (g *groupConsumer) leave() (wait func()) {
-	// If g.using is nonzero before this check, then a manage goroutine has
-	// started. If not, it will never start because we set dying.
+	// If g.managing is true before this check, then a manage goroutine
+	// has started. If not, it will never start because we set dying.
 	g.mu.Lock()
 	wasDead := g.dying
 	g.dying = true
-	wasManaging := len(g.using) > 0
+	wasManaging := g.managing
 	g.mu.Unlock()
 
 	done := make(chan struct{})
@@ -433,13 +457,17 @@ const (
 //     (1) if revoking lost partitions from a prior session (i.e., after sync),
 //         this revokes the passed in lost
 //     (2) if revoking at the end of a session, this revokes topics that the
-//         consumer is no longer interested in consuming (TODO, actually, only
-//         once we allow subscriptions to change without leaving the group).
+//         consumer is no longer interested in consuming (i.e., topics that
+//         were removed with RemoveConsumeTopics).
 //
 // Lastly, for cooperative consumers, this must selectively delete what was
 // lost from the uncommitted map.
 func (g *groupConsumer) revoke(stage revokeStage, lost map[string][]int32, leaving bool) {
 	if !g.cooperative || leaving { // stage == revokeThisSession if not cooperative
+		if !leaving {
+			defer g.waitAllowRebalance()()
+		}
+
 		// If we are an eager consumer, we stop fetching all of our
 		// current partitions as we will be revoking them.
 		g.c.mu.Lock()
@@ -474,13 +502,25 @@ func (g *groupConsumer) revoke(stage revokeStage, lost map[string][]int32, leavi
 	case revokeThisSession:
 		// lost is nil for cooperative assigning. Instead, we determine
 		// lost by finding subscriptions we are no longer interested in.
-		//
-		// TODO only relevant when we allow reassigning with the same
-		// group to change subscriptions (also we must delete the
-		// unused partitions from nowAssigned).
+		// We delete these from nowAssigned so that we do not claim to
+		// own them when rejoining.
+		g.mu.Lock()
+		for topic, partitions := range g.nowAssigned {
+			if _, using := g.using[topic]; using {
+				continue
+			}
+			if lost == nil {
+				lost = make(map[string][]int32)
+			}
+			lost[topic] = partitions
+			delete(g.nowAssigned, topic)
+		}
+		g.mu.Unlock()
 	}
 
 	if len(lost) > 0 {
+		defer g.waitAllowRebalance()()
+
 		// We must now stop fetching anything we lost and invalidate
 		// any buffered fetches before falling into onRevoked.
 		//
@@ -841,6 +881,86 @@ func (cl *Client) ForceRebalance() {
 	}
 }
 
+// AllowRebalance allows a group member to revoke partitions if revoking is
+// blocked by the BlockRebalanceOnPoll option. This should be called once the
+// records from the last poll are processed (and committed, if committing
+// manually). This is a no-op if the client is not a group member, or if
+// revoking is not blocked.
+func (cl *Client) AllowRebalance() {
+	g := cl.consumer.g
+	if g == nil {
+		return
+	}
+	g.blockMu.Lock()
+	defer g.blockMu.Unlock()
+	if g.pollBlocked != nil {
+		close(g.pollBlocked)
+		g.pollBlocked = nil
+	}
+}
+
+// waitAllowRebalance, if blocking rebalances on poll, blocks polls from
+// returning fetches and waits for AllowRebalance if a poll returned fetches.
+// We wait at most half the rebalance timeout, leaving the other half for
+// onRevoked and rejoining so that the member is not kicked from the group.
+//
+// The returned function must be called once revoking is done to allow polls
+// again.
+func (g *groupConsumer) waitAllowRebalance() (done func()) {
+	if !g.cfg.blockRebalanceOnPoll {
+		return func() {}
+	}
+
+	revoking := make(chan struct{})
+	g.blockMu.Lock()
+	g.revoking = revoking
+	polled := g.pollBlocked
+	g.blockMu.Unlock()
+
+	done = func() {
+		g.blockMu.Lock()
+		g.revoking = nil
+		g.blockMu.Unlock()
+		close(revoking)
+	}
+	if polled == nil {
+		return done
+	}
+
+	timeout := g.cfg.rebalanceTimeout / 2
+	g.cfg.logger.Log(LogLevelInfo, "waiting for AllowRebalance before revoking", "group", g.cfg.group, "timeout", timeout)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-polled:
+	case <-timer.C:
+		g.cfg.logger.Log(LogLevelWarn, "AllowRebalance was not called within half of the rebalance timeout, revoking anyway to avoid being kicked from the group", "group", g.cfg.group)
+	case <-g.ctx.Done():
+	}
+	return done
+}
+
+// waitRevoking, if blocking rebalances on poll, waits for any active revoke
+// to finish so that a poll does not return fetches for partitions that are
+// being revoked. If the context is nil or quits first, the poll returns
+// nothing while the revoke is active.
+func (g *groupConsumer) waitRevoking(ctx context.Context) {
+	if g == nil || !g.cfg.blockRebalanceOnPoll || ctx == nil {
+		return
+	}
+	g.blockMu.Lock()
+	revoking := g.revoking
+	g.blockMu.Unlock()
+	if revoking == nil {
+		return
+	}
+	select {
+	case <-revoking:
+	case <-ctx.Done():
+	case <-g.cl.ctx.Done():
+	}
+}
+
 // rejoin is called after a cooperative member revokes what it lost at the
 // beginning of a session, or if we are leader and detect new partitions to
 // consume.
@@ -1135,7 +1255,7 @@ start:
 				offset.epoch = rPartition.LeaderEpoch
 			}
 			if rPartition.Offset == -1 {
-				offset = g.cfg.resetOffset
+				offset = g.cfg.resetOffsetFor(rTopic.Topic, rPartition.Partition)
 			}
 			topicOffsets[rPartition.Partition] = offset
 		}
@@ -1171,7 +1291,7 @@ start:
 			g.uncommitted[topic] = topicUncommitted
 		}
 		for partition, offset := range partitions {
-			if offset.at < 0 {
+			if !offset.exact() {
 				continue // not yet committed
 			}
 			committed := EpochOffset{
@@ -1232,7 +1352,7 @@ func (g *groupConsumer) findNewAssignments() {
 		if g.cfg.regex {
 			want, seen := g.reSeen[topic]
 			if !seen {
-				for _, re := range g.cfg.topics {
+				for _, re := range g.topics {
 					if want = re.MatchString(topic); want {
 						break
 					}
@@ -1241,7 +1361,7 @@ func (g *groupConsumer) findNewAssignments() {
 			}
 			useTopic = want
 		} else {
-			_, useTopic = g.cfg.topics[topic]
+			_, useTopic = g.topics[topic]
 		}
 
 		// We only track using the topic if there are partitions for
@@ -1269,12 +1389,12 @@ func (g *groupConsumer) findNewAssignments() {
 		return
 	}
 
-	wasManaging := len(g.using) != 0
 	for topic, change := range toChange {
 		g.using[topic] += change.delta
 	}
 
-	if !wasManaging {
+	if !g.managing {
+		g.managing = true
 		go g.manage()
 		return
 	}
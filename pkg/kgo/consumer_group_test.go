@@ -0,0 +1,61 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBlockRebalanceOnPoll(t *testing.T) {
+	cl := &Client{cfg: defaultCfg(), ctx: context.Background()}
+	cl.cfg.blockRebalanceOnPoll = true
+	c := &cl.consumer
+	c.init(cl)
+	g := &groupConsumer{c: c, cl: cl, cfg: &cl.cfg, ctx: cl.ctx}
+	c.g = g
+
+	errPoll := errors.New("poll")
+	poll := func() Fetches {
+		c.addFakeReadyForDraining("foo", 0, errPoll)
+		return cl.PollFetches(nil)
+	}
+
+	if fs := poll(); len(fs) != 1 {
+		t.Fatalf("got %d fetches != exp 1", len(fs))
+	}
+
+	revoked := make(chan func())
+	go func() { revoked <- g.waitAllowRebalance() }()
+	select {
+	case <-revoked:
+		t.Fatal("revoking did not wait for AllowRebalance")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// While revoking, polls return nothing.
+	if fs := poll(); len(fs) != 0 {
+		t.Fatalf("got %d fetches while revoking != exp 0", len(fs))
+	}
+
+	cl.AllowRebalance()
+	var done func()
+	select {
+	case done = <-revoked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("revoking did not continue after AllowRebalance")
+	}
+	done()
+
+	// The fetch injected while revoking is now returned, blocking again;
+	// without AllowRebalance, revoking continues after the timeout.
+	if fs := cl.PollFetches(nil); len(fs) != 1 {
+		t.Fatalf("got %d fetches after revoking != exp 1", len(fs))
+	}
+	cl.cfg.rebalanceTimeout = 20 * time.Millisecond
+	start := time.Now()
+	g.waitAllowRebalance()()
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("revoking returned after %v, before half the rebalance timeout", elapsed)
+	}
+}
@@ -0,0 +1,165 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestBuildListReqTimestamps(t *testing.T) {
+	load := offsetLoadMap{"foo": {
+		0: {replica: -1, Offset: NewOffset().AtStart()},
+		1: {replica: -1, Offset: NewOffset().AtEnd().Relative(-10)},
+		2: {replica: -1, Offset: NewOffset().At(100)},
+		3: {replica: -1, Offset: NewOffset().AfterMilli(1600000000000)},
+		4: {replica: -1, Offset: NewOffset().AfterMilli(1600000000000).At(5)},
+		5: {replica: -1, Offset: NewOffset().BeforeMilli(1600000000000)},
+		6: {replica: -1, Offset: NewOffset().At(5), atLeastStart: true},
+	}}
+	exp := map[int32]int64{
+		0: -2,
+		1: -1,
+		2: -1, // exact offsets list the end just to load the partition
+		3: 1600000000000,
+		4: -1, // At clears AfterMilli
+		5: 1600000000000,
+		6: -2, // bounding an exact offset by the start lists the start
+	}
+
+	req := load.buildListReq(0)
+	if len(req.Topics) != 1 {
+		t.Fatalf("got %d topics != exp 1", len(req.Topics))
+	}
+	for _, p := range req.Topics[0].Partitions {
+		if got := p.Timestamp; got != exp[p.Partition] {
+			t.Errorf("partition %d: got timestamp %d != exp %d", p.Partition, got, exp[p.Partition])
+		}
+	}
+}
+
+func TestCursorPositionedWakesSeeks(t *testing.T) {
+	var c consumer
+
+	first := &seek{
+		left: map[string]map[int32]struct{}{"foo": {0: {}, 1: {}}},
+		done: make(chan struct{}),
+	}
+	second := &seek{
+		left: map[string]map[int32]struct{}{"foo": {1: {}}, "bar": {0: {}}},
+		done: make(chan struct{}),
+	}
+	c.seeks = []*seek{first, second}
+
+	isDone := func(sk *seek) bool {
+		select {
+		case <-sk.done:
+			return true
+		default:
+			return false
+		}
+	}
+
+	c.cursorPositioned("foo", 1)
+	if isDone(first) || isDone(second) {
+		t.Fatal("seek finished before all partitions were positioned")
+	}
+	c.cursorPositioned("foo", 0)
+	if !isDone(first) || isDone(second) {
+		t.Fatal("expected only the first seek to be finished")
+	}
+	c.cursorPositioned("bar", 0)
+	if !isDone(second) {
+		t.Fatal("expected the second seek to be finished")
+	}
+	if len(c.seeks) != 0 {
+		t.Errorf("got %d remaining seeks != exp 0", len(c.seeks))
+	}
+}
+
+func TestNextTimestampLoad(t *testing.T) {
+	after := NewOffset().AfterMilli(100)
+	before := NewOffset().BeforeMilli(100)
+
+	for i, test := range []struct {
+		in     Offset
+		listed int64
+
+		again        bool
+		exp          Offset
+		atLeastStart bool
+	}{
+		{in: NewOffset().AtStart(), listed: 5},
+		{in: after, listed: 5},
+
+		{in: after, listed: -1, again: true, exp: NewOffset().AtEnd()},
+		{in: after.Relative(-3), listed: -1, again: true, exp: NewOffset().AtEnd().Relative(-3)},
+
+		{in: before, listed: -1, again: true, exp: NewOffset().AtEnd().Relative(-1)},
+		{in: before, listed: 5, again: true, exp: NewOffset().At(4), atLeastStart: true},
+		{in: before.Relative(-10), listed: 5, again: true, exp: NewOffset().At(0), atLeastStart: true},
+	} {
+		var listed kmsg.ListOffsetsResponseTopicPartition
+		listed.Offset = test.listed
+
+		got, again := offsetLoad{replica: -1, Offset: test.in}.nextTimestampLoad(listed)
+		if again != test.again {
+			t.Errorf("#%d: got again %v != exp %v", i, again, test.again)
+			continue
+		}
+		if !again {
+			continue
+		}
+		if got.Offset != test.exp || got.atLeastStart != test.atLeastStart {
+			t.Errorf("#%d: got %+v (atLeastStart %v) != exp %+v (atLeastStart %v)", i, got.Offset, got.atLeastStart, test.exp, test.atLeastStart)
+		}
+	}
+}
+
+func TestSeekPartitionsNoReset(t *testing.T) {
+	cl := &Client{cfg: defaultCfg(), ctx: context.Background()}
+	cl.cfg.partitions = map[string]map[int32]Offset{"foo": {0: NewOffset()}}
+	c := &cl.consumer
+	c.cl = cl
+	c.initDirect()
+	c.d.using["foo"] = map[int32]struct{}{0: {}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := cl.SeekPartitions(ctx, map[string]map[int32]Offset{"foo": {0: NoResetOffset()}})
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got err %v, exp an immediate NoResetOffset error", err)
+	}
+	if len(c.seeks) != 0 {
+		t.Errorf("got %d seeks != exp 0", len(c.seeks))
+	}
+}
+
+func TestAssignNoResetPositionsSeek(t *testing.T) {
+	cl := &Client{cfg: defaultCfg(), ctx: context.Background()}
+	c := &cl.consumer
+	c.init(cl)
+	sk := &seek{
+		left: map[string]map[int32]struct{}{"foo": {0: {}}},
+		done: make(chan struct{}),
+	}
+	c.seeks = []*seek{sk}
+
+	tps := newTopicsPartitions()
+	data := tps.ensureTopics([]string{"foo"})
+	data["foo"].v.Store(&topicPartitionsData{partitions: make([]*topicPartition, 1)})
+	tps.storeData(data)
+
+	c.assignPartitions(map[string]map[int32]Offset{"foo": {0: NoResetOffset()}}, assignWithoutInvalidating, tps)
+
+	select {
+	case <-sk.done:
+	default:
+		t.Error("seek waiting on a NoResetOffset partition was not finished")
+	}
+	if len(c.fakeReadyForDraining) != 1 || c.fakeReadyForDraining[0].Topics[0].Partitions[0].Err != ErrNoResetOffset {
+		t.Errorf("got fake fetches %v, exp one ErrNoResetOffset", c.fakeReadyForDraining)
+	}
+}
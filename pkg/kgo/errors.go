@@ -169,6 +169,11 @@ var (
 	//
 	// For any request, the request is failed with this error.
 	ErrClientClosed = errors.New("client closed")
+
+	// ErrNoResetOffset is injected into a poll response for a partition
+	// that has no offset to begin consuming from, when the reset offset
+	// for the partition is NoResetOffset. The partition is not consumed.
+	ErrNoResetOffset = errors.New("partition has no offset to consume from and the reset offset does not allow resetting")
 )
 
 // ErrDataLoss is returned for Kafka >=2.1.0 when data loss is detected and the
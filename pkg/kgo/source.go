@@ -74,6 +74,12 @@ func (s *source) removeCursor(rm *cursor) {
 	s.cursorsMu.Lock()
 	defer s.cursorsMu.Unlock()
 
+	// The cursor may never have been added, or may have already been
+	// removed if all cursors were cleared when leaving a group.
+	if rm.cursorsIdx < 0 || rm.cursorsIdx >= len(s.cursors) || s.cursors[rm.cursorsIdx] != rm {
+		return
+	}
+
 	if rm.cursorsIdx != len(s.cursors)-1 {
 		s.cursors[rm.cursorsIdx], s.cursors[len(s.cursors)-1] = s.cursors[len(s.cursors)-1], nil
 		s.cursors[rm.cursorsIdx].cursorsIdx = rm.cursorsIdx
@@ -842,17 +848,27 @@ func (s *source) handleReqResp(br *broker, req *fetchRequest, resp *kmsg.FetchRe
 				// rare". Rather than falling back to listing offsets,
 				// we stay in a cycle of validating the leader epoch
 				// until the follower has caught up.
-
-				if s.nodeID == partOffset.from.leader { // non KIP-392 case
+				//
+				// Whenever we would list the reset offset, if the
+				// reset offset does not allow resetting, we keep the
+				// error for the user instead. Validating the epoch
+				// (KIP-392 case 4) is not a reset.
+				resetTo := func(replica int32) {
+					resetOffset := s.cl.cfg.resetOffsetFor(topic, partition)
+					if resetOffset.noReset {
+						keep = true
+						return
+					}
 					reloadOffsets.addLoad(topic, partition, loadTypeList, offsetLoad{
-						replica: -1,
-						Offset:  s.cl.cfg.resetOffset,
+						replica: replica,
+						Offset:  resetOffset,
 					})
+				}
+
+				if s.nodeID == partOffset.from.leader { // non KIP-392 case
+					resetTo(-1)
 				} else if partOffset.offset < fp.LogStartOffset { // KIP-392 case 3
-					reloadOffsets.addLoad(topic, partition, loadTypeList, offsetLoad{
-						replica: s.nodeID,
-						Offset:  s.cl.cfg.resetOffset,
-					})
+					resetTo(s.nodeID)
 				} else { // partOffset.offset > fp.HighWatermark, KIP-392 case 4
 					if kip320 {
 						reloadOffsets.addLoad(topic, partition, loadTypeEpoch, offsetLoad{
@@ -866,10 +882,7 @@ func (s *source) handleReqResp(br *broker, req *fetchRequest, resp *kmsg.FetchRe
 						// If the broker does not support offset for leader epoch but
 						// does support follower fetching for some reason, we have to
 						// fallback to listing.
-						reloadOffsets.addLoad(topic, partition, loadTypeList, offsetLoad{
-							replica: -1,
-							Offset:  s.cl.cfg.resetOffset,
-						})
+						resetTo(-1)
 					}
 				}
 
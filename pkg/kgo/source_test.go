@@ -0,0 +1,70 @@
+package kgo
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestOffsetOutOfRangeNoReset(t *testing.T) {
+	const leader, follower = 1, 2
+
+	for i, test := range []struct {
+		nodeID int32
+		offset int64
+		kip320 bool
+
+		expKeep  bool
+		expEpoch bool
+	}{
+		{nodeID: leader, offset: 100, kip320: true, expKeep: true},    // leader: reset
+		{nodeID: follower, offset: -5, kip320: true, expKeep: true},   // KIP-392 case 3: reset
+		{nodeID: follower, offset: 100, kip320: true, expEpoch: true}, // KIP-392 case 4: validate epoch, not a reset
+		{nodeID: follower, offset: 100, kip320: false, expKeep: true}, // case 4 without KIP-320: reset
+	} {
+		b := &broker{meta: BrokerMetadata{NodeID: leader}}
+		versions := newBrokerVersions()
+		if test.kip320 {
+			versions.versions[23] = 3
+		} else {
+			versions.versions[23] = 1
+		}
+		b.storeVersions(versions)
+
+		cl := &Client{cfg: defaultCfg(), brokers: map[int32]*broker{leader: b}}
+		cl.cfg.resetOffset = NoResetOffset()
+		s := &source{cl: cl, nodeID: test.nodeID}
+
+		c := &cursor{topic: "foo", partition: 0, source: s}
+		c.leader = leader
+		req := &fetchRequest{usedOffsets: usedOffsets{"foo": {0: &cursorOffsetNext{
+			cursorOffset: cursorOffset{offset: test.offset, lastConsumedEpoch: 1},
+			from:         c,
+		}}}}
+
+		resp := kmsg.NewPtrFetchResponse()
+		resp.Version = 11
+		rt := kmsg.NewFetchResponseTopic()
+		rt.Topic = "foo"
+		rp := kmsg.NewFetchResponseTopicPartition()
+		rp.ErrorCode = kerr.OffsetOutOfRange.Code
+		rp.HighWatermark = 50
+		rp.LogStartOffset = 0
+		rt.Partitions = append(rt.Partitions, rp)
+		resp.Topics = append(resp.Topics, rt)
+
+		fetch, reloads, _, _ := s.handleReqResp(b, req, resp)
+
+		kept := len(fetch.Topics) == 1 && len(fetch.Topics[0].Partitions) == 1 && fetch.Topics[0].Partitions[0].Err == kerr.OffsetOutOfRange
+		if kept != test.expKeep {
+			t.Errorf("#%d: got kept %v != exp %v", i, kept, test.expKeep)
+		}
+		if len(reloads.List) != 0 {
+			t.Errorf("#%d: unexpectedly listing a reset offset: %v", i, reloads.List)
+		}
+		if epoch := len(reloads.Epoch) == 1; epoch != test.expEpoch {
+			t.Errorf("#%d: got epoch load %v != exp %v", i, epoch, test.expEpoch)
+		}
+	}
+}
@@ -32916,6 +32916,18 @@ type DescribeQuorumResponseTopicPartitionReplicaState struct {
 	// The last known log end offset of the follower, or -1 if it is unknown.
 	LogEndOffset int64
 
+	// The last known leader wall clock time when a follower fetched from the
+	// leader, or -1 for the current leader or if unknown.
+	//
+	// This field has a default of -1.
+	LastFetchTimestamp int64 // v1+
+
+	// The last known leader wall clock time when a follower was caught up to
+	// the high watermark, or -1 for the current leader or if unknown.
+	//
+	// This field has a default of -1.
+	LastCaughtUpTimestamp int64 // v1+
+
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
 	UnknownTags Tags
 }
@@ -32923,6 +32935,8 @@ type DescribeQuorumResponseTopicPartitionReplicaState struct {
 // Default sets any default fields. Calling this allows for future compatibility
 // if new fields are added to DescribeQuorumResponseTopicPartitionReplicaState.
 func (v *DescribeQuorumResponseTopicPartitionReplicaState) Default() {
+	v.LastFetchTimestamp = -1
+	v.LastCaughtUpTimestamp = -1
 }
 
 // NewDescribeQuorumResponseTopicPartitionReplicaState returns a default DescribeQuorumResponseTopicPartitionReplicaState
@@ -32978,6 +32992,9 @@ func NewDescribeQuorumRequestTopic() DescribeQuorumRequestTopic {
 // Part of KIP-642 (and KIP-595) to replace Kafka's dependence on Zookeeper with a
 // Kafka-only raft protocol,
 // DescribeQuorumRequest is sent by a leader to describe the quorum.
+//
+// Version 1, introduced in KIP-836, adds the last fetch and last caught up
+// timestamps to replica states.
 type DescribeQuorumRequest struct {
 	// Version is the version of this message used with a Kafka broker.
 	Version int16
@@ -32989,7 +33006,7 @@ type DescribeQuorumRequest struct {
 }
 
 func (*DescribeQuorumRequest) Key() int16                 { return 55 }
-func (*DescribeQuorumRequest) MaxVersion() int16          { return 0 }
+func (*DescribeQuorumRequest) MaxVersion() int16          { return 1 }
 func (v *DescribeQuorumRequest) SetVersion(version int16) { v.Version = version }
 func (v *DescribeQuorumRequest) GetVersion() int16        { return v.Version }
 func (v *DescribeQuorumRequest) IsFlexible() bool         { return v.Version >= 0 }
@@ -33230,7 +33247,7 @@ type DescribeQuorumResponse struct {
 }
 
 func (*DescribeQuorumResponse) Key() int16                 { return 55 }
-func (*DescribeQuorumResponse) MaxVersion() int16          { return 0 }
+func (*DescribeQuorumResponse) MaxVersion() int16          { return 1 }
 func (v *DescribeQuorumResponse) SetVersion(version int16) { v.Version = version }
 func (v *DescribeQuorumResponse) GetVersion() int16        { return v.Version }
 func (v *DescribeQuorumResponse) IsFlexible() bool         { return v.Version >= 0 }
@@ -33310,6 +33327,14 @@ func (v *DescribeQuorumResponse) AppendTo(dst []byte) []byte {
 								v := v.LogEndOffset
 								dst = kbin.AppendInt64(dst, v)
 							}
+							if version >= 1 {
+								v := v.LastFetchTimestamp
+								dst = kbin.AppendInt64(dst, v)
+							}
+							if version >= 1 {
+								v := v.LastCaughtUpTimestamp
+								dst = kbin.AppendInt64(dst, v)
+							}
 							if isFlexible {
 								dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
 								dst = v.UnknownTags.AppendEach(dst)
@@ -33333,6 +33358,14 @@ func (v *DescribeQuorumResponse) AppendTo(dst []byte) []byte {
 								v := v.LogEndOffset
 								dst = kbin.AppendInt64(dst, v)
 							}
+							if version >= 1 {
+								v := v.LastFetchTimestamp
+								dst = kbin.AppendInt64(dst, v)
+							}
+							if version >= 1 {
+								v := v.LastCaughtUpTimestamp
+								dst = kbin.AppendInt64(dst, v)
+							}
 							if isFlexible {
 								dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
 								dst = v.UnknownTags.AppendEach(dst)
@@ -33464,6 +33497,14 @@ func (v *DescribeQuorumResponse) ReadFrom(src []byte) error {
 								v := b.Int64()
 								s.LogEndOffset = v
 							}
+							if version >= 1 {
+								v := b.Int64()
+								s.LastFetchTimestamp = v
+							}
+							if version >= 1 {
+								v := b.Int64()
+								s.LastCaughtUpTimestamp = v
+							}
 							if isFlexible {
 								s.UnknownTags = internalReadTags(&b)
 							}
@@ -33498,6 +33539,14 @@ func (v *DescribeQuorumResponse) ReadFrom(src []byte) error {
 								v := b.Int64()
 								s.LogEndOffset = v
 							}
+							if version >= 1 {
+								v := b.Int64()
+								s.LastFetchTimestamp = v
+							}
+							if version >= 1 {
+								v := b.Int64()
+								s.LastCaughtUpTimestamp = v
+							}
 							if isFlexible {
 								s.UnknownTags = internalReadTags(&b)
 							}
@@ -77,6 +77,33 @@ func (a Auth) AsSha512Mechanism() sasl.Mechanism {
 	})
 }
 
+// TokenAuth returns Auth for authenticating with a delegation token (KIP-48).
+// The token ID is used as the user, the base64 encoded token HMAC is used as
+// the password, and IsToken is set to true.
+func TokenAuth(tokenID string, mac []byte) Auth {
+	return Auth{
+		User:    tokenID,
+		Pass:    base64.StdEncoding.EncodeToString(mac),
+		IsToken: true,
+	}
+}
+
+// TokenSha256 returns a SCRAM-SHA-256 sasl mechanism that authenticates with
+// the given delegation token ID and HMAC for all sasl sessions.
+//
+// This is a shortcut for TokenAuth(tokenID, mac).AsSha256Mechanism().
+func TokenSha256(tokenID string, mac []byte) sasl.Mechanism {
+	return TokenAuth(tokenID, mac).AsSha256Mechanism()
+}
+
+// TokenSha512 returns a SCRAM-SHA-512 sasl mechanism that authenticates with
+// the given delegation token ID and HMAC for all sasl sessions.
+//
+// This is a shortcut for TokenAuth(tokenID, mac).AsSha512Mechanism().
+func TokenSha512(tokenID string, mac []byte) sasl.Mechanism {
+	return TokenAuth(tokenID, mac).AsSha512Mechanism()
+}
+
 // Sha256 returns a SCRAM-SHA-256 sasl mechanism that will call authFn
 // whenever authentication is needed. The returned Auth is used for a single
 // session.